@@ -75,20 +75,18 @@ AWS_DEFAULT_REGION=us-east-1`)
 
 //parse a docker image into it's constituent parts: ${repo}:${version}-${prerelease}
 func parseDockerImage(image string) (string, string, string) {
-	repoVersion := strings.Split(image, ":")
+	parsedImage, err := parseImage(image)
+	check(err)
 	version := "latest"
 	pre := ""
-	if len(repoVersion) > 1 {
-		versionPrerelease := strings.Split(repoVersion[1], "-")
+	if parsedImage.Tag != "" {
+		versionPrerelease := strings.SplitN(parsedImage.Tag, "-", 2)
 		version = versionPrerelease[0]
-		if version == "" {
-			version = "latest"
-		}
-		if len(versionPrerelease) > 0 {
-			pre = strings.Join(versionPrerelease[1:], "-")
+		if len(versionPrerelease) > 1 {
+			pre = versionPrerelease[1]
 		}
 	}
-	return repoVersion[0], version, pre
+	return parsedImage.Repository, version, pre
 }
 
 func getConfigEnv(app string, env string, repo string, version string) string {
@@ -5,6 +5,7 @@ import (
 	"html/template"
 	"log"
 	"os"
+	"sort"
 	"strings"
 	"text/tabwriter"
 
@@ -13,10 +14,10 @@ import (
 
 //BuildTokenOutput represents an object that can be written to stdout and formatted
 type BuildTokenOutput struct {
-	Shipment    string
-	Environment string
-	CiCdEnvVar  string
-	Token       string
+	Shipment    string `json:"shipment"`
+	Environment string `json:"environment"`
+	CiCdEnvVar  string `json:"cicdEnvVar"`
+	Token       string `json:"token"`
 }
 
 var listEnvironmentOverride string
@@ -118,7 +119,7 @@ func envBuildTokens(cmd *cobra.Command, args []string) {
 	//ensure user is logged in
 	username, authToken, err := Login()
 	check(err)
-	harborCompose := DeserializeHarborCompose(HarborComposeFile)
+	harborCompose := loadHarborCompose()
 	if harborCompose.Shipments == nil || len(harborCompose.Shipments) == 0 {
 		fmt.Println("no shipments found")
 		return
@@ -127,7 +128,8 @@ func envBuildTokens(cmd *cobra.Command, args []string) {
 	for name, shipment := range harborCompose.Shipments {
 		
 		//fetch build token
-		shipmentObject := GetShipmentEnvironment(username, authToken, name, shipment.Env)
+		shipmentObject, err := GetShipmentEnvironment(username, authToken, name, shipment.Env)
+		check(err)
 		if shipmentObject == nil {
 			continue
 		}
@@ -144,7 +146,7 @@ func listBuildTokens(cmd *cobra.Command, args []string) {
 	//ensure user is logged in
 	username, authToken, err := Login()
 	check(err)
-	harborCompose := DeserializeHarborCompose(HarborComposeFile)
+	harborCompose := loadHarborCompose()
 	if harborCompose.Shipments == nil || len(harborCompose.Shipments) == 0 {
 		fmt.Println("no shipments found")
 		return
@@ -156,18 +158,29 @@ func listBuildTokens(cmd *cobra.Command, args []string) {
 
 func internalListBuildTokens(shipmentMap map[string]ComposeShipment, username string, authToken string) {
 
+	//collected for --output and --format
+	tokens := []interface{}{}
+
 	//print table header
 	const padding = 3
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, padding, ' ', tabwriter.DiscardEmptyColumns)
-	fmt.Println("")
-	fmt.Fprintln(w, "SHIPMENT\tENVIRONMENT\tCICD_ENVAR\tTOKEN\t")
+	if !isStructuredOutput() {
+		fmt.Println("")
+		fmt.Fprintln(w, "SHIPMENT\tENVIRONMENT\tCICD_ENVAR\tTOKEN\t")
+	}
 
 	//create a formatted template
 	tmpl, err := template.New("shipment-token").Parse("{{.Shipment}}\t{{.Environment}}\t{{.CiCdEnvVar}}\t{{.Token}}")
 	check(err)
 
-	//iterate shipments
-	for shipmentName, shipment := range shipmentMap {
+	//iterate shipments (sorted for consistent output)
+	shipmentNames := []string{}
+	for shipmentName := range shipmentMap {
+		shipmentNames = append(shipmentNames, shipmentName)
+	}
+	sort.Strings(shipmentNames)
+	for _, shipmentName := range shipmentNames {
+		shipment := shipmentMap[shipmentName]
 
 		//allow --env flag to override environment specified in compose file
 		shipmentEnv := shipment.Env
@@ -176,7 +189,8 @@ func internalListBuildTokens(shipmentMap map[string]ComposeShipment, username st
 		}
 
 		//fetch the current state
-		shipmentObject := GetShipmentEnvironment(username, authToken, shipmentName, shipmentEnv)
+		shipmentObject, err := GetShipmentEnvironment(username, authToken, shipmentName, shipmentEnv)
+		check(err)
 		if shipmentObject == nil {
 			continue
 		}
@@ -189,12 +203,22 @@ func internalListBuildTokens(shipmentMap map[string]ComposeShipment, username st
 			Token:       shipmentObject.BuildToken,
 		}
 
+		if isStructuredOutput() {
+			tokens = append(tokens, output)
+			continue
+		}
+
 		//execute the template with the data
 		err = tmpl.Execute(w, output)
 		check(err)
 		fmt.Fprintln(w)
 	}
 
+	if isStructuredOutput() {
+		check(writeOutput(os.Stdout, tokens))
+		return
+	}
+
 	//flush the writer
 	w.Flush()
 	fmt.Println("")
@@ -4,7 +4,6 @@ import (
 	"errors"
 	"fmt"
 	"log"
-	"strings"
 
 	"github.com/spf13/cobra"
 )
@@ -26,6 +25,7 @@ MSS_APP_WEB_DEV_TOKEN=xyz harbor-compose catalog
 }
 
 func init() {
+	addEnvFlag(catalogCmd)
 	RootCmd.AddCommand(catalogCmd)
 }
 
@@ -35,7 +35,7 @@ func init() {
 func catalog(cmd *cobra.Command, args []string) {
 
 	//read the compose files
-	dockerCompose, harborCompose := unmarshalComposeFiles(DockerComposeFile, HarborComposeFile)
+	dockerCompose, harborCompose := unmarshalComposeFiles(DockerComposeFile)
 
 	//validate the compose file
 	_, err := dockerCompose.Config()
@@ -56,12 +56,15 @@ func catalog(cmd *cobra.Command, args []string) {
 				check(errors.New("could not find service in docker compose file"))
 			}
 
-			//parse image:tag
-			parsedImage := strings.Split(serviceConfig.Image, ":")
-			tag := parsedImage[1]
+			//parse image:tag (or digest)
+			parsedImage, err := parseVersionedImage(serviceConfig.Image)
+			check(err)
+			tag := parsedImage.Version()
 
 			//lookup container image in the catalog and catalog if missing
-			if !IsContainerVersionCataloged(containerName, tag) {
+			cataloged, err := IsContainerVersionCataloged(containerName, tag)
+			check(err)
+			if !cataloged {
 
 				if Verbose {
 					log.Printf("cataloging container: %v\n", containerName)
@@ -3,7 +3,6 @@ package cmd
 import (
 	"fmt"
 	"log"
-	"os"
 
 	yaml "gopkg.in/yaml.v2"
 
@@ -19,21 +18,26 @@ func unmarshalDockerCompose(yamlString string) project.APIProject {
 		log.Printf("unmarshalDockerCompose - %v", yamlString)
 	}
 
+	dockerCompose, err := parseDockerCompose(yamlString)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return dockerCompose
+}
+
+//parses a docker compose yaml string into a compose APIProject
+func parseDockerCompose(yamlString string) (project.APIProject, error) {
 	yamlBits := []byte(yamlString)
 
 	//use libcompose to parse compose yml
 	bytes := [][]byte{yamlBits}
-	dockerCompose, err := docker.NewProject(&ctx.Context{
+	return docker.NewProject(&ctx.Context{
 		Context: project.Context{
 			ComposeBytes: bytes,
 			ProjectName:  "required",
 		},
 	}, nil)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	return dockerCompose
 }
 
 //unmarshal harbor compose yaml
@@ -54,18 +58,17 @@ func unmarshalCompose(dockerComposeYaml string, harborComposeYaml string) (proje
 	return dc, hc
 }
 
-//unmarshals both docker compose and harbor compose yaml files
-func unmarshalComposeFiles(dockerComposeFile string, harborComposeFile string) (project.APIProject, HarborCompose) {
+//unmarshals a docker compose file and the (merged) harbor compose files
+func unmarshalComposeFiles(dockerComposeFile string) (project.APIProject, HarborCompose) {
 	dc := DeserializeDockerCompose(dockerComposeFile)
-	hc := DeserializeHarborCompose(harborComposeFile)
+	hc := loadHarborCompose()
 	return dc, hc
 }
 
-func getDockerComposeService(dockerCompose project.APIProject, container string) *config.ServiceConfig {
+func getDockerComposeService(dockerCompose project.APIProject, container string) (*config.ServiceConfig, error) {
 	serviceConfig, success := dockerCompose.GetServiceConfig(container)
 	if !success {
-		fmt.Printf("ERROR: Container: %v defined in %v cannot be found in %v\n", container, HarborComposeFile, DockerComposeFile)
-		os.Exit(-1)
+		return nil, fmt.Errorf("container: %v defined in %v cannot be found in %v", container, HarborComposeFile, DockerComposeFile)
 	}
-	return serviceConfig
+	return serviceConfig, nil
 }
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// composeConfigCmd represents the config command
+var composeConfigCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Validate and view the harbor compose file",
+	Long: `Validate and view the harbor compose file
+
+Outputs the fully resolved harbor compose configuration after all of the files specified with -c have been merged (in order) and variables have been interpolated.
+
+When -c isn't specified, harbor-compose.yml is used along with harbor-compose.override.yml (if it exists).
+
+Variables (${VAR}, ${VAR:-default}, ${VAR:?error}) are resolved from the shell environment, falling back to a .env file next to the first harbor compose file.
+
+Shipment environments are resolved using --env (or each shipment's env).`,
+	Example: `harbor-compose config
+harbor-compose config -c harbor-compose.yml -c harbor-compose.prod.yml
+harbor-compose config --env prod`,
+	Run:    composeConfig,
+	PreRun: preRunHook,
+}
+
+func init() {
+	addEnvFlag(composeConfigCmd)
+	RootCmd.AddCommand(composeConfigCmd)
+}
+
+func composeConfig(cmd *cobra.Command, args []string) {
+	harborCompose := loadHarborCompose()
+	fmt.Print(string(marshalHarborCompose(harborCompose)))
+}
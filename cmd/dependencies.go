@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+)
+
+//groups shipments into tiers using their dependsOn settings
+//shipments only depend on shipments in earlier tiers and are sorted by name within a tier
+func shipmentTiers(harborCompose HarborCompose) ([][]string, error) {
+
+	//every dependency must be a shipment in the compose file
+	for _, name := range sortedShipmentNames(harborCompose) {
+		for _, dependency := range harborCompose.Shipments[name].DependsOn {
+			if _, ok := harborCompose.Shipments[dependency]; !ok {
+				return nil, fmt.Errorf(messageDependencyNotFound, name, dependency)
+			}
+		}
+	}
+
+	tiers := [][]string{}
+	placed := map[string]bool{}
+	remaining := sortedShipmentNames(harborCompose)
+
+	for len(remaining) > 0 {
+		tier := []string{}
+		next := []string{}
+		for _, name := range remaining {
+			if dependenciesPlaced(harborCompose.Shipments[name].DependsOn, placed) {
+				tier = append(tier, name)
+			} else {
+				next = append(next, name)
+			}
+		}
+
+		//nothing can be placed when the remaining shipments depend on each other
+		if len(tier) == 0 {
+			return nil, dependencyCycleError{Cycle: findDependencyCycle(harborCompose, next)}
+		}
+
+		for _, name := range tier {
+			placed[name] = true
+		}
+		tiers = append(tiers, tier)
+		remaining = next
+	}
+
+	return tiers, nil
+}
+
+//dependencyCycleError is returned when shipments depend on each other
+type dependencyCycleError struct {
+	//the shipments in the cycle, starting and ending with the same shipment (e.g., [a b a])
+	Cycle []string
+}
+
+func (e dependencyCycleError) Error() string {
+	return fmt.Sprintf(messageDependencyCycle, strings.Join(e.Cycle, " -> "))
+}
+
+func dependenciesPlaced(dependencies []string, placed map[string]bool) bool {
+	for _, dependency := range dependencies {
+		if !placed[dependency] {
+			return false
+		}
+	}
+	return true
+}
+
+//returns a dependency cycle (e.g., [a b a]) among shipments that can't be placed in a tier
+func findDependencyCycle(harborCompose HarborCompose, remaining []string) []string {
+	unplaced := map[string]bool{}
+	for _, name := range remaining {
+		unplaced[name] = true
+	}
+
+	//every unplaced shipment depends on another unplaced shipment,
+	//so following those dependencies must eventually revisit one
+	path := []string{}
+	visited := map[string]int{}
+	name := remaining[0]
+	for {
+		if i, ok := visited[name]; ok {
+			return append(path[i:], name)
+		}
+		visited[name] = len(path)
+		path = append(path, name)
+
+		dependencies := append([]string{}, harborCompose.Shipments[name].DependsOn...)
+		sort.Strings(dependencies)
+		for _, dependency := range dependencies {
+			if unplaced[dependency] {
+				name = dependency
+				break
+			}
+		}
+	}
+}
+
+//returns the shipments in a harbor compose file as tiers of tasks (in dependency order)
+func composeShipmentTiers(harborCompose HarborCompose) ([][]shipmentTask, error) {
+	tiers, err := shipmentTiers(harborCompose)
+	if err != nil {
+		return nil, err
+	}
+
+	//shipments that others depend on need to be healthy before the next tier starts
+	dependedOn := map[string]bool{}
+	for _, shipment := range harborCompose.Shipments {
+		for _, dependency := range shipment.DependsOn {
+			dependedOn[dependency] = true
+		}
+	}
+
+	result := [][]shipmentTask{}
+	for _, tier := range tiers {
+		tasks := []shipmentTask{}
+		for _, name := range tier {
+			shipment := harborCompose.Shipments[name]
+			tasks = append(tasks, shipmentTask{Shipment: name, Env: shipment.Env, Config: shipment, HasDependents: dependedOn[name]})
+		}
+		result = append(result, tasks)
+	}
+	return result, nil
+}
+
+//runs fn for each tier of tasks in order
+//a tier only starts once every shipment in the previous tier has finished.
+//once every tier has finished, a summary of all of the results is printed
+func runShipmentTiers(tiers [][]shipmentTask, fn shipmentFunc) {
+	out := shipmentProgressWriter()
+	results := runShipmentTierWorkers(tiers, fn, parallelism, out)
+	exitOnShipmentFailure(out, results)
+}
+
+//runs fn for each tier of tasks in order and returns the results in tier order
+//shipments that depend on a shipment that failed (or was skipped) are skipped
+func runShipmentTierWorkers(tiers [][]shipmentTask, fn shipmentFunc, workers int, out io.Writer) []shipmentResult {
+	results := []shipmentResult{}
+	failed := map[string]bool{}
+
+	for i, tier := range tiers {
+		if Verbose && len(tiers) > 1 {
+			log.Printf("processing tier %v of %v", i+1, len(tiers))
+		}
+
+		tierResults := map[string]shipmentResult{}
+		tasks := []shipmentTask{}
+		for _, task := range tier {
+			if dependency := failedDependency(task.Config.DependsOn, failed); dependency != "" {
+				err := fmt.Errorf(messageDependencyFailed, dependency)
+				fmt.Fprintf(out, "Skipping %v %v: %v\n", task.Shipment, task.Env, err)
+				tierResults[task.Shipment] = shipmentResult{Shipment: task.Shipment, Env: task.Env, Err: err, Skipped: true}
+				continue
+			}
+			tasks = append(tasks, task)
+		}
+
+		for _, result := range runShipmentWorkers(tasks, fn, workers, out) {
+			tierResults[result.Shipment] = result
+		}
+
+		for _, task := range tier {
+			result := tierResults[task.Shipment]
+			if result.Err != nil {
+				failed[task.Shipment] = true
+			}
+			results = append(results, result)
+		}
+	}
+
+	return results
+}
+
+//returns the first dependency that failed (or was skipped)
+func failedDependency(dependencies []string, failed map[string]bool) string {
+	for _, dependency := range dependencies {
+		if failed[dependency] {
+			return dependency
+		}
+	}
+	return ""
+}
@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func getDependencyCompose(t *testing.T, harborComposeYaml string) HarborCompose {
+	var harborCompose HarborCompose
+	assert.Nil(t, yaml.Unmarshal([]byte(harborComposeYaml), &harborCompose))
+	return harborCompose
+}
+
+func TestShipmentTiers(t *testing.T) {
+	harborCompose := getDependencyCompose(t, `shipments:
+  web:
+    env: dev
+    dependsOn:
+    - api
+    - auth
+  api:
+    env: dev
+    dependsOn:
+    - db
+  auth:
+    env: dev
+  db:
+    env: dev
+  worker:
+    env: dev
+`)
+
+	tiers, err := shipmentTiers(harborCompose)
+	assert.Nil(t, err)
+	assert.Equal(t, [][]string{
+		{"auth", "db", "worker"},
+		{"api"},
+		{"web"},
+	}, tiers)
+
+	tasks, err := composeShipmentTiers(harborCompose)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(tasks))
+	assert.True(t, tasks[0][0].HasDependents)  //auth
+	assert.True(t, tasks[0][1].HasDependents)  //db
+	assert.False(t, tasks[0][2].HasDependents) //worker
+	assert.True(t, tasks[1][0].HasDependents)  //api
+	assert.False(t, tasks[2][0].HasDependents) //web
+	assert.Equal(t, "dev", tasks[2][0].Env)
+}
+
+func TestShipmentTiersNoDependencies(t *testing.T) {
+	harborCompose := getDependencyCompose(t, `shipments:
+  b:
+    env: dev
+  a:
+    env: dev
+`)
+
+	tiers, err := shipmentTiers(harborCompose)
+	assert.Nil(t, err)
+	assert.Equal(t, [][]string{{"a", "b"}}, tiers)
+}
+
+func TestShipmentTiersCycle(t *testing.T) {
+	harborCompose := getDependencyCompose(t, `shipments:
+  a:
+    env: dev
+  b:
+    env: dev
+    dependsOn:
+    - c
+  c:
+    env: dev
+    dependsOn:
+    - d
+  d:
+    env: dev
+    dependsOn:
+    - b
+  e:
+    env: dev
+    dependsOn:
+    - b
+`)
+
+	_, err := shipmentTiers(harborCompose)
+	assert.EqualError(t, err, fmt.Sprintf(messageDependencyCycle, "b -> c -> d -> b"))
+}
+
+func TestShipmentTiersSelfDependency(t *testing.T) {
+	harborCompose := getDependencyCompose(t, `shipments:
+  a:
+    env: dev
+    dependsOn:
+    - a
+`)
+
+	_, err := shipmentTiers(harborCompose)
+	assert.EqualError(t, err, fmt.Sprintf(messageDependencyCycle, "a -> a"))
+}
+
+func TestShipmentTiersNotFound(t *testing.T) {
+	harborCompose := getDependencyCompose(t, `shipments:
+  web:
+    env: dev
+    dependsOn:
+    - api
+`)
+
+	_, err := shipmentTiers(harborCompose)
+	assert.EqualError(t, err, fmt.Sprintf(messageDependencyNotFound, "web", "api"))
+}
+
+func TestLintDependencies(t *testing.T) {
+	dockerComposeYaml := `version: "2"
+services:
+  web:
+    image: registry/web:1.0
+    ports:
+    - 80:5000
+    environment:
+      HEALTHCHECK: /hc
+`
+	harborComposeYaml := `shipments:
+  mss-app-api:
+    env: dev
+    barge: corp-sandbox
+    containers:
+    - web
+    dependsOn:
+    - mss-app-web
+  mss-app-web:
+    env: dev
+    barge: corp-sandbox
+    containers:
+    - web
+    dependsOn:
+    - mss-app-api
+    - mss-app-db
+`
+
+	findings := getLintFindings(t, dockerComposeYaml, harborComposeYaml)
+	assert.Equal(t, 1, len(findings))
+	assert.Equal(t, 16, findings[0].Line)
+	assert.Equal(t, fmt.Sprintf(messageDependencyNotFound, "mss-app-web", "mss-app-db"), findings[0].Message)
+
+	//cycles are reported once the unknown dependency is fixed
+	harborComposeYaml = harborComposeYaml[:len(harborComposeYaml)-len("    - mss-app-db\n")]
+	findings = getLintFindings(t, dockerComposeYaml, harborComposeYaml)
+	assert.Equal(t, 1, len(findings))
+	assert.Equal(t, 7, findings[0].Line)
+	assert.Equal(t, fmt.Sprintf(messageDependencyCycle, "mss-app-api -> mss-app-web -> mss-app-api"), findings[0].Message)
+}
+
+func TestRunShipmentTierWorkers(t *testing.T) {
+	tiers := [][]shipmentTask{
+		{{Shipment: "api", Env: "dev"}, {Shipment: "db", Env: "dev"}},
+		{{Shipment: "web", Env: "dev", Config: ComposeShipment{DependsOn: []string{"api"}}}, {Shipment: "worker", Env: "dev", Config: ComposeShipment{DependsOn: []string{"db"}}}},
+		{{Shipment: "cdn", Env: "dev", Config: ComposeShipment{DependsOn: []string{"web"}}}},
+	}
+
+	for _, workers := range []int{1, 2} {
+		ran := []string{}
+		var mu sync.Mutex
+		var out bytes.Buffer
+		results := runShipmentTierWorkers(tiers, func(out io.Writer, task shipmentTask) error {
+			mu.Lock()
+			ran = append(ran, task.Shipment)
+			mu.Unlock()
+			if task.Shipment == "api" {
+				return errors.New("boom")
+			}
+			return nil
+		}, workers, &out)
+
+		//dependents of the failed shipment (directly or indirectly) are skipped
+		sort.Strings(ran)
+		assert.Equal(t, []string{"api", "db", "worker"}, ran)
+
+		//every tier's results are returned in tier order
+		assert.Equal(t, 5, len(results))
+		assert.Equal(t, "api", results[0].Shipment)
+		assert.EqualError(t, results[0].Err, "boom")
+		assert.False(t, results[0].Skipped)
+		assert.Equal(t, "db", results[1].Shipment)
+		assert.Nil(t, results[1].Err)
+		assert.Equal(t, "web", results[2].Shipment)
+		assert.True(t, results[2].Skipped)
+		assert.EqualError(t, results[2].Err, fmt.Sprintf(messageDependencyFailed, "api"))
+		assert.Equal(t, "worker", results[3].Shipment)
+		assert.Nil(t, results[3].Err)
+		assert.Equal(t, "cdn", results[4].Shipment)
+		assert.True(t, results[4].Skipped)
+		assert.EqualError(t, results[4].Err, fmt.Sprintf(messageDependencyFailed, "web"))
+
+		assert.Contains(t, out.String(), "Skipping web dev: dependency api did not succeed\n")
+	}
+}
@@ -1,11 +1,14 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"log"
-	"strings"
+	"time"
 
+	"github.com/docker/libcompose/project"
 	"github.com/spf13/cobra"
 )
 
@@ -17,17 +20,21 @@ var deployCmd = &cobra.Command{
 	
 Note that the deploy command is a subset of the up command without updates for environment variables, replicas, barge info, etc.
 
-Also note that a shipment build token is required to be specified as an environment variable using the specific naming convention below.  Shipment build tokens are generated at the environment level so you can use any environment you wish.`,
+Also note that a shipment build token is required to be specified as an environment variable using the specific naming convention below.  Shipment build tokens are generated at the environment level so you can use any environment you wish.
+
+Shipments that list other shipments in dependsOn are deployed after those shipments are healthy (i.e., their replicas from harbor-compose.yml are running the deployed images and are ready).  Shipments whose dependencies fail are skipped.  Use --timeout to control how long to wait.`,
 	Example: `(shipment = mss-app-web):
 MSS_APP_WEB_DEV_TOKEN=xyz harbor-compose deploy`,
 	Run:    deploy,
 	PreRun: preRunHook,
 }
 
-var environmentOverride string
+var deployTimeout time.Duration
 
 func init() {
-	deployCmd.PersistentFlags().StringVarP(&environmentOverride, "env", "e", "", "override the shipment environment specified in the harbor compose file.")
+	deployCmd.PersistentFlags().DurationVarP(&deployTimeout, "timeout", "", 5*time.Minute, "how long to wait for shipments that others depend on to become healthy")
+	addEnvFlag(deployCmd)
+	addParallelFlag(deployCmd)
 	RootCmd.AddCommand(deployCmd)
 }
 
@@ -35,7 +42,7 @@ func init() {
 func deploy(cmd *cobra.Command, args []string) {
 
 	//read the compose files
-	dockerCompose, harborCompose := unmarshalComposeFiles(DockerComposeFile, HarborComposeFile)
+	dockerCompose, harborCompose := unmarshalComposeFiles(DockerComposeFile)
 
 	//validate the compose file
 	_, err := dockerCompose.Config()
@@ -43,9 +50,28 @@ func deploy(cmd *cobra.Command, args []string) {
 		check(errors.New("error parsing compose file" + err.Error()))
 	}
 
+	//shipments are deployed in dependency order
+	tiers, err := composeShipmentTiers(harborCompose)
+	check(err)
+
+	//stored credentials (if any) are used to record history
+	auth, err := readAuthFile()
+	if err != nil && Verbose {
+		log.Println("not logged in, skipping history")
+	}
+
 	//iterate shipments
-	for shipmentName, shipment := range harborCompose.Shipments {
-		fmt.Printf("deploying images for shipment: %v %v ...\n", shipmentName, shipment.Env)
+	runShipmentTiers(tiers, func(out io.Writer, task shipmentTask) error {
+		shipmentName, shipment := task.Shipment, task.Config
+		fmt.Fprintf(out, "deploying images for shipment: %v %v ...\n", shipmentName, shipment.Env)
+
+		//the --env flag is applied when loading the harbor compose file
+		shipmentEnv := shipment.Env
+
+		//record the current state so that changes can be rolled back
+		if auth != nil {
+			recordDeployRevision(auth, shipmentName, shipmentEnv, shipment, dockerCompose)
+		}
 
 		// loop over containers in docker-compose file
 		for _, containerName := range shipment.Containers {
@@ -53,15 +79,21 @@ func deploy(cmd *cobra.Command, args []string) {
 			//lookup the container in the list of services in the docker-compose file
 			serviceConfig, found := dockerCompose.GetServiceConfig(containerName)
 			if !found {
-				check(errors.New("could not find service in docker compose file"))
+				return errors.New("could not find service in docker compose file")
 			}
 
-			//parse image:tag and map to name/version
-			parsedImage := strings.Split(serviceConfig.Image, ":")
-			tag := parsedImage[1]
+			//parse image:tag (or digest) and map to name/version
+			parsedImage, err := parseVersionedImage(serviceConfig.Image)
+			if err != nil {
+				return err
+			}
+			tag := parsedImage.Version()
 
 			//lookup container image in the catalog and catalog if missing
-			catalog := !IsContainerVersionCataloged(containerName, tag)
+			cataloged, err := IsContainerVersionCataloged(containerName, tag)
+			if err != nil {
+				return err
+			}
 
 			//now deploy container
 			if Verbose {
@@ -72,22 +104,61 @@ func deploy(cmd *cobra.Command, args []string) {
 				Name:    containerName,
 				Image:   serviceConfig.Image,
 				Version: tag,
-				Catalog: catalog,
-			}
-
-			//allow --env flag to override environment specified in compose file
-			shipmentEnv := shipment.Env
-			if len(environmentOverride) > 0 {
-				shipmentEnv = environmentOverride
+				Catalog: !cataloged,
 			}
 
 			//get for envvar for this shipment/environment
 			buildTokenEnvVar := getBuildTokenEnvVar(shipmentName, shipmentEnv)
 
-			Deploy(shipmentName, shipmentEnv, buildTokenEnvVar, deployRequest, "ec2")
+			if err := Deploy(shipmentName, shipmentEnv, buildTokenEnvVar, deployRequest, "ec2"); err != nil {
+				return err
+			}
+		}
+
+		fmt.Fprintln(out, "done")
+
+		//dependencies must be healthy before their dependents are deployed
+		if task.HasDependents {
+			fmt.Fprintf(out, "Waiting for %v %v ...\n", shipmentName, shipmentEnv)
+			return waitForRollout(out, shipmentName, shipmentEnv, shipment.Barge, composeRolloutTarget(shipment, dockerCompose), deployTimeout)
 		}
+		return nil
+	})
+}
 
-		fmt.Println("done")
+//builds a rollout target from the compose files
+//deploy uses a build token, so the shipment environment (and its replicas) can't be fetched from harbor,
+//and deploy doesn't apply the replicas in harbor-compose.yml, so only the images are checked
+func composeRolloutTarget(shipment ComposeShipment, dockerCompose project.APIProject) rolloutTarget {
+	target := rolloutTarget{
+		Images:   map[string]bool{},
+		AnyCount: true,
+	}
+	for _, container := range shipment.Containers {
+		if serviceConfig, found := dockerCompose.GetServiceConfig(container); found {
+			target.Images[serviceConfig.Image] = true
+		}
+	}
+	return target
+}
 
-	} //shipments
+//records a revision before deploying changed images
+//since deploy uses a build token rather than a login, this is only possible when the user has stored credentials
+func recordDeployRevision(auth *Auth, shipmentName string, env string, shipment ComposeShipment, dockerCompose project.APIProject) {
+	current, err := harborClient(auth.Username, auth.Token).Shipit.GetShipmentEnvironment(context.Background(), shipmentName, env)
+	if err != nil {
+		if Verbose {
+			log.Printf("unable to fetch shipment environment, skipping history: %v", err)
+		}
+		return
+	}
+
+	for _, containerName := range shipment.Containers {
+		serviceConfig, found := dockerCompose.GetServiceConfig(containerName)
+		existing := findContainer(containerName, current.Containers)
+		if found && existing != nil && existing.Image != serviceConfig.Image {
+			recordRevision(current, auth.Username, "deploy")
+			return
+		}
+	}
 }
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComposeRolloutTargetReplicasUnset(t *testing.T) {
+	dockerComposeYaml := `version: "2"
+services:
+  web:
+    image: registry/web:2.0
+  worker:
+    image: registry/worker:2.0
+`
+	harborComposeYaml := `shipments:
+  my-app:
+    env: dev
+    barge: corp-sandbox
+    containers:
+    - web
+    - worker
+`
+	dockerCompose, harborCompose := unmarshalCompose(dockerComposeYaml, harborComposeYaml)
+	target := composeRolloutTarget(harborCompose.Shipments["my-app"], dockerCompose)
+	assert.Equal(t, map[string]bool{"registry/web:2.0": true, "registry/worker:2.0": true}, target.Images)
+
+	//harbor runs 2 replicas
+	web := ContainerStatus{ID: "1111111111", Image: "registry/web:2.0", Ready: true, Status: "running"}
+	worker := ContainerStatus{ID: "2222222222", Image: "registry/worker:2.0", Ready: true, Status: "running"}
+	oldWeb := ContainerStatus{ID: "3333333333", Image: "registry/web:1.0", Ready: true, Status: "running"}
+
+	//old containers are still running
+	progress := evaluateRollout(target, getWaitTestStatus(web, worker, oldWeb, worker), map[string]int{})
+	assert.False(t, progress.Done)
+	assert.Equal(t, "3/4 ready, 3/4 on desired image, 0 restarts", progress.String())
+
+	//every container is on the new images
+	progress = evaluateRollout(target, getWaitTestStatus(web, worker, web, worker), map[string]int{})
+	assert.True(t, progress.Done)
+
+	//nothing is running yet
+	progress = evaluateRollout(target, getWaitTestStatus(), map[string]int{})
+	assert.False(t, progress.Done)
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 	"log"
 
 	"github.com/spf13/cobra"
@@ -23,20 +24,25 @@ harbor-compose down -d`,
 
 func init() {
 	downCmd.PersistentFlags().BoolVarP(&deleteShipmentEnvironment, "delete", "d", false, "deletes your shipment environment")
+	addEnvFlag(downCmd)
+	addParallelFlag(downCmd)
 	RootCmd.AddCommand(downCmd)
 }
 
 func down(cmd *cobra.Command, args []string) {
+	check(validateParallel(false))
 
 	username, token, err := Login()
 	check(err)
 
 	//read the harbor compose file
-	var harborCompose = DeserializeHarborCompose(HarborComposeFile)
+	var harborCompose = loadHarborCompose()
 
 	//iterate shipments
-	for shipmentName, shipment := range harborCompose.Shipments {
-		fmt.Printf("Stopping %v %v ...\n", shipmentName, shipment.Env)
+	runShipments(composeShipmentTasks(harborCompose), func(out io.Writer, task shipmentTask) error {
+		shipmentName := task.Shipment
+		shipment := task.Config
+		fmt.Fprintf(out, "Stopping %v %v ...\n", shipmentName, shipment.Env)
 
 		if Verbose {
 			log.Println("processing  " + shipmentName + "/" + shipment.Env)
@@ -48,16 +54,23 @@ func down(cmd *cobra.Command, args []string) {
 			Name:     providerEc2,
 			Replicas: 0,
 		}
-		UpdateProvider(username, token, shipmentName, shipment.Env, provider)
+		if err := UpdateProvider(username, token, shipmentName, shipment.Env, provider); err != nil {
+			return err
+		}
 
 		//trigger shipment
-		Trigger(shipmentName, shipment.Env)
+		if _, _, err := Trigger(shipmentName, shipment.Env); err != nil {
+			return err
+		}
 
 		if deleteShipmentEnvironment {
-			fmt.Printf("Deleting %v %v ...\n", shipmentName, shipment.Env)
-			DeleteShipmentEnvironment(username, token, shipmentName, shipment.Env)
+			fmt.Fprintf(out, "Deleting %v %v ...\n", shipmentName, shipment.Env)
+			if err := DeleteShipmentEnvironment(username, token, shipmentName, shipment.Env); err != nil {
+				return err
+			}
 		}
 
-		fmt.Println("done")
-	}
+		fmt.Fprintln(out, "done")
+		return nil
+	})
 }
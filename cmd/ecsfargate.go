@@ -671,6 +671,7 @@ func migrateImage(image string, accountID string, region string, shipment string
 	//quay.io/turner/xyz:0.1.0
 	//to
 	//${accountID}.dkr.ecr.${region}.amazonaws.com/${shipment}:0.1.0
-	tmp := strings.Split(image, ":")
-	return fmt.Sprintf("%s.dkr.ecr.%s.amazonaws.com/%s:%s", accountID, region, shipment, tmp[len(tmp)-1])
+	parsedImage, err := parseVersionedImage(image)
+	check(err)
+	return fmt.Sprintf("%s.dkr.ecr.%s.amazonaws.com/%s%s", accountID, region, shipment, parsedImage.Suffix())
 }
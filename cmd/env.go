@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
@@ -61,6 +62,8 @@ var envShipment string
 var envEnvironment string
 var envHiddenFile string
 var envEnvFile string
+var envPrune bool
+var envYes bool
 
 func init() {
 	RootCmd.AddCommand(envCmd)
@@ -68,20 +71,24 @@ func init() {
 	//list
 	envCmd.AddCommand(listEnvCmd)
 	listEnvCmd.PersistentFlags().StringVarP(&envShipment, "shipment", "s", "", "shipment name")
-	listEnvCmd.PersistentFlags().StringVarP(&envEnvironment, "environment", "e", "", "environment name")
+	listEnvCmd.PersistentFlags().StringVarP(&envEnvironment, "environment", "e", "", "environment name (or --env)")
 
 	//push
 	envCmd.AddCommand(pushEnvCmd)
 	pushEnvCmd.PersistentFlags().StringVarP(&envShipment, "shipment", "s", "", "shipment name")
-	pushEnvCmd.PersistentFlags().StringVarP(&envEnvironment, "environment", "e", "", "environment name")
+	pushEnvCmd.PersistentFlags().StringVarP(&envEnvironment, "environment", "e", "", "environment name (or --env)")
 	pushEnvCmd.PersistentFlags().StringVarP(&envHiddenFile, "hidden", "", hiddenEnvFileName, "The location of the docker compose environment file that contains hidden environment variables")
+	pushEnvCmd.PersistentFlags().BoolVarP(&envPrune, "prune", "", false, "delete env vars that no longer exist locally")
+	pushEnvCmd.PersistentFlags().BoolVarP(&envYes, "yes", "y", false, "don't ask for confirmation before deleting env vars")
 
 	//pull
 	envCmd.AddCommand(pullEnvCmd)
 	pullEnvCmd.PersistentFlags().StringVarP(&envShipment, "shipment", "s", "", "shipment name")
-	pullEnvCmd.PersistentFlags().StringVarP(&envEnvironment, "environment", "e", "", "environment name")
+	pullEnvCmd.PersistentFlags().StringVarP(&envEnvironment, "environment", "e", "", "environment name (or --env)")
 	pullEnvCmd.PersistentFlags().StringVarP(&envHiddenFile, "hidden", "", hiddenEnvFileName, "The location of the docker compose environment file that contains hidden environment variables")
 	pullEnvCmd.PersistentFlags().StringVarP(&envEnvFile, "env-file", "", "", "Specify a docker compose env_file to write to rather than writing directly to the docker-compose.yml environment section")
+
+	addEnvAlias(envCmd)
 }
 
 // envCmd represents the env command
@@ -110,6 +117,7 @@ Use the --shipment and --environment flags to specify a shipment/environment oth
 `,
 	Example: `harbor-compose env ls
 harbor-compose env ls -s my-app -e dev
+harbor-compose env ls --output json
 `,
 	Run:    listEnvVars,
 	PreRun: preRunHook,
@@ -124,12 +132,18 @@ var pushEnvCmd = &cobra.Command{
 The push command takes all of the environment variables accessible by docker-compose and uploads them to Harbor.  Note that this command does not trigger a deployment.
 
 The push command works with a harbor-compose.yml file to push environment variables for one or many shipment/environment/containers, as well as for a single shipment environment using the --shipment and --environment flags.
+
+The --prune flag deletes environment variables that exist in Harbor but no longer exist locally.  Reserved environment variables (e.g., BARGE, PRODUCT, LOGS_ENDPOINT, etc.) are never deleted.  Shipment/environment level environment variables are only pruned when using a harbor-compose.yml file.
 `,
 	Example: `harbor-compose env push
 harbor-compose env push -s my-shipment -e dev
 
 You can specify which env file contains your hidden environment variables using the --hidden flag (defaults to hidden.env)
 harbor-compose env push --hidden secrets.env
+
+Delete environment variables that were removed from your compose files
+harbor-compose env push --prune
+harbor-compose env push --prune --yes
 `,
 	Run:    pushEnvVars,
 	PreRun: preRunHook,
@@ -170,17 +184,26 @@ func listEnvVars(cmd *cobra.Command, args []string) {
 	//determine which shipment/environments user wants to process
 	inputShipmentEnvironments, _ := getShipmentEnvironmentsFromInput(envShipment, envEnvironment)
 
+	//collected for --output and --format
+	output := []interface{}{}
+
 	//iterate shipment/environments
 	for _, shipmentEnv := range inputShipmentEnvironments {
 		shipment := shipmentEnv.Item1
 		env := shipmentEnv.Item2
 
-		fmt.Printf("SHIPMENT: %v\n", shipment)
-		fmt.Printf("ENVIRONMENT: %v\n", env)
+		if !isStructuredOutput() {
+			fmt.Printf("SHIPMENT: %v\n", shipment)
+			fmt.Printf("ENVIRONMENT: %v\n", env)
+		}
 
 		//lookup the shipment environment
-		shipmentEnvironment := GetShipmentEnvironment(username, token, shipment, env)
+		shipmentEnvironment, err := GetShipmentEnvironment(username, token, shipment, env)
+		check(err)
 		if shipmentEnvironment == nil {
+			if isStructuredOutput() {
+				check(errors.New(messageShipmentEnvironmentNotFound))
+			}
 			fmt.Println(messageShipmentEnvironmentNotFound)
 			return
 		}
@@ -192,6 +215,15 @@ func listEnvVars(cmd *cobra.Command, args []string) {
 				envvarsToPrint = append(envvarsToPrint, envvar)
 			}
 		}
+
+		if isStructuredOutput() {
+			output = append(output, getEnvVarOutputs(shipment, env, "", envvarsToPrint)...)
+			for _, container := range shipmentEnvironment.Containers {
+				output = append(output, getEnvVarOutputs(shipment, env, container.Name, container.EnvVars)...)
+			}
+			continue
+		}
+
 		if len(envvarsToPrint) > 0 {
 			printEnvVars(envvarsToPrint)
 		} else {
@@ -204,6 +236,25 @@ func listEnvVars(cmd *cobra.Command, args []string) {
 			printEnvVars(container.EnvVars)
 		}
 	}
+
+	if isStructuredOutput() {
+		check(writeOutput(os.Stdout, output))
+	}
+}
+
+//returns objects representing env vars (without special env vars and with hidden values masked)
+func getEnvVarOutputs(shipment string, env string, container string, envvars []EnvVarPayload) []interface{} {
+	result := []interface{}{}
+	for _, envvar := range envvars {
+		if specialEnvVars()[envvar.Name] != "" {
+			continue
+		}
+		if envvar.Type == "hidden" {
+			envvar.Value = planHiddenValue
+		}
+		result = append(result, EnvVarOutput{Shipment: shipment, Environment: env, Container: container, EnvVarPayload: envvar})
+	}
+	return result
 }
 
 func printEnvVars(envvars []EnvVarPayload) {
@@ -250,12 +301,16 @@ func pushEnvVars(cmd *cobra.Command, args []string) {
 		env := t.Item2
 
 		//lookup the shipment environment
-		shipmentEnvironment := GetShipmentEnvironment(username, token, shipment, env)
+		shipmentEnvironment, err := GetShipmentEnvironment(username, token, shipment, env)
+		check(err)
 		if shipmentEnvironment == nil {
 			fmt.Println(messageShipmentEnvironmentNotFound)
 			return
 		}
 
+		//env var changes are computed locally and applied together
+		changes := []envVarChange{}
+
 		//iterate containers
 		for _, container := range shipmentEnvironment.Containers {
 			if Verbose {
@@ -263,19 +318,14 @@ func pushEnvVars(cmd *cobra.Command, args []string) {
 			}
 
 			//lookup the container in the list of services in the docker-compose file
-			serviceConfig := getDockerComposeService(dc, container.Name)
+			serviceConfig, err := getDockerComposeService(dc, container.Name)
+			check(err)
 
-			//translate docker envvars to harbor
+			//translate docker envvars to harbor and diff against the current ones
 			harborEnvVars := transformDockerServiceEnvVarsToHarborEnvVarsHidden(serviceConfig, envHiddenFile)
-			for _, envvar := range harborEnvVars {
-				if envvar.Name != "" {
-					if Verbose {
-						log.Printf("processing %s (%s)", envvar.Name, envvar.Type)
-					}
-
-					//save the envvar
-					SaveEnvVar(username, token, shipment, env, envvar, container.Name)
-				}
+			changes = append(changes, diffEnvVarChanges(container.Name, container.EnvVars, harborEnvVars)...)
+			if envPrune {
+				changes = append(changes, pruneEnvVarChanges(container.Name, container.EnvVars, harborEnvVars)...)
 			}
 		}
 
@@ -286,19 +336,19 @@ func pushEnvVars(cmd *cobra.Command, args []string) {
 			}
 
 			//lookup current shipment in harbor-compose.yml
-			for evName, evValue := range harborComposeConfig.Shipments[shipment].Environment {
-				if Verbose {
-					log.Println("processing " + evName)
-				}
-
-				//translate to a a harbor basic env var
-				envVarPayload := envVar(evName, evValue)
-
-				//save the envvar
-				SaveEnvVar(username, token, shipment, env, envVarPayload, "")
+			environment := basicEnvVars(harborComposeConfig.Shipments[shipment].Environment)
+			changes = append(changes, diffEnvVarChanges("", shipmentEnvironment.EnvVars, environment)...)
+			if envPrune {
+				changes = append(changes, pruneEnvVarChanges("", shipmentEnvironment.EnvVars, environment)...)
+			}
+		}
 
-			} //envvars
+		if envPrune {
+			changes = confirmEnvVarPrune(os.Stdout, changes, envYes)
 		}
+
+		//save all env var changes
+		check(syncEnvVars(username, token, shipment, env, changes))
 	}
 
 	fmt.Println("done")
@@ -332,7 +382,8 @@ func pullEnvVars(cmd *cobra.Command, args []string) {
 		env := t.Item2
 
 		//fetch the shipment environment from the backend
-		shipmentEnvironment := GetShipmentEnvironment(username, token, shipment, env)
+		shipmentEnvironment, err := GetShipmentEnvironment(username, token, shipment, env)
+		check(err)
 		if shipmentEnvironment == nil {
 			fmt.Println(messageShipmentEnvironmentNotFound)
 			return
@@ -385,9 +436,12 @@ func pullEnvVars(cmd *cobra.Command, args []string) {
 	} //shipment/env to process
 
 	//output harbor-compose.yml, if not using -s -e
-	if localHarborCompose != nil {
+	//(merged or interpolated files are left alone so that overrides and variables aren't lost)
+	if localHarborCompose != nil && isHarborComposeWritable() {
 		content := marshalHarborCompose(*localHarborCompose)
 		outputFile(string(content), HarborComposeFile)
+	} else if localHarborCompose != nil {
+		fmt.Printf("not updating %v since it uses overrides or variables\n", HarborComposeFile)
 	}
 
 	//output docker-compose.yml
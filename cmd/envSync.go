@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//maximum number of env var requests that are made at the same time
+var envVarSyncConcurrency = 8
+
+//envVarChange is an env var that needs to be created, updated or deleted (container is empty for environment-level env vars)
+type envVarChange struct {
+	Action    planAction
+	Container string
+	EnvVar    EnvVarPayload
+}
+
+//describes env var changes in log and error messages
+var envVarChangeVerbs = map[planAction]string{
+	planCreate: "creating",
+	planUpdate: "updating",
+	planDelete: "deleting",
+}
+
+func (c envVarChange) String() string {
+	if c.Container == "" {
+		return fmt.Sprintf("%v env var %v", envVarChangeVerbs[c.Action], c.EnvVar.Name)
+	}
+	return fmt.Sprintf("%v env var %v on container %v", envVarChangeVerbs[c.Action], c.EnvVar.Name, c.Container)
+}
+
+//envVarSyncError is the collection of errors that occurred while syncing env vars
+type envVarSyncError []error
+
+func (e envVarSyncError) Error() string {
+	messages := []string{}
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return fmt.Sprintf("%v env var change(s) failed:\n%v", len(e), strings.Join(messages, "\n"))
+}
+
+//computes the creates and updates needed to go from the existing env vars to the desired ones
+func diffEnvVarChanges(container string, existing []EnvVarPayload, desired []EnvVarPayload) []envVarChange {
+	changes := []envVarChange{}
+	current := envVarsByName(existing)
+
+	for _, envVar := range desired {
+		if envVar.Name == "" {
+			continue
+		}
+		e, exists := current[envVar.Name]
+		if !exists {
+			changes = append(changes, envVarChange{Action: planCreate, Container: container, EnvVar: envVar})
+		} else if e.Value != envVar.Value || e.Type != envVar.Type {
+			changes = append(changes, envVarChange{Action: planUpdate, Container: container, EnvVar: envVar})
+		}
+	}
+
+	return changes
+}
+
+//computes the deletes needed to remove existing env vars that are no longer desired
+//reserved env vars (special and log shipping) are never deleted
+func pruneEnvVarChanges(container string, existing []EnvVarPayload, desired []EnvVarPayload) []envVarChange {
+	changes := []envVarChange{}
+	wanted := envVarsByName(desired)
+	special := specialEnvVars()
+	logShipping := logShippingEnvVars()
+
+	for _, envVar := range existing {
+		if _, ok := wanted[envVar.Name]; ok {
+			continue
+		}
+		if _, ok := special[envVar.Name]; ok {
+			continue
+		}
+		if _, ok := logShipping[envVar.Name]; ok {
+			continue
+		}
+		changes = append(changes, envVarChange{Action: planDelete, Container: container, EnvVar: envVar})
+	}
+
+	return changes
+}
+
+//lists env vars that will be deleted (to out) and asks the user to confirm (unless yes is specified)
+//returns the changes without deletes if the user declines
+func confirmEnvVarPrune(out io.Writer, changes []envVarChange, yes bool) []envVarChange {
+	deletes := []envVarChange{}
+	others := []envVarChange{}
+	for _, change := range changes {
+		if change.Action == planDelete {
+			deletes = append(deletes, change)
+		} else {
+			others = append(others, change)
+		}
+	}
+	if len(deletes) == 0 {
+		return changes
+	}
+
+	fmt.Fprintln(out, "The following env vars no longer exist locally and will be deleted:")
+	for _, change := range deletes {
+		if change.Container == "" {
+			fmt.Fprintf(out, "  - %v\n", change.EnvVar.Name)
+		} else {
+			fmt.Fprintf(out, "  - %v (container %v)\n", change.EnvVar.Name, change.Container)
+		}
+	}
+	if yes {
+		return changes
+	}
+
+	fmt.Fprint(out, "Are you sure? (yes/no) ")
+	if askForConfirmation() {
+		return changes
+	}
+	fmt.Fprintln(out, "skipping env var deletes")
+	return others
+}
+
+//converts a map of env vars into basic harbor env vars, ordered by name
+func basicEnvVars(environment map[string]string) []EnvVarPayload {
+	names := []string{}
+	for name := range environment {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := []EnvVarPayload{}
+	for _, name := range names {
+		result = append(result, envVar(name, environment[name]))
+	}
+	return result
+}
+
+//applies env var changes to a shipment environment concurrently and returns all of the errors that occurred
+func syncEnvVars(username string, token string, shipment string, env string, changes []envVarChange) error {
+	if len(changes) == 0 {
+		if Verbose {
+			log.Println("env vars unchanged, skipping")
+		}
+		return nil
+	}
+
+	shipit := harborClient(username, token).Shipit
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	errs := envVarSyncError{}
+	semaphore := make(chan struct{}, envVarSyncConcurrency)
+
+	for _, change := range changes {
+		wg.Add(1)
+		semaphore <- struct{}{}
+
+		go func(change envVarChange) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			if Verbose {
+				log.Println(change)
+			}
+
+			var err error
+			switch change.Action {
+			case planCreate:
+				err = shipit.CreateEnvVar(ctx, shipment, env, change.Container, change.EnvVar)
+			case planUpdate:
+				err = shipit.UpdateEnvVar(ctx, shipment, env, change.Container, change.EnvVar)
+			case planDelete:
+				err = shipit.DeleteEnvVar(ctx, shipment, env, change.Container, change.EnvVar.Name)
+			}
+
+			if err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("%v: %v", change, err))
+				mu.Unlock()
+			}
+		}(change)
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		sort.Slice(errs, func(i, j int) bool {
+			return errs[i].Error() < errs[j].Error()
+		})
+		return errs
+	}
+	return nil
+}
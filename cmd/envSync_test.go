@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/turnerlabs/harbor-compose/harbor/client"
+	"github.com/turnerlabs/harbor-compose/harbor/mockserver"
+)
+
+func TestDiffEnvVarChanges(t *testing.T) {
+	existing := []EnvVarPayload{
+		envVar("SAME", "1"),
+		envVar("CHANGED", "1"),
+		envVar("HIDDEN", "1"),
+		envVar("REMOTE_ONLY", "1"),
+	}
+	desired := []EnvVarPayload{
+		envVar("SAME", "1"),
+		envVar("CHANGED", "2"),
+		envVarHidden("HIDDEN", "1"),
+		envVar("NEW", "1"),
+		envVar("", "ignored"),
+	}
+
+	changes := diffEnvVarChanges("web", existing, desired)
+
+	assert.Equal(t, []envVarChange{
+		{Action: planUpdate, Container: "web", EnvVar: envVar("CHANGED", "2")},
+		{Action: planUpdate, Container: "web", EnvVar: envVarHidden("HIDDEN", "1")},
+		{Action: planCreate, Container: "web", EnvVar: envVar("NEW", "1")},
+	}, changes)
+}
+
+func TestPruneEnvVarChanges(t *testing.T) {
+	existing := []EnvVarPayload{
+		envVar("KEEP", "1"),
+		envVarHidden("REMOVED", "1"),
+		envVar(envVarNameProduct, "product"),
+		envVar(envVarNameRestart, "user_20180101000000"),
+		envVar(envVarNameShipLogs, "true"),
+	}
+	desired := []EnvVarPayload{envVar("KEEP", "2")}
+
+	changes := pruneEnvVarChanges("", existing, desired)
+
+	assert.Equal(t, []envVarChange{
+		{Action: planDelete, EnvVar: envVarHidden("REMOVED", "1")},
+	}, changes)
+}
+
+func TestConfirmEnvVarPruneYes(t *testing.T) {
+	changes := []envVarChange{
+		{Action: planCreate, Container: "web", EnvVar: envVar("NEW", "1")},
+		{Action: planDelete, Container: "web", EnvVar: envVar("OLD", "1")},
+	}
+
+	var out bytes.Buffer
+	assert.Equal(t, changes, confirmEnvVarPrune(&out, changes, true))
+	assert.Equal(t, "The following env vars no longer exist locally and will be deleted:\n  - OLD (container web)\n", out.String())
+}
+
+func TestBasicEnvVars(t *testing.T) {
+	envVars := basicEnvVars(map[string]string{"B": "2", "A": "1"})
+
+	assert.Equal(t, []EnvVarPayload{envVar("A", "1"), envVar("B", "2")}, envVars)
+}
+
+func TestSyncEnvVars(t *testing.T) {
+	server := httptest.NewServer(mockserver.New(&mockserver.Seed{
+		Shipments: []client.ShipmentEnvironment{
+			{
+				Name:           "dev",
+				ParentShipment: client.ParentShipment{Name: "my-app"},
+				EnvVars:        []client.EnvVarPayload{envVar("EXISTING", "1")},
+				Containers:     []client.ContainerPayload{{Name: "web", Image: "registry/app:1.0"}},
+			},
+		},
+	}))
+	defer server.Close()
+
+	defer useMockHarbor(server)()
+
+	changes := []envVarChange{{Action: planUpdate, Container: "", EnvVar: envVar("EXISTING", "2")}}
+	for _, name := range []string{"A", "B", "C", "D", "E", "F", "G", "H", "I", "J"} {
+		changes = append(changes, envVarChange{Action: planCreate, Container: "web", EnvVar: envVar(name, "1")})
+	}
+
+	err := syncEnvVars("user", "token", "my-app", "dev", changes)
+	assert.Nil(t, err)
+
+	shipment, err := GetShipmentEnvironment("user", "token", "my-app", "dev")
+	assert.Nil(t, err)
+	assert.Equal(t, "2", shipment.EnvVars[0].Value)
+	assert.Equal(t, 10, len(shipment.Containers[0].EnvVars))
+
+	//all failures are reported together
+	err = syncEnvVars("user", "token", "my-app", "dev", []envVarChange{
+		{Action: planCreate, Container: "missing", EnvVar: envVar("A", "1")},
+		{Action: planCreate, Container: "web", EnvVar: envVar("A", "1")},
+		{Action: planCreate, Container: "web", EnvVar: envVar("K", "1")},
+	})
+	syncErr, ok := err.(envVarSyncError)
+	assert.True(t, ok)
+	assert.Equal(t, 2, len(syncErr))
+	assert.True(t, strings.HasPrefix(err.Error(), "2 env var change(s) failed"))
+	assert.Contains(t, err.Error(), "creating env var A on container missing")
+	assert.Contains(t, err.Error(), "creating env var A on container web")
+
+	//deletes
+	err = syncEnvVars("user", "token", "my-app", "dev", []envVarChange{
+		{Action: planDelete, Container: "web", EnvVar: envVar("A", "1")},
+		{Action: planDelete, Container: "", EnvVar: envVar("EXISTING", "2")},
+	})
+	assert.Nil(t, err)
+
+	shipment, err = GetShipmentEnvironment("user", "token", "my-app", "dev")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(shipment.EnvVars))
+	assert.Equal(t, 10, len(shipment.Containers[0].EnvVars))
+}
@@ -1,12 +1,17 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"sort"
 	"strings"
+	"sync"
 	"text/tabwriter"
 	"text/template"
+	"time"
 
 	humanize "github.com/dustin/go-humanize"
 	"github.com/spf13/cobra"
@@ -32,6 +37,21 @@ hargor-compose events -m
 # show events for a particular shipment environment
 harbor-compose events --shipment my-shipment --environment dev
 harbor-compose events -s my-shipment -e dev
+
+# output events as json
+harbor-compose events --output json
+
+# show events from the last 10 minutes
+harbor-compose events --since 10m
+
+# print new (or repeated) events as they happen until ctrl-c
+harbor-compose events --watch
+
+# fail a deployment pipeline when warning events happen within 5 minutes of a deploy
+harbor-compose events --watch --fail-on warning --timeout 5m
+
+# fail on specific reasons
+harbor-compose events --watch --fail-on BackOff --fail-on Unhealthy --timeout 5m
 `,
 	Run:    runEvents,
 	PreRun: preRunHook,
@@ -41,17 +61,39 @@ var eventsShipment string
 var eventsEnvironment string
 var eventsType string
 var eventsFullMessage bool
+var eventsWatch bool
+var eventsInterval time.Duration
+var eventsTimeout time.Duration
+var eventsSince string
+var eventsFailOn []string
 
 func init() {
 	eventsCmd.PersistentFlags().StringVarP(&eventsShipment, "shipment", "s", "", "shipment name")
 	eventsCmd.PersistentFlags().StringVarP(&eventsEnvironment, "environment", "e", "", "environment name")
 	eventsCmd.PersistentFlags().StringVarP(&eventsType, "type", "t", "all", "specify what level of events you would like to see (normal, warning, or all)")
 	eventsCmd.PersistentFlags().BoolVarP(&eventsFullMessage, "message", "m", false, "include the full message")
+	eventsCmd.PersistentFlags().BoolVarP(&eventsWatch, "watch", "w", false, "print new (or repeated) events as they happen until ctrl-c")
+	eventsCmd.PersistentFlags().DurationVarP(&eventsInterval, "interval", "", 5*time.Second, "how often to poll for events when using --watch")
+	eventsCmd.PersistentFlags().DurationVarP(&eventsTimeout, "timeout", "", 0, "stop watching after this long (e.g., 5m) and exit successfully")
+	eventsCmd.PersistentFlags().StringVarP(&eventsSince, "since", "", "", "only show events since a timestamp (e.g., 2017-05-01T12:00:00Z) or relative duration (e.g., 10m)")
+	eventsCmd.PersistentFlags().StringArrayVarP(&eventsFailOn, "fail-on", "", []string{}, "exit with an error when an event of this type (e.g., warning) or reason (e.g., BackOff) happens (can be repeated)")
+	addParallelFlag(eventsCmd)
 	RootCmd.AddCommand(eventsCmd)
 }
 
 // events your shipment
 func runEvents(cmd *cobra.Command, args []string) {
+	check(validateParallel(eventsWatch))
+	if eventsWatch && isStructuredOutput() {
+		check(errors.New(messageWatchOutput))
+	}
+
+	var since time.Time
+	if eventsSince != "" {
+		var err error
+		since, err = parseLogTime(eventsSince, time.Now())
+		check(err)
+	}
 
 	//make sure user is authenticated
 	username, token, err := Login()
@@ -60,52 +102,137 @@ func runEvents(cmd *cobra.Command, args []string) {
 	//determine which shipment/environments user wants status for
 	inputShipmentEnvironments, _ := getShipmentEnvironmentsFromInput(eventsShipment, eventsEnvironment)
 
+	if eventsWatch {
+		ctx, cancel := interruptContext()
+		defer cancel()
+		if eventsTimeout > 0 {
+			ctx, cancel = context.WithTimeout(ctx, eventsTimeout)
+			defer cancel()
+		}
+
+		//the watch window starts now unless --since is specified
+		windowStart := since
+		if windowStart.IsZero() {
+			windowStart = time.Now()
+		}
+
+		check(watchEvents(ctx, os.Stdout, getEventTargets(username, token, inputShipmentEnvironments), eventsInterval, since, windowStart, eventsFailOn))
+		return
+	}
+
 	//iterate shipment/environments
-	for _, t := range inputShipmentEnvironments {
-		shipment := t.Item1
-		env := t.Item2
+	var output shipmentOutput
+	var mu sync.Mutex
+	var failure error
+	tasks := tupleShipmentTasks(inputShipmentEnvironments)
+	runShipments(tasks, func(out io.Writer, task shipmentTask) error {
+		shipment := task.Shipment
+		env := task.Env
 
 		//lookup the shipment environment
-		shipmentEnvironment := GetShipmentEnvironment(username, token, shipment, env)
+		shipmentEnvironment, err := GetShipmentEnvironment(username, token, shipment, env)
+		if err != nil {
+			return err
+		}
 		if shipmentEnvironment == nil {
-			fmt.Println(messageShipmentEnvironmentNotFound)
-			return
+			return errors.New(messageShipmentEnvironmentNotFound)
 		}
 
 		//lookup the provider
 		provider := ec2Provider(shipmentEnvironment.Providers)
 
 		//fetch events from helmit
-		events := GetShipmentEvents(provider.Barge, shipment, env)
+		events, err := GetShipmentEvents(provider.Barge, shipment, env)
+		if err != nil {
+			return err
+		}
+
+		//only the events since --since
+		if !since.IsZero() {
+			recent := []ShipmentEvent{}
+			for _, event := range events.Events {
+				if !event.LastTimestamp.Before(since) {
+					recent = append(recent, event)
+				}
+			}
+			events.Events = recent
+		}
 
 		//sort by LastTimestamp (the last time this event happened)
 		sort.Slice(events.Events, func(i, j int) bool {
 			return events.Events[i].LastTimestamp.After(events.Events[j].LastTimestamp)
 		})
 
+		//fail when an event matches --fail-on (after everything is rendered)
+		for _, event := range events.Events {
+			if isEventType(event) && isFailingEvent(event, eventsFailOn, since) {
+				mu.Lock()
+				if failure == nil {
+					failure = fmt.Errorf(messageEventsFailOn, event.Type, event.Reason, shipment, env)
+				}
+				mu.Unlock()
+				break
+			}
+		}
+
 		//render
+		if isStructuredOutput() {
+			for _, event := range events.Events {
+				if isEventType(event) {
+					output.add(task, ShipmentEventOutput{Shipment: shipment, Environment: env, ShipmentEvent: event})
+				}
+			}
+			return nil
+		}
+
 		if len(events.Events) > 0 {
 
 			if eventsFullMessage {
-				printShipmentEventMessages(events)
+				printShipmentEventMessages(out, events)
 			} else {
-				printShipmentEvents(events)
+				printShipmentEvents(out, events)
 			}
-			fmt.Println("-----")
+			fmt.Fprintln(out, "-----")
 
 		} else {
-			fmt.Println("no events found")
-			fmt.Println()
-			fmt.Println("note that events only occur around a deployment or an unhealthy application and eventually disappear when an app becomes healthy")
+			fmt.Fprintln(out, "no events found")
+			fmt.Fprintln(out)
+			fmt.Fprintln(out, "note that events only occur around a deployment or an unhealthy application and eventually disappear when an app becomes healthy")
+		}
+		return nil
+	})
+
+	if isStructuredOutput() {
+		check(output.write(os.Stdout, tasks))
+	}
+	check(failure)
+}
+
+//returns the shipment environments to watch
+func getEventTargets(username string, token string, shipmentEnvironments []tuple) []helmitTarget {
+	targets := []helmitTarget{}
+	for _, t := range shipmentEnvironments {
+		shipmentEnvironment, err := GetShipmentEnvironment(username, token, t.Item1, t.Item2)
+		check(err)
+		if shipmentEnvironment == nil {
+			check(errors.New(messageShipmentEnvironmentNotFound))
 		}
+		provider := ec2Provider(shipmentEnvironment.Providers)
+		targets = append(targets, helmitTarget{Shipment: t.Item1, Env: t.Item2, Barge: provider.Barge})
 	}
+	return targets
+}
+
+//returns true if an event matches the --type flag
+func isEventType(event ShipmentEvent) bool {
+	return strings.ToLower(eventsType) == "all" || strings.ToLower(eventsType) == strings.ToLower(event.Type)
 }
 
-func printShipmentEvents(events *ShipmentEventResult) {
+func printShipmentEvents(out io.Writer, events *ShipmentEventResult) {
 	const padding = 3
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, padding, ' ', tabwriter.DiscardEmptyColumns)
+	w := tabwriter.NewWriter(out, 0, 0, padding, ' ', tabwriter.DiscardEmptyColumns)
 
-	fmt.Println()
+	fmt.Fprintln(out)
 	fmt.Fprintln(w, "TYPE\tREASON\tMESSAGE\tTIME\tCOUNT")
 
 	//create a formatted template
@@ -115,7 +242,7 @@ func printShipmentEvents(events *ShipmentEventResult) {
 	for _, event := range events.Events {
 
 		//filter events for specified type
-		if strings.ToLower(eventsType) != "all" && strings.ToLower(eventsType) != strings.ToLower(event.Type) {
+		if !isEventType(event) {
 			continue
 		}
 
@@ -137,16 +264,16 @@ func printShipmentEvents(events *ShipmentEventResult) {
 	w.Flush()
 }
 
-func printShipmentEventMessages(events *ShipmentEventResult) {
-	fmt.Println()
+func printShipmentEventMessages(out io.Writer, events *ShipmentEventResult) {
+	fmt.Fprintln(out)
 	for _, event := range events.Events {
 
 		//filter events for specified type
-		if strings.ToLower(eventsType) != "all" && strings.ToLower(eventsType) != strings.ToLower(event.Type) {
+		if !isEventType(event) {
 			continue
 		}
 
-		fmt.Printf("%s - %s\n", event.Reason, event.Message)
-		fmt.Println()
+		fmt.Fprintf(out, "%s - %s\n", event.Reason, event.Message)
+		fmt.Fprintln(out)
 	}
 }
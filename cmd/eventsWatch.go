@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+//eventChange is an event that's new (or happened again) since the last poll
+type eventChange struct {
+	Shipment string
+	Env      string
+	ShipmentEvent
+
+	//true the first time an event is seen
+	New bool
+
+	//how many more times the event happened since the last poll
+	CountDelta int
+}
+
+//eventsWatchState tracks events between polls so that only new or changed events are printed
+type eventsWatchState struct {
+	seen map[string]ShipmentEvent
+}
+
+func newEventsWatchState() *eventsWatchState {
+	return &eventsWatchState{seen: map[string]ShipmentEvent{}}
+}
+
+//returns the events that are new or changed since the last poll (oldest first)
+//events are identified by their reason and message, and events that last happened before since are ignored
+func (s *eventsWatchState) changes(shipment string, env string, events []ShipmentEvent, since time.Time) []eventChange {
+	result := []eventChange{}
+	for _, event := range events {
+		if !since.IsZero() && event.LastTimestamp.Before(since) {
+			continue
+		}
+
+		key := strings.Join([]string{shipment, env, event.Reason, event.Message}, "|")
+		previous, seen := s.seen[key]
+		if seen && event.Count <= previous.Count && !event.LastTimestamp.After(previous.LastTimestamp) {
+			continue
+		}
+		s.seen[key] = event
+
+		change := eventChange{Shipment: shipment, Env: env, ShipmentEvent: event, New: !seen}
+		if seen {
+			change.CountDelta = event.Count - previous.Count
+		}
+		result = append(result, change)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].LastTimestamp.Before(result[j].LastTimestamp)
+	})
+	return result
+}
+
+//returns true when an event matches a --fail-on type or reason and happened during the watch window
+func isFailingEvent(event ShipmentEvent, failOn []string, windowStart time.Time) bool {
+	if event.LastTimestamp.Before(windowStart) {
+		return false
+	}
+	for _, value := range failOn {
+		if strings.EqualFold(value, event.Type) || strings.EqualFold(value, event.Reason) {
+			return true
+		}
+	}
+	return false
+}
+
+//formats an event change as a single line
+func formatEventChange(change eventChange) string {
+	count := fmt.Sprintf("x%v", change.Count)
+	if change.CountDelta > 0 {
+		count = fmt.Sprintf("x%v, +%v", change.Count, change.CountDelta)
+	}
+	return fmt.Sprintf("%v  %v %v  %v  %v  %v  (%v)", change.LastTimestamp.Local().Format("15:04:05"), change.Shipment, change.Env, change.Type, change.Reason, change.Message, count)
+}
+
+//polls the events of shipment environments and prints the ones that are new or changed until the context is done
+//returns an error as soon as an event matches --fail-on
+func watchEvents(ctx context.Context, out io.Writer, targets []helmitTarget, interval time.Duration, since time.Time, windowStart time.Time, failOn []string) error {
+	state := newEventsWatchState()
+	for {
+		for _, target := range targets {
+			result, err := harborClient("", "").Helmit.GetShipmentEvents(ctx, target.Barge, target.Shipment, target.Env)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				//keep watching through errors
+				if Verbose {
+					log.Printf("unable to fetch events for %v %v: %v", target.Shipment, target.Env, err)
+				}
+				continue
+			}
+
+			for _, change := range state.changes(target.Shipment, target.Env, result.Events, since) {
+				if !isEventType(change.ShipmentEvent) {
+					continue
+				}
+				fmt.Fprintln(out, formatEventChange(change))
+				if isFailingEvent(change.ShipmentEvent, failOn, windowStart) {
+					return fmt.Errorf(messageEventsFailOn, change.Type, change.Reason, target.Shipment, target.Env)
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/turnerlabs/harbor-compose/harbor/client"
+	"github.com/turnerlabs/harbor-compose/harbor/mockserver"
+)
+
+func TestEventsWatchChanges(t *testing.T) {
+	state := newEventsWatchState()
+	start := time.Date(2017, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	events := []ShipmentEvent{
+		{Type: "Warning", Reason: "BackOff", Message: "back-off restarting", Count: 1, FirstTimestamp: start, LastTimestamp: start.Add(time.Minute)},
+		{Type: "Normal", Reason: "Pulled", Message: "pulled image", Count: 1, FirstTimestamp: start, LastTimestamp: start},
+		{Type: "Normal", Reason: "Scheduled", Message: "assigned to node", Count: 1, FirstTimestamp: start.Add(-time.Hour), LastTimestamp: start.Add(-time.Hour)},
+	}
+
+	//everything is new on the first poll (except events before since), oldest first
+	changes := state.changes("my-app", "dev", events, start.Add(-time.Minute))
+	assert.Equal(t, 2, len(changes))
+	assert.Equal(t, "Pulled", changes[0].Reason)
+	assert.True(t, changes[0].New)
+	assert.Equal(t, "BackOff", changes[1].Reason)
+	assert.Equal(t, "my-app", changes[1].Shipment)
+
+	//nothing changed
+	assert.Equal(t, 0, len(state.changes("my-app", "dev", events, start.Add(-time.Minute))))
+
+	//an event happened again
+	events[0].Count = 3
+	events[0].LastTimestamp = start.Add(2 * time.Minute)
+	changes = state.changes("my-app", "dev", events, start.Add(-time.Minute))
+	assert.Equal(t, 1, len(changes))
+	assert.False(t, changes[0].New)
+	assert.Equal(t, 2, changes[0].CountDelta)
+	assert.True(t, strings.HasSuffix(formatEventChange(changes[0]), "  my-app dev  Warning  BackOff  back-off restarting  (x3, +2)"))
+
+	//the same event in another shipment environment is different
+	changes = state.changes("my-app", "qa", events[:1], time.Time{})
+	assert.Equal(t, 1, len(changes))
+	assert.True(t, changes[0].New)
+	assert.True(t, strings.HasSuffix(formatEventChange(changes[0]), "  my-app qa  Warning  BackOff  back-off restarting  (x3)"))
+}
+
+func TestIsFailingEvent(t *testing.T) {
+	start := time.Date(2017, 5, 1, 12, 0, 0, 0, time.UTC)
+	event := ShipmentEvent{Type: "Warning", Reason: "Unhealthy", LastTimestamp: start}
+
+	assert.True(t, isFailingEvent(event, []string{"warning"}, start))
+	assert.True(t, isFailingEvent(event, []string{"BackOff", "unhealthy"}, start))
+	assert.False(t, isFailingEvent(event, []string{"BackOff"}, start))
+	assert.False(t, isFailingEvent(event, []string{}, start))
+
+	//events before the window don't fail
+	assert.False(t, isFailingEvent(event, []string{"warning"}, start.Add(time.Second)))
+}
+
+func TestWatchEventsMockServer(t *testing.T) {
+	now := time.Now()
+	server := httptest.NewServer(mockserver.New(&mockserver.Seed{
+		Events: map[string][]client.ShipmentEvent{
+			"my-app/dev": {
+				{Type: "Normal", Reason: "Pulled", Message: "pulled image", Count: 1, FirstTimestamp: now, LastTimestamp: now},
+			},
+			"my-api/dev": {
+				{Type: "Normal", Reason: "Pulled", Message: "pulled image", Count: 1, FirstTimestamp: now, LastTimestamp: now},
+				{Type: "Warning", Reason: "BackOff", Message: "back-off restarting", Count: 4, FirstTimestamp: now.Add(-time.Hour), LastTimestamp: now.Add(-time.Hour)},
+			},
+		},
+	}))
+	defer server.Close()
+
+	defer useMockHarbor(server)()
+
+	//runs until the timeout when nothing fails
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	var out bytes.Buffer
+	targets := []helmitTarget{{Shipment: "my-app", Env: "dev", Barge: "corp-sandbox"}}
+	err := watchEvents(ctx, &out, targets, 10*time.Millisecond, time.Time{}, now.Add(-time.Minute), []string{"warning"})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, strings.Count(out.String(), "\n"))
+	assert.Contains(t, out.String(), "my-app dev  Normal  Pulled  pulled image  (x1)")
+
+	//warnings from before the window are printed but don't fail
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	out.Reset()
+	targets = append(targets, helmitTarget{Shipment: "my-api", Env: "dev", Barge: "corp-sandbox"})
+	err = watchEvents(ctx, &out, targets, 10*time.Millisecond, time.Time{}, now.Add(-time.Minute), []string{"warning"})
+	assert.Nil(t, err)
+	assert.Contains(t, out.String(), "my-api dev  Warning  BackOff")
+
+	//warnings in the window fail
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	out.Reset()
+	err = watchEvents(ctx, &out, targets, 10*time.Millisecond, time.Time{}, now.Add(-2*time.Hour), []string{"warning"})
+	assert.EqualError(t, err, "Warning event BackOff found for my-api dev")
+	assert.Nil(t, ctx.Err())
+}
@@ -63,7 +63,8 @@ func generate(cmd *cobra.Command, args []string) {
 	if Verbose {
 		log.Printf("fetching shipment...")
 	}
-	shipmentObject := GetShipmentEnvironment(username, token, shipment, env)
+	shipmentObject, err := GetShipmentEnvironment(username, token, shipment, env)
+	check(err)
 	if shipmentObject == nil {
 		fmt.Println(messageShipmentEnvironmentNotFound)
 		return
@@ -238,6 +239,13 @@ func transformShipmentToHarborCompose(shipmentObject *ShipmentEnvironment) Harbo
 		composeShipment.Containers = append(composeShipment.Containers, container.Name)
 	}
 
+	//iam role
+	composeShipment.IamRole = shipmentObject.IamRole
+
+	//port settings that differ from the defaults
+	composeShipment.LoadBalancer = transformLoadBalancerToCompose(shipmentObject.Containers)
+	composeShipment.Ports = transformPortsToCompose(shipmentObject.Containers, composeShipment.LoadBalancer)
+
 	//add single shipment to list
 	harborCompose.Shipments[shipmentObject.ParentShipment.Name] = composeShipment
 
@@ -272,7 +280,7 @@ func transformShipmentToDockerComposeWithEnvFile(shipmentObject *ShipmentEnviron
 		}
 
 		//populate ports
-		for _, port := range container.Ports {
+		for i, port := range container.Ports {
 
 			//format = external:internal
 			if port.PublicPort == 0 {
@@ -281,10 +289,12 @@ func transformShipmentToDockerComposeWithEnvFile(shipmentObject *ShipmentEnviron
 			dockerPort := fmt.Sprintf("%v:%v", port.PublicPort, port.Value)
 			service.Ports = append(service.Ports, dockerPort)
 
-			//set container env vars for healthcheck, and port
+			//set container env vars for healthcheck, and port (from the first port)
 			//so that apps can simulate running in harbor
-			service.Environment["PORT"] = strconv.Itoa(port.Value)
-			service.Environment["HEALTHCHECK"] = port.Healthcheck
+			if i == 0 {
+				service.Environment["PORT"] = strconv.Itoa(port.Value)
+				service.Environment["HEALTHCHECK"] = port.Healthcheck
+			}
 		}
 
 		//copy shipment, environment, provider level env vars down to the
@@ -2,765 +2,281 @@ package cmd
 
 import (
 	"bufio"
-	"encoding/json"
-	"errors"
+	"context"
 	"fmt"
 	"log"
-	"net/http"
-	"strings"
+	"os"
 
-	"github.com/jtacoma/uritemplates"
-	"github.com/parnurzeal/gorequest"
+	"github.com/turnerlabs/harbor-compose/harbor/client"
 )
 
-func shipitURI(template string, params ...tuple) string {
-	return buildURI(GetConfig().ShipitURI, template, params...)
-}
+//harborClient returns a harbor api client for a user (tests can swap this out for fakes)
+var harborClient = newHarborClient
 
-func helmitURI(template string, params ...tuple) string {
-	return buildURI(GetConfig().HelmitURI, template, params...)
-}
+//builds a harbor api client using the endpoints in the harbor config
+func newHarborClient(username string, token string) *client.Client {
+	config := GetConfig()
 
-func triggerURI(template string, params ...tuple) string {
-	return buildURI(GetConfig().TriggerURI, template, params...)
-}
+	clientConfig := client.Config{
+		ShipitURI:    config.ShipitURI,
+		HelmitURI:    config.HelmitURI,
+		TriggerURI:   config.TriggerURI,
+		CustomsURI:   config.CustomsURI,
+		CatalogitURI: config.CatalogitURI,
+		BargesURI:    config.BargesURI,
+		Username:     username,
+		Token:        token,
+	}
+
+	if Verbose {
+		clientConfig.Logger = log.New(os.Stderr, "", log.LstdFlags)
+	}
 
-func customsURI(template string, params ...tuple) string {
-	return buildURI(GetConfig().CustomsURI, template, params...)
+	return client.New(clientConfig)
 }
 
+//builds a uri for a file or api hosted on the barges endpoint
 func bargesURI(template string, params ...tuple) string {
 	return buildURI(GetConfig().BargesURI, template, params...)
 }
 
 // GetShipmentEnvironment returns a harbor shipment from the API
-func GetShipmentEnvironment(username string, token string, shipment string, env string) *ShipmentEnvironment {
+func GetShipmentEnvironment(username string, token string, shipment string, env string) (*ShipmentEnvironment, error) {
 
-	//build URI
-	uri := shipitURI("/v1/shipment/{shipment}/environment/{env}/",
-		param("shipment", shipment),
-		param("env", env))
-
-	if Verbose {
-		fmt.Println("fetching: " + uri)
-	}
-
-	//issue request
-	request := gorequest.New().Get(uri)
-
-	//if token is specified, add it to the headers
-	if token != "" {
-		request = request.
-			Set("x-username", username).
-			Set("x-token", token)
-	}
-
-	resp, body, err := request.EndBytes()
-	if err != nil {
-		check(err[0])
-	}
+	result, err := harborClient(username, token).Shipit.GetShipmentEnvironment(context.Background(), shipment, env)
 
 	//return nil if the shipment/env isn't found
-	if resp.StatusCode == http.StatusNotFound {
-		return nil
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		log.Fatal("GetShipment returned ", resp.StatusCode)
+	if err == client.ErrNotFound {
+		return nil, nil
 	}
 
-	//deserialize json into object
-	var result ShipmentEnvironment
-	unmarshalErr := json.Unmarshal(body, &result)
-	check(unmarshalErr)
-
-	return &result
+	return result, err
 }
 
 //UpdateProvider updates provider configuration
-func UpdateProvider(username string, token string, shipment string, env string, provider ProviderPayload) {
-
-	uri := shipitURI("/v1/shipment/{shipment}/environment/{env}/provider/ec2",
-		param("shipment", shipment),
-		param("env", env))
+func UpdateProvider(username string, token string, shipment string, env string, provider ProviderPayload) error {
 
 	if Verbose {
-		log.Printf("updating replicas on shipment provider: " + uri)
+		log.Printf("updating replicas on shipment provider")
 	}
 
-	//call the API
-	r, _, e := update(username, token, uri, provider)
-	if e != nil {
-		check(e[0])
-	}
-	if r.StatusCode != http.StatusOK {
-		check(errors.New("update provider failed"))
-	}
+	return harborClient(username, token).Shipit.UpdateProvider(context.Background(), shipment, env, provider)
 }
 
 //UpdateShipmentEnvironment updates shipment/environment-level configuration
-func UpdateShipmentEnvironment(username string, token string, shipment string, composeShipment ComposeShipment) {
-
-	//update enableMonitoring
-	uri := shipitURI("/v1/shipment/{shipment}/environment/{env}",
-		param("shipment", shipment),
-		param("env", composeShipment.Env))
-
-	if Verbose {
-		log.Printf("updating enableMonitoring on shipment provider: " + uri)
-	}
-
-	request := UpdateShipmentEnvironmentRequest{
-		EnableMonitoring: *composeShipment.EnableMonitoring,
-	}
-
-	//call the API
-	r, _, e := update(username, token, uri, request)
-	if e != nil {
-		check(e[0])
-	}
-	if r.StatusCode != http.StatusOK {
-		check(errors.New("update provider failed"))
-	}
-}
-
-func create(username string, token string, url string, data interface{}) (*http.Response, string, []error) {
-
-	if Verbose {
-		log.Printf("POST %v", url)
-	}
-
-	res, body, err := gorequest.New().
-		Post(url).
-		Set("x-username", username).
-		Set("x-token", token).
-		Send(data).
-		End()
-
-	if err != nil {
-		check(err[0])
-	}
-
-	if Verbose {
-		log.Printf("status code = %v", res.StatusCode)
-		log.Println(body)
-	}
-
-	return res, body, err
-}
-
-func update(username string, token string, url string, data interface{}) (*http.Response, string, []error) {
-
-	if Verbose {
-		log.Printf("PUT %v", url)
-		if b, e := json.Marshal(data); e == nil {
-			log.Println(string(b))
-		}
-	}
-
-	res, body, err := gorequest.New().
-		Put(url).
-		Set("x-username", username).
-		Set("x-token", token).
-		Send(data).
-		End()
-
-	if err != nil {
-		check(err[0])
-	}
+func UpdateShipmentEnvironment(username string, token string, shipment string, env string, request UpdateShipmentEnvironmentRequest) error {
 
 	if Verbose {
-		log.Println(body)
-		log.Printf("status code = %v", res.StatusCode)
+		log.Printf("updating shipment environment configuration")
 	}
 
-	return res, body, err
+	return harborClient(username, token).Shipit.UpdateShipmentEnvironment(context.Background(), shipment, env, request)
 }
 
-func deleteHTTP(username string, token string, url string) (*http.Response, string, []error) {
+// GetLogs returns all container logs for a shipment
+func GetLogs(barge string, shipment string, env string) (*HelmitResponse, error) {
 
 	if Verbose {
-		log.Printf("DELETE %v", url)
-	}
-
-	res, body, err := gorequest.New().
-		Delete(url).
-		Set("x-username", username).
-		Set("x-token", token).
-		End()
-
-	if err != nil {
-		check(err[0])
-	}
-
-	if Verbose {
-		log.Printf("status code = %v", res.StatusCode)
-		log.Println(body)
-	}
-
-	return res, body, err
-}
-
-// GetLogs returns a string of all container logs for a shipment
-func GetLogs(barge string, shipment string, env string) string {
-
-	uri := helmitURI("/harbor/{barge}/{shipment}/{env}",
-		param("barge", barge),
-		param("shipment", shipment),
-		param("env", env))
-
-	_, body, err := gorequest.New().
-		Get(uri).
-		End()
-
-	if err != nil {
-		check(err[0])
-	}
-
-	if Verbose {
-		fmt.Println(uri)
 		fmt.Println("Fetching Harbor Logs")
 	}
 
-	return body
+	return harborClient("", "").Helmit.GetLogs(context.Background(), barge, shipment, env)
 }
 
 // GetLogStreamer return reader object to parse docker container logs
 func GetLogStreamer(streamer string) (reader *bufio.Reader, err error) {
-	resp, err := http.Get(streamer)
-
+	stream, err := harborClient("", "").Helmit.GetLogStream(context.Background(), streamer)
 	if err != nil {
 		return
 	}
 
-	reader = bufio.NewReader(resp.Body)
+	reader = bufio.NewReader(stream)
 	return
 }
 
 // GetShipmentEvents returns a ShipmentEventResult for a given shipment/environment
-func GetShipmentEvents(barge string, shipment string, env string) *ShipmentEventResult {
-
-	uri := helmitURI("/shipment/events/{barge}/{shipment}/{env}",
-		param("barge", barge),
-		param("shipment", shipment),
-		param("env", env))
-
-	if Verbose {
-		fmt.Println("fetching: " + uri)
-	}
-
-	res, body, err := gorequest.New().
-		Get(uri).
-		EndBytes()
-
-	if err != nil {
-		check(err[0])
-	}
-
-	if res.StatusCode != http.StatusOK {
-		log.Fatal("GetShipmentEvents returned ", res.StatusCode)
-	}
-
-	//deserialize json into object
-	var result ShipmentEventResult
-	unmarshalErr := json.Unmarshal(body, &result)
-	check(unmarshalErr)
-
-	return &result
+func GetShipmentEvents(barge string, shipment string, env string) (*ShipmentEventResult, error) {
+	return harborClient("", "").Helmit.GetShipmentEvents(context.Background(), barge, shipment, env)
 }
 
 // GetShipmentStatus returns the running status of a shipment
-func GetShipmentStatus(barge string, shipment string, env string) *ShipmentStatus {
-
-	uri := helmitURI("/shipment/status/{barge}/{shipment}/{env}",
-		param("barge", barge),
-		param("shipment", shipment),
-		param("env", env))
-
-	if Verbose {
-		fmt.Println("fetching: " + uri)
-	}
-
-	res, body, err := gorequest.New().
-		Get(uri).
-		EndBytes()
-
-	if err != nil {
-		check(err[0])
-	}
-
-	if res.StatusCode != http.StatusOK {
-		log.Fatal("GetShipmentStatus returned ", res.StatusCode)
-	}
-
-	//deserialize json into object
-	var result ShipmentStatus
-	unmarshalErr := json.Unmarshal(body, &result)
-	check(unmarshalErr)
-
-	return &result
+func GetShipmentStatus(barge string, shipment string, env string) (*ShipmentStatus, error) {
+	return harborClient("", "").Helmit.GetShipmentStatus(context.Background(), barge, shipment, env)
 }
 
 // Trigger calls the trigger api
-func Trigger(shipment string, env string) (bool, []string) {
-
-	//build URI
-	uri := triggerURI("/{shipment}/{env}/ec2",
-		param("shipment", shipment),
-		param("env", env))
+//non-OK responses are logged and reported as unsuccessful rather than as an error
+func Trigger(shipment string, env string) (bool, []string, error) {
 
 	if Verbose {
-		log.Printf("triggering shipment: " + uri)
+		log.Printf("triggering shipment: %v %v", shipment, env)
 	}
 
-	//make network request
-	resp, body, err := gorequest.New().
-		Post(uri).
-		EndBytes()
+	messages, err := harborClient("", "").Trigger.Trigger(context.Background(), shipment, env)
 
-	//handle errors
+	//log non-OK status codes and message body
+	if apiErr, ok := err.(*client.APIError); ok {
+		log.Printf("trigger api returned a %v", apiErr.StatusCode)
+		log.Println(apiErr.Body)
+		return false, nil, nil
+	}
 	if err != nil {
 		log.Println("an error occurred calling trigger api")
-		check(err[0])
-	}
-
-	//if verbose or non-OK, log status code and message body
-	if Verbose || resp.StatusCode != http.StatusOK {
-		log.Printf("trigger api returned a %v", resp.StatusCode)
-		log.Println(string(body))
-	}
-
-	var result []string
-
-	//parse http OK responses as JSON
-	if resp.StatusCode == http.StatusOK {
-		//trigger api returns both single and multiple messages:
-
-		//example responses...
-		//error: {"message":"Could not parse docker image data from http://registry.services.dmtio.net/v2/mss-poc-thingproxy/manifests/: 757: unexpected token at '404 page not found\n'\n"}
-		//success: {"message":["compose-test.dev.services.ec2.dmtio.net:5000"]}
-
-		//single message
-		if strings.Contains(string(body), "message\":\"") {
-			//convert single message into an array for consistency
-			var response TriggerResponseSingle
-			unmarshalErr := json.Unmarshal(body, &response)
-			check(unmarshalErr)
-			result = append(result, response.Message)
-		} else if strings.Contains(string(body), "message\":[") {
-			//multiple messages
-			var response TriggerResponseMultiple
-			unmarshalErr := json.Unmarshal(body, &response)
-			check(unmarshalErr)
-			result = response.Messages
-		}
+		return false, nil, err
 	}
 
 	//return whether trigger call was successful along with messages
-	return resp.StatusCode == http.StatusOK, result
+	return true, messages, nil
 }
 
 // SaveEnvVar updates an environment variable in harbor (supports both environment and container levels)
-func SaveEnvVar(username string, token string, shipment string, environment string, envVarPayload EnvVarPayload, container string) {
-	var config = GetConfig()
+func SaveEnvVar(username string, token string, shipment string, environment string, envVarPayload EnvVarPayload, container string) error {
 
 	//first, issue a GET to check if the var exists
 	//if not exists, issue a POST
 	//if exists and value has changed, issue a PUT
 
-	//build url
-	templateStringEnvLevelExisting := config.ShipitURI + "/v1/shipment/{shipment}/environment/{env}/envvar/{envvar}"
-	templateStringContainerLevelExisting := config.ShipitURI + "/v1/shipment/{shipment}/environment/{env}/container/{container}/envvar/{envvar}"
-	templateStringEnvLevelNew := config.ShipitURI + "/v1/shipment/{shipment}/environment/{env}/envvars/"
-	templateStringContainerLevelNew := config.ShipitURI + "/v1/shipment/{shipment}/environment/{env}/container/{container}/envvars/"
-
-	values := make(map[string]interface{})
-	values["shipment"] = shipment
-	values["env"] = environment
-	values["envvar"] = envVarPayload.Name
-	values["container"] = container
-
-	//is the var at the environment or container level?
-	templateString := templateStringEnvLevelExisting
-	if len(container) > 0 {
-		templateString = templateStringContainerLevelExisting
-	}
-	template, _ := uritemplates.Parse(templateString)
-	uri, _ := template.Expand(values)
+	ctx := context.Background()
+	shipit := harborClient(username, token).Shipit
 
-	//issue GET request
-	request := gorequest.New().Get(uri).
-		Set("x-username", username).
-		Set("x-token", token)
-
-	if Verbose {
-		fmt.Println("fetching: " + uri)
-	}
-	res, body, err := request.EndBytes()
-	if err != nil {
-		check(err[0])
-	}
+	result, err := shipit.GetEnvVar(ctx, shipment, environment, container, envVarPayload.Name)
 
 	//exist?
-	if res.StatusCode == http.StatusNotFound { //not exist
-		//build url
-		//now POST a new envvar
-		//is the var at the environment or container level?
-		templateString := templateStringEnvLevelNew
-		if len(container) > 0 {
-			templateString = templateStringContainerLevelNew
-		}
-		template, _ = uritemplates.Parse(templateString)
-		uri, _ = template.Expand(values)
-
+	if err == client.ErrNotFound { //not exist
 		if Verbose {
 			fmt.Println("creating env var...")
 		}
+		return shipit.CreateEnvVar(ctx, shipment, environment, container, envVarPayload)
+	}
+	if err != nil {
+		return err
+	}
 
-		//call the api
-		r, _, e := create(username, token, uri, envVarPayload)
-		if e != nil {
-			check(e[0])
-		}
-		if r.StatusCode != http.StatusCreated {
-			check(errors.New("unable to create env var"))
+	//modified?
+	if result.Value != envVarPayload.Value || result.Type != envVarPayload.Type {
+		if Verbose {
+			fmt.Println("updating env var...")
 		}
+		return shipit.UpdateEnvVar(ctx, shipment, environment, container, envVarPayload)
 
-	} else { //exist
-		//issue PUT if modified
-
-		//deserialize json into object
-		var result EnvVarPayload
-		unmarshalErr := json.Unmarshal(body, &result)
-		check(unmarshalErr)
-
-		//modified?
-		if result.Value != envVarPayload.Value || result.Type != envVarPayload.Type {
-			if Verbose {
-				fmt.Println("updating env var...")
-			}
-
-			//is the var at the environment or container level?
-			templateString := templateStringEnvLevelExisting
-			if len(container) > 0 {
-				templateString = templateStringContainerLevelExisting
-			}
-			template, _ = uritemplates.Parse(templateString)
-			uri, _ = template.Expand(values)
-			r, _, e := update(username, token, uri, envVarPayload)
-			if e != nil {
-				check(e[0])
-			}
-			if r.StatusCode != http.StatusOK {
-				check(errors.New("unable to update env var"))
-			}
-
-		} else {
-			if Verbose {
-				fmt.Println("envvar unchanged, skipping")
-			}
-		}
+	} else if Verbose {
+		fmt.Println("envvar unchanged, skipping")
 	}
+	return nil
 }
 
 // UpdateContainerImage updates a container version on a shipment
-func UpdateContainerImage(username string, token string, shipment string, env string, container ContainerPayload) {
-
-	//build url
-	uri := shipitURI("/v1/shipment/{shipment}/environment/{env}/container/{container}",
-		param("shipment", shipment),
-		param("env", env),
-		param("container", container.Name))
+func UpdateContainerImage(username string, token string, shipment string, env string, container ContainerPayload) error {
 
 	if Verbose {
 		log.Printf("updating container settings")
 	}
 
-	//call api
-	r, _, err := update(username, token, uri, container)
-	if err != nil {
-		check(err[0])
-	}
-	if r.StatusCode != http.StatusOK {
-		check(errors.New("update failed"))
-	}
+	return harborClient(username, token).Shipit.UpdateContainer(context.Background(), shipment, env, container)
 }
 
-// SaveNewShipmentEnvironment bulk saves a new shipment/environment
-func SaveNewShipmentEnvironment(username string, token string, shipment ShipmentEnvironment) bool {
-
-	var config = GetConfig()
-	shipment.Username = username
-	shipment.Token = token
-
-	//POST /api/v1/shipments
-	res, body, err := create(username, token, config.ShipitURI+"/v1/bulk/shipments", shipment)
-
-	if err != nil || res.StatusCode != http.StatusCreated {
-		fmt.Printf("creating shipment was not successful: %v\ncode: %v\n", body, res.StatusCode)
-		return false
-	}
-
-	//api returns an object with an errors property that is
-	//false when there are no errors and an object if there are
-	if !strings.Contains(body, "errors\": false") {
-		return false
-	}
-
-	return true
-}
-
-// DeleteShipmentEnvironment deletes a shipment/environment from harbor
-func DeleteShipmentEnvironment(username string, token string, shipment string, env string) {
-
-	//build URI
-	uri := shipitURI("/v1/shipment/{shipment}/environment/{env}",
-		param("shipment", shipment),
-		param("env", env))
+// AddContainer adds a container (along with its ports) to an existing shipment environment
+func AddContainer(username string, token string, shipment string, env string, container ContainerPayload) error {
 
 	if Verbose {
-		log.Printf("deleting: " + uri)
+		log.Printf("adding container %v", container.Name)
 	}
 
-	res, _, _ := deleteHTTP(username, token, uri)
+	shipit := harborClient(username, token).Shipit
+	ctx := context.Background()
 
-	if res.StatusCode != http.StatusOK {
-		log.Fatalf("delete returned a status code of %v", res.StatusCode)
+	if err := shipit.CreateContainer(ctx, shipment, env, container); err != nil {
+		return err
 	}
+	for _, port := range container.Ports {
+		if err := shipit.CreatePort(ctx, shipment, env, container.Name, port); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-// Catalogit sends a POST to the catalogit api
-func Catalogit(container CatalogitContainer) (string, []error) {
-
-	var config = GetConfig()
+// RemoveContainer removes a container from an existing shipment environment
+func RemoveContainer(username string, token string, shipment string, env string, container string) error {
 
 	if Verbose {
-		log.Printf("Sending POST to: %v /v1/containers\n", config.CatalogitURI)
-	}
-
-	//make network request
-	resp, body, err := gorequest.New().
-		Post(config.CatalogitURI + "/v1/containers").
-		Send(container).
-		EndBytes()
-
-	//treat non-OK as error
-	if resp.StatusCode != http.StatusOK {
-		err = append(err, fmt.Errorf("catalogit api returned a %v", resp.StatusCode))
+		log.Printf("removing container %v", container)
 	}
 
-	return string(body), err
+	return harborClient(username, token).Shipit.DeleteContainer(context.Background(), shipment, env, container)
 }
 
-//IsContainerVersionCataloged determines whether or not a container/version exists in the catalog
-func IsContainerVersionCataloged(name string, version string) bool {
+// SaveNewShipmentEnvironment bulk saves a new shipment/environment
+func SaveNewShipmentEnvironment(username string, token string, shipment ShipmentEnvironment) error {
+	return harborClient(username, token).Shipit.CreateShipmentEnvironment(context.Background(), shipment)
+}
 
-	//build URI
-	uri := customsURI("/catalog/{name}/{version}/",
-		param("name", name),
-		param("version", version))
+// DeleteShipmentEnvironment deletes a shipment/environment from harbor
+func DeleteShipmentEnvironment(username string, token string, shipment string, env string) error {
 
 	if Verbose {
-		log.Println("fetching: " + uri)
+		log.Printf("deleting: %v %v", shipment, env)
 	}
 
-	//issue request
-	res, _, err := gorequest.New().Get(uri).EndBytes()
+	err := harborClient(username, token).Shipit.DeleteShipmentEnvironment(context.Background(), shipment, env)
 	if err != nil {
-		check(err[0])
-	}
-
-	//not found
-	if res.StatusCode == 404 {
-		return false
+		return fmt.Errorf("delete failed: %v", err)
 	}
+	return nil
+}
 
-	//throw error if not OK
-	if res.StatusCode != http.StatusOK {
-		log.Fatalf("GET %v returned %v", uri, res.StatusCode)
-	}
+// Catalogit sends a POST to the catalogit api
+func Catalogit(container CatalogitContainer) (string, error) {
+	return harborClient("", "").Catalogit.Catalog(context.Background(), container)
+}
 
-	return true
+//IsContainerVersionCataloged determines whether or not a container/version exists in the catalog
+func IsContainerVersionCataloged(name string, version string) (bool, error) {
+	return harborClient("", "").Customs.IsContainerVersionCataloged(context.Background(), name, version)
 }
 
 // Deploy deploys (and catalogs) a shipment container to an environment
-func Deploy(shipment string, env string, buildToken string, deployRequest DeployRequest, provider string) {
-
-	//build URI
-	uri := customsURI("/deploy/{shipment}/{env}/{provider}",
-		param("shipment", shipment),
-		param("env", env),
-		param("provider", provider))
-
-	request := gorequest.New().Get(uri)
-
-	if Verbose {
-		log.Printf("POST " + uri)
-	}
-
-	//make network request
-	res, body, err := request.
-		Post(uri).
-		Set("x-build-token", buildToken).
-		Send(deployRequest).
-		EndBytes()
-
-	//handle errors
+func Deploy(shipment string, env string, buildToken string, deployRequest DeployRequest, provider string) error {
+	err := harborClient("", "").Customs.Deploy(context.Background(), shipment, env, provider, buildToken, deployRequest)
 	if err != nil {
 		log.Println("an error occurred calling customs api")
-		check(err[0])
-	}
-
-	//logging
-	if Verbose || res.StatusCode != http.StatusOK {
-		log.Printf("customs api returned a %v", res.StatusCode)
-		log.Println(string(body))
-	}
-
-	if res.StatusCode != http.StatusOK {
-		check(errors.New("customs/deploy failed"))
 	}
+	return err
 }
 
 // CatalogCustoms catalogs a container using the customs catalog api
 func CatalogCustoms(shipment string, env string, buildToken string, catalogRequest CatalogitContainer, provider string) {
-
-	uri := customsURI("/catalog/{shipment}/{env}/{provider}",
-		param("shipment", shipment),
-		param("env", env),
-		param("provider", provider))
-
-	request := gorequest.New().Get(uri)
-
-	if Verbose {
-		log.Printf("POST " + uri)
-	}
-
-	//make network request
-	res, body, err := request.
-		Post(uri).
-		Set("x-build-token", buildToken).
-		Send(catalogRequest).
-		EndBytes()
-
-	//handle errors
+	err := harborClient("", "").Customs.Catalog(context.Background(), shipment, env, provider, buildToken, catalogRequest)
 	if err != nil {
 		log.Println("an error occurred calling customs api")
-		check(err[0])
-	}
-
-	//logging
-	if Verbose || res.StatusCode != http.StatusOK {
-		log.Printf("customs api returned a %v", res.StatusCode)
-		log.Println(string(body))
-	}
-
-	if res.StatusCode != http.StatusOK {
-		check(errors.New("customs/catalog failed"))
 	}
+	check(err)
 }
 
 //update a port
-func updatePort(username string, token string, shipment string, env string, container string, port UpdatePortRequest) {
-
-	//build url
-	uri := shipitURI("/v1/shipment/{shipment}/environment/{env}/container/{container}/port/{port}",
-		param("shipment", shipment),
-		param("env", env),
-		param("container", container),
-		param("port", port.Name))
-
-	//make the api call
-	r, _, e := update(username, token, uri, port)
-	if e != nil {
-		check(e[0])
-	}
-	if r.StatusCode != http.StatusOK {
-		check(errors.New("update port failed"))
-	}
+func updatePort(username string, token string, shipment string, env string, container string, port UpdatePortRequest) error {
+	return harborClient(username, token).Shipit.UpdatePort(context.Background(), shipment, env, container, port)
+}
+
+//add a port to a container
+func createPort(username string, token string, shipment string, env string, container string, port PortPayload) error {
+	return harborClient(username, token).Shipit.CreatePort(context.Background(), shipment, env, container, port)
 }
 
 // GetBarges returns a list of harbor barges
 func GetBarges() *BargeResults {
-
-	uri := bargesURI("/barges")
-	if Verbose {
-		fmt.Println("fetching: " + uri)
-	}
-
-	res, body, err := gorequest.New().Get(uri).EndBytes()
-	if err != nil {
-		check(err[0])
-	}
-
-	if res.StatusCode != http.StatusOK {
-		log.Fatal("GetBarges returned ", res.StatusCode)
-	}
-
-	//deserialize json into object
-	var result BargeResults
-	unmarshalErr := json.Unmarshal(body, &result)
-	check(unmarshalErr)
-
-	return &result
+	result, err := harborClient("", "").Barges.GetBarges(context.Background())
+	check(err)
+	return result
 }
 
 // GetGroup returns the members of a harbor group
 func GetGroup(id string) *Group {
-
-	uri := bargesURI("/harbor/groups/{id}", param("id", id))
-
-	if Verbose {
-		fmt.Println("fetching: " + uri)
-	}
-
-	res, body, err := gorequest.New().Get(uri).EndBytes()
-	if err != nil {
-		check(err[0])
-	}
-
-	if res.StatusCode != http.StatusOK {
-		log.Fatal("GetBarges returned ", res.StatusCode)
-	}
-
-	//deserialize json into object
-	var result Group
-	unmarshalErr := json.Unmarshal(body, &result)
-	check(unmarshalErr)
-
-	return &result
+	result, err := harborClient("", "").Barges.GetGroup(context.Background(), id)
+	check(err)
+	return result
 }
 
 func getLoadBalancerStatus(shipment string, env string) (*LoadBalancer, error) {
-
-	uri := triggerURI("/v2/loadbalancer/status/{shipment}/{env}/{provider}",
-		param("shipment", shipment),
-		param("env", env),
-		param("provider", providerEc2))
-
-	if Verbose {
-		log.Printf("getting lb status: " + uri)
-	}
-
-	//issue request
-	res, body, err := gorequest.New().Get(uri).EndBytes()
-	if err != nil {
-		return nil, err[0]
-	}
-
-	var result LoadBalancer
-	if res.StatusCode == http.StatusOK {
-		if Verbose {
-			log.Println(string(body))
-		}
-
-		unmarshalErr := json.Unmarshal(body, &result)
-		if unmarshalErr != nil {
-			return nil, unmarshalErr
-		}
-	} else {
-		return nil, fmt.Errorf("get lb status returned: %v; %v", res.StatusCode, string(body))
-	}
-
-	return &result, nil
+	return harborClient("", "").Trigger.GetLoadBalancerStatus(context.Background(), shipment, env, providerEc2)
 }
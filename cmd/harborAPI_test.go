@@ -1,12 +1,35 @@
 package cmd
 
 import (
+	"net/http/httptest"
 	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/turnerlabs/harbor-compose/harbor/client"
 )
 
+//points the harbor client at a mock server (for every api) and returns a function that restores it
+//usage: defer useMockHarbor(server)()
+func useMockHarbor(server *httptest.Server) func() {
+	original := harborClient
+	harborClient = func(username string, token string) *client.Client {
+		return client.New(client.Config{
+			ShipitURI:    server.URL,
+			HelmitURI:    server.URL,
+			TriggerURI:   server.URL,
+			CustomsURI:   server.URL,
+			CatalogitURI: server.URL,
+			BargesURI:    server.URL,
+			Username:     username,
+			Token:        token,
+		})
+	}
+	return func() {
+		harborClient = original
+	}
+}
+
 func TestGetShipmentEnvironment(t *testing.T) {
 	if !*integrationTest || *usernameTest == "" || *passwordTest == "" {
 		t.SkipNow()
@@ -24,7 +47,8 @@ func TestGetShipmentEnvironment(t *testing.T) {
 	//test
 	shipment := "mss-shipit-api"
 	env := "dev"
-	shipmentEnv := GetShipmentEnvironment(*usernameTest, token, shipment, env)
+	shipmentEnv, err := GetShipmentEnvironment(*usernameTest, token, shipment, env)
+	assert.Nil(t, err)
 
 	//assertions
 	assert.NotNil(t, shipmentEnv)
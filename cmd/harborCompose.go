@@ -4,10 +4,27 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
 
+	"github.com/docker/docker/runconfig/opts"
 	"gopkg.in/yaml.v2"
 )
 
+//the harbor compose file that is used when -c isn't specified
+const defaultHarborComposeFile = "harbor-compose.yml"
+
+//automatically merged on top of the default harbor compose file (when it exists and -c isn't specified)
+const harborComposeOverrideFile = "harbor-compose.override.yml"
+
+//matches $$, ${...} and $VAR
+var interpolationPattern = regexp.MustCompile(`\$(?:(\$)|\{([^}]*)\}|([A-Za-z_][A-Za-z0-9_]*))`)
+
+//matches the inside of ${...}: a variable name followed by an optional operator and word
+var interpolationExpressionPattern = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*)(?:(:?[-?])(.*))?$`)
+
 // DeserializeHarborCompose deserializes a harbor-compose.yml file into an object
 func DeserializeHarborCompose(file string) HarborCompose {
 
@@ -20,6 +37,337 @@ func DeserializeHarborCompose(file string) HarborCompose {
 	return unmarshalHarborCompose(string(harborComposeData))
 }
 
+//returns the harbor compose files to load, in the order that they're merged
+func getHarborComposeFiles() []string {
+	files := HarborComposeFiles
+	if len(files) == 0 {
+		files = []string{defaultHarborComposeFile}
+	}
+
+	//like docker-compose, only pick up the override file when no files are specified
+	if !RootCmd.PersistentFlags().Changed("harbor-file") {
+		if _, err := os.Stat(harborComposeOverrideFile); err == nil {
+			files = append(files, harborComposeOverrideFile)
+		}
+	}
+	return files
+}
+
+//loads, interpolates and merges the harbor compose files specified by the user
+//using the environment selected with --env
+func loadHarborCompose() HarborCompose {
+	return loadHarborComposeEnv(ComposeEnvironment)
+}
+
+//loads, interpolates and merges the harbor compose files specified by the user
+//and applies the environment profile for env (or each shipment's env if empty)
+func loadHarborComposeEnv(env string) HarborCompose {
+	files := getHarborComposeFiles()
+	if Verbose {
+		log.Printf("loading %v", strings.Join(files, ", "))
+	}
+	harborCompose := unmarshalHarborCompose(resolveHarborComposeFiles(files))
+	check(applyEnvironmentProfiles(&harborCompose, env))
+	return harborCompose
+}
+
+//resolves each shipment's environment profile, overriding the shared settings
+func applyEnvironmentProfiles(harborCompose *HarborCompose, env string) error {
+	for name, shipment := range harborCompose.Shipments {
+		resolved, err := applyEnvironmentProfile(name, shipment, env)
+		if err != nil {
+			return err
+		}
+		harborCompose.Shipments[name] = resolved
+	}
+	return nil
+}
+
+//applies the profile for env (or the shipment's env if empty) to a shipment
+func applyEnvironmentProfile(name string, shipment ComposeShipment, env string) (ComposeShipment, error) {
+	if env == "" {
+		env = shipment.Env
+	}
+
+	if len(shipment.Environments) > 0 {
+		if env == "" {
+			return shipment, fmt.Errorf(messageEnvironmentRequired, name)
+		}
+		profile, ok := shipment.Environments[env]
+		if !ok {
+			return shipment, fmt.Errorf(messageEnvironmentNotDefined, env, name)
+		}
+
+		if profile.Barge != "" {
+			shipment.Barge = profile.Barge
+		}
+		if profile.Replicas != nil {
+			shipment.Replicas = *profile.Replicas
+		}
+		if profile.IgnoreImageVersion != nil {
+			shipment.IgnoreImageVersion = *profile.IgnoreImageVersion
+		}
+		if profile.HealthcheckTimeoutSeconds != nil {
+			shipment.HealthcheckTimeoutSeconds = profile.HealthcheckTimeoutSeconds
+		}
+		if profile.HealthcheckIntervalSeconds != nil {
+			shipment.HealthcheckIntervalSeconds = profile.HealthcheckIntervalSeconds
+		}
+
+		//env vars are merged with the shared ones
+		if len(profile.Environment) > 0 {
+			environment := map[string]string{}
+			for k, v := range shipment.Environment {
+				environment[k] = v
+			}
+			for k, v := range profile.Environment {
+				environment[k] = v
+			}
+			shipment.Environment = environment
+		}
+	}
+
+	shipment.Env = env
+	shipment.Environments = nil
+	return shipment, nil
+}
+
+//returns the fully interpolated and merged yaml for a list of harbor compose files
+func resolveHarborComposeFiles(files []string) string {
+	lookup, err := harborComposeEnvLookup(harborComposeEnvFile(files))
+	check(err)
+
+	documents := []string{}
+	warned := map[string]bool{}
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		check(err)
+		interpolated, unset, err := interpolateHarborCompose(string(data), lookup)
+		if err != nil {
+			check(fmt.Errorf("%v: %v", file, err))
+		}
+		warnUnsetVariables(unset, warned)
+		documents = append(documents, interpolated)
+	}
+
+	//a single file doesn't need merging (and keeps its line numbers in yaml errors)
+	if len(documents) == 1 {
+		return documents[0]
+	}
+
+	merged, err := mergeHarborComposeYaml(documents)
+	check(err)
+	return merged
+}
+
+//like docker-compose, warns about variables that are replaced with an empty string (once per variable)
+func warnUnsetVariables(unset []string, warned map[string]bool) {
+	for _, name := range unset {
+		if !warned[name] {
+			warned[name] = true
+			log.Printf(messageVariableNotSet, name)
+		}
+	}
+}
+
+//returns true if the harbor compose config is read as-is from a single file and can safely be written back
+//(i.e., no overrides, variables or environment profiles)
+func isHarborComposeWritable() bool {
+	files := getHarborComposeFiles()
+	if len(files) > 1 {
+		return false
+	}
+	data, err := ioutil.ReadFile(files[0])
+	if err != nil {
+		return false
+	}
+	if interpolationPattern.MatchString(stripYamlComments(string(data))) {
+		return false
+	}
+
+	//environment profiles are resolved when loading
+	for _, shipment := range unmarshalHarborCompose(string(data)).Shipments {
+		if len(shipment.Environments) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+//returns the .env file that variables are read from (next to the first harbor compose file)
+func harborComposeEnvFile(files []string) string {
+	return filepath.Join(filepath.Dir(files[0]), ".env")
+}
+
+//looks up variables in the process environment, falling back to a .env file
+func harborComposeEnvLookup(envFile string) (func(string) (string, bool), error) {
+	dotEnv := map[string]string{}
+	if _, err := os.Stat(envFile); err == nil {
+		lines, err := opts.ParseEnvFile(envFile)
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range lines {
+			parts := strings.SplitN(line, "=", 2)
+			dotEnv[parts[0]] = parts[1]
+		}
+	}
+
+	return func(name string) (string, bool) {
+		if value, ok := os.LookupEnv(name); ok {
+			return value, true
+		}
+		value, ok := dotEnv[name]
+		return value, ok
+	}, nil
+}
+
+//interpolationError is a problem with a variable on a line of a harbor compose file
+type interpolationError struct {
+	Line int
+	Err  error
+}
+
+func (e interpolationError) Error() string {
+	return fmt.Sprintf("line %v: %v", e.Line, e.Err)
+}
+
+//replaces $VAR, ${VAR}, ${VAR:-default}, ${VAR-default}, ${VAR:?err} and ${VAR?err} with values from lookup
+//$$ is an escaped $ and comment lines are left alone
+//also returns the names of unset variables that were replaced with an empty string
+func interpolateHarborCompose(yamlString string, lookup func(string) (string, bool)) (string, []string, error) {
+	unset := []string{}
+	lines := strings.Split(yamlString, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+
+		var lineErr error
+		lines[i] = interpolationPattern.ReplaceAllStringFunc(line, func(match string) string {
+			if lineErr != nil {
+				return match
+			}
+			value, err := interpolateExpression(match, lookup)
+			if err != nil {
+				lineErr = interpolationError{Line: i + 1, Err: err}
+			}
+			if name := unsetVariable(match, lookup); name != "" && !containsString(unset, name) {
+				unset = append(unset, name)
+			}
+			return value
+		})
+		if lineErr != nil {
+			return "", nil, lineErr
+		}
+	}
+	return strings.Join(lines, "\n"), unset, nil
+}
+
+//returns the variable name when a $VAR or ${VAR} match (without a default) refers to an unset variable
+func unsetVariable(match string, lookup func(string) (string, bool)) string {
+	groups := interpolationPattern.FindStringSubmatch(match)
+	name := groups[3]
+	if expression := interpolationExpressionPattern.FindStringSubmatch(groups[2]); expression != nil && expression[2] == "" {
+		name = expression[1]
+	}
+	if name == "" {
+		return ""
+	}
+	if _, set := lookup(name); set {
+		return ""
+	}
+	return name
+}
+
+//resolves a single $$, $VAR or ${...} match
+func interpolateExpression(match string, lookup func(string) (string, bool)) (string, error) {
+	groups := interpolationPattern.FindStringSubmatch(match)
+	if groups[1] != "" {
+		return "$", nil
+	}
+	if groups[3] != "" {
+		value, _ := lookup(groups[3])
+		return value, nil
+	}
+
+	expression := interpolationExpressionPattern.FindStringSubmatch(groups[2])
+	if expression == nil {
+		return "", fmt.Errorf("invalid interpolation format for %v", match)
+	}
+	name, operator, word := expression[1], expression[2], expression[3]
+	value, set := lookup(name)
+
+	//the colon forms also treat empty values as unset
+	missing := !set || (strings.HasPrefix(operator, ":") && value == "")
+
+	switch operator {
+	case ":-", "-":
+		if missing {
+			return word, nil
+		}
+	case ":?", "?":
+		if missing {
+			if word == "" {
+				word = "required variable is missing a value"
+			}
+			return "", fmt.Errorf("%v: %v", name, word)
+		}
+	}
+	return value, nil
+}
+
+//removes comment lines from a yaml string
+func stripYamlComments(yamlString string) string {
+	lines := []string{}
+	for _, line := range strings.Split(yamlString, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "#") {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+//merges yaml documents in order, later documents override earlier ones
+//maps are merged key by key, while scalars and lists are replaced
+func mergeHarborComposeYaml(documents []string) (string, error) {
+	var merged interface{}
+	for _, document := range documents {
+		var parsed interface{}
+		err := yaml.Unmarshal([]byte(document), &parsed)
+		if err != nil {
+			return "", fmt.Errorf("harbor compose error: %v", err)
+		}
+		if parsed == nil {
+			continue
+		}
+		merged = mergeYamlValues(merged, parsed)
+	}
+
+	data, err := yaml.Marshal(merged)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func mergeYamlValues(base interface{}, override interface{}) interface{} {
+	baseMap, baseIsMap := base.(map[interface{}]interface{})
+	overrideMap, overrideIsMap := override.(map[interface{}]interface{})
+	if !baseIsMap || !overrideIsMap {
+		return override
+	}
+
+	for key, value := range overrideMap {
+		if existing, ok := baseMap[key]; ok {
+			baseMap[key] = mergeYamlValues(existing, value)
+		} else {
+			baseMap[key] = value
+		}
+	}
+	return baseMap
+}
+
 func marshalHarborCompose(o HarborCompose) []byte {
 	//serialize object to yaml
 	data, err := yaml.Marshal(o)
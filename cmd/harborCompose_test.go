@@ -0,0 +1,230 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testLookup(values map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, ok := values[name]
+		return value, ok
+	}
+}
+
+func TestInterpolateHarborCompose(t *testing.T) {
+	lookup := testLookup(map[string]string{
+		"ENV":   "qa",
+		"EMPTY": "",
+	})
+
+	yaml := `# ${NOT_INTERPOLATED:?comment}
+shipments:
+  my-app:
+    env: ${ENV}
+    group: $ENV-group
+    barge: ${BARGE:-digital-sandbox}
+    replicas: ${REPLICAS-2}
+    property: ${EMPTY:-default}
+    project: ${EMPTY-default}
+    product: $$ENV`
+
+	result, unset, err := interpolateHarborCompose(yaml, lookup)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{}, unset)
+	assert.Equal(t, `# ${NOT_INTERPOLATED:?comment}
+shipments:
+  my-app:
+    env: qa
+    group: qa-group
+    barge: digital-sandbox
+    replicas: 2
+    property: default
+    project: 
+    product: $ENV`, result)
+}
+
+func TestInterpolateHarborComposeRequired(t *testing.T) {
+	lookup := testLookup(map[string]string{"EMPTY": ""})
+
+	_, _, err := interpolateHarborCompose("version: \"1\"\nenv: ${ENV:?ENV is required}", lookup)
+	assert.NotNil(t, err)
+	assert.Equal(t, "line 2: ENV: ENV is required", err.Error())
+
+	_, _, err = interpolateHarborCompose("env: ${EMPTY:?}", lookup)
+	assert.NotNil(t, err)
+	assert.Equal(t, "line 1: EMPTY: required variable is missing a value", err.Error())
+
+	//empty is allowed without the colon
+	result, _, err := interpolateHarborCompose("env: ${EMPTY?required}", lookup)
+	assert.Nil(t, err)
+	assert.Equal(t, "env: ", result)
+
+	_, _, err = interpolateHarborCompose("env: ${1BAD}", lookup)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "invalid interpolation format")
+}
+
+func TestInterpolateHarborComposeUnset(t *testing.T) {
+	lookup := testLookup(map[string]string{"EMPTY": ""})
+
+	//a bare $ in a value is a variable
+	result, unset, err := interpolateHarborCompose("password: pa$word\nuser: ${USER}-$word\nproperty: ${EMPTY}\nproject: ${PROJECT:-default}\nproduct: pa$$word", lookup)
+	assert.Nil(t, err)
+	assert.Equal(t, "password: pa\nuser: -\nproperty: \nproject: default\nproduct: pa$word", result)
+	assert.Equal(t, []string{"word", "USER"}, unset)
+}
+
+func TestMergeHarborComposeYaml(t *testing.T) {
+	base := `
+shipments:
+  my-app:
+    env: dev
+    barge: digital-sandbox
+    containers:
+      - web
+      - worker
+    replicas: 2
+    environment:
+      FOO: foo
+      BAR: bar
+`
+	override := `
+shipments:
+  my-app:
+    env: prod
+    containers:
+      - web
+    environment:
+      BAR: baz
+`
+
+	merged, err := mergeHarborComposeYaml([]string{base, override, ""})
+	assert.Nil(t, err)
+
+	hc := unmarshalHarborCompose(merged)
+	shipment := hc.Shipments["my-app"]
+	assert.Equal(t, "prod", shipment.Env)
+	assert.Equal(t, "digital-sandbox", shipment.Barge)
+	assert.Equal(t, []string{"web"}, shipment.Containers)
+	assert.Equal(t, 2, shipment.Replicas)
+	assert.Equal(t, map[string]string{"FOO": "foo", "BAR": "baz"}, shipment.Environment)
+}
+
+func TestResolveHarborComposeFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "harbor-compose")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	base := filepath.Join(dir, "harbor-compose.yml")
+	prod := filepath.Join(dir, "harbor-compose.prod.yml")
+	assert.Nil(t, ioutil.WriteFile(base, []byte(`
+shipments:
+  my-app:
+    env: dev
+    barge: ${BARGE}
+    containers:
+      - web
+    replicas: ${REPLICAS:-1}
+`), 0644))
+	assert.Nil(t, ioutil.WriteFile(prod, []byte(`
+shipments:
+  my-app:
+    env: prod
+    replicas: ${PROD_REPLICAS}
+`), 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, ".env"), []byte("BARGE=corp-sandbox\nPROD_REPLICAS=4\n"), 0644))
+
+	//process env takes precedence over .env
+	defer os.Unsetenv("PROD_REPLICAS")
+	os.Setenv("PROD_REPLICAS", "6")
+
+	hc := unmarshalHarborCompose(resolveHarborComposeFiles([]string{base}))
+	assert.Equal(t, "dev", hc.Shipments["my-app"].Env)
+	assert.Equal(t, "corp-sandbox", hc.Shipments["my-app"].Barge)
+	assert.Equal(t, 1, hc.Shipments["my-app"].Replicas)
+
+	hc = unmarshalHarborCompose(resolveHarborComposeFiles([]string{base, prod}))
+	assert.Equal(t, "prod", hc.Shipments["my-app"].Env)
+	assert.Equal(t, "corp-sandbox", hc.Shipments["my-app"].Barge)
+	assert.Equal(t, []string{"web"}, hc.Shipments["my-app"].Containers)
+	assert.Equal(t, 6, hc.Shipments["my-app"].Replicas)
+}
+
+func TestApplyEnvironmentProfiles(t *testing.T) {
+	hc := unmarshalHarborCompose(`
+shipments:
+  my-app:
+    env: dev
+    barge: digital-sandbox
+    containers:
+      - web
+    replicas: 2
+    healthcheckTimeoutSeconds: 1
+    environment:
+      LOG_LEVEL: debug
+      FOO: foo
+    environments:
+      dev: {}
+      prod:
+        barge: digital-prod
+        replicas: 6
+        ignoreImageVersion: true
+        healthcheckIntervalSeconds: 20
+        environment:
+          LOG_LEVEL: info
+`)
+
+	//defaults to the shipment's env
+	dev := hc
+	dev.Shipments = map[string]ComposeShipment{"my-app": hc.Shipments["my-app"]}
+	assert.Nil(t, applyEnvironmentProfiles(&dev, ""))
+	shipment := dev.Shipments["my-app"]
+	assert.Equal(t, "dev", shipment.Env)
+	assert.Equal(t, "digital-sandbox", shipment.Barge)
+	assert.Equal(t, 2, shipment.Replicas)
+	assert.Nil(t, shipment.Environments)
+
+	prod := hc
+	prod.Shipments = map[string]ComposeShipment{"my-app": hc.Shipments["my-app"]}
+	assert.Nil(t, applyEnvironmentProfiles(&prod, "prod"))
+	shipment = prod.Shipments["my-app"]
+	assert.Equal(t, "prod", shipment.Env)
+	assert.Equal(t, "digital-prod", shipment.Barge)
+	assert.Equal(t, 6, shipment.Replicas)
+	assert.True(t, shipment.IgnoreImageVersion)
+	assert.Equal(t, 1, *shipment.HealthcheckTimeoutSeconds)
+	assert.Equal(t, 20, *shipment.HealthcheckIntervalSeconds)
+	assert.Equal(t, map[string]string{"LOG_LEVEL": "info", "FOO": "foo"}, shipment.Environment)
+	assert.Equal(t, []string{"web"}, shipment.Containers)
+
+	//shared env vars aren't modified
+	assert.Equal(t, "debug", hc.Shipments["my-app"].Environment["LOG_LEVEL"])
+
+	err := applyEnvironmentProfiles(&hc, "qa")
+	assert.NotNil(t, err)
+	assert.Equal(t, "environment qa is not defined in the environments for shipment my-app", err.Error())
+}
+
+func TestApplyEnvironmentProfileWithoutEnvironments(t *testing.T) {
+	shipment := ComposeShipment{Env: "dev", Replicas: 2}
+
+	//the flag overrides env when there are no profiles (like deploy --env)
+	resolved, err := applyEnvironmentProfile("my-app", shipment, "qa")
+	assert.Nil(t, err)
+	assert.Equal(t, "qa", resolved.Env)
+	assert.Equal(t, 2, resolved.Replicas)
+
+	resolved, err = applyEnvironmentProfile("my-app", shipment, "")
+	assert.Nil(t, err)
+	assert.Equal(t, "dev", resolved.Env)
+
+	//an env is required when there are profiles
+	shipment = ComposeShipment{Environments: map[string]ComposeProfile{"dev": {}}}
+	_, err = applyEnvironmentProfile("my-app", shipment, "")
+	assert.NotNil(t, err)
+}
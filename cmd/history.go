@@ -0,0 +1,314 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+	"github.com/mitchellh/go-homedir"
+	"github.com/spf13/cobra"
+)
+
+// historyCmd represents the history command
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List recorded revisions of your shipment environments",
+	Long: `List recorded revisions of your shipment environments
+
+Before the up, deploy and rollback commands change container images, environment variables or replicas, the previous state of each shipment environment is recorded as a revision in a local journal (~/.harbor/history/<shipment>/<env>/).  The history command lists these revisions along with who made the change, when, and what changed.  Use the rollback command to restore a revision.`,
+	Example: `harbor-compose history
+harbor-compose history --shipment my-shipment --environment dev
+harbor-compose history -s my-shipment -e dev`,
+	Run:    history,
+	PreRun: preRunHook,
+}
+
+var historyShipment string
+var historyEnvironment string
+
+func init() {
+	historyCmd.PersistentFlags().StringVarP(&historyShipment, "shipment", "s", "", "shipment name")
+	historyCmd.PersistentFlags().StringVarP(&historyEnvironment, "environment", "e", "", "environment name")
+	RootCmd.AddCommand(historyCmd)
+}
+
+//revision is the recorded state of a shipment environment before a command changed it
+type revision struct {
+	Version     int                 `json:"version"`
+	Shipment    string              `json:"shipment"`
+	Environment string              `json:"environment"`
+	User        string              `json:"user"`
+	Timestamp   time.Time           `json:"timestamp"`
+	Command     string              `json:"command"`
+	Replicas    int                 `json:"replicas"`
+	EnvVars     []EnvVarPayload     `json:"envVars"`
+	Containers  []revisionContainer `json:"containers"`
+}
+
+//revisionContainer is the recorded state of a container
+type revisionContainer struct {
+	Name    string          `json:"name"`
+	Image   string          `json:"image"`
+	EnvVars []EnvVarPayload `json:"envVars"`
+}
+
+func history(cmd *cobra.Command, args []string) {
+
+	//make sure user is authenticated
+	username, token, err := Login()
+	check(err)
+
+	//determine which shipment/environments user wants history for
+	inputShipmentEnvironments, _ := getShipmentEnvironmentsFromInput(historyShipment, historyEnvironment)
+
+	for _, t := range inputShipmentEnvironments {
+		shipment := t.Item1
+		env := t.Item2
+
+		dir, err := historyDir(shipment, env)
+		check(err)
+		revisions, err := readRevisions(dir)
+		check(err)
+
+		fmt.Printf("%v %v\n", shipment, env)
+		if len(revisions) == 0 {
+			fmt.Println("no revisions found")
+			fmt.Println("-----")
+			continue
+		}
+
+		//the latest revision's changes are relative to the current state
+		var current *revision
+		shipmentEnvironment, err := GetShipmentEnvironment(username, token, shipment, env)
+		check(err)
+		if shipmentEnvironment != nil {
+			r := newRevision(shipmentEnvironment, "", "")
+			current = &r
+		}
+
+		printRevisions(revisions, current)
+		fmt.Println("-----")
+	}
+}
+
+//prints revisions along with the changes that were made after each one was recorded
+func printRevisions(revisions []revision, current *revision) {
+	const padding = 3
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, padding, ' ', 0)
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "REVISION\tUSER\tWHEN\tCOMMAND\tCHANGES\t")
+
+	for i, r := range revisions {
+		next := current
+		if i+1 < len(revisions) {
+			next = &revisions[i+1]
+		}
+
+		changes := []string{}
+		if next != nil {
+			changes = diffRevisions(r, *next)
+		}
+		description := strings.Join(changes, ", ")
+		if len(changes) == 0 {
+			description = "none"
+		}
+
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t\n", r.Version, r.User, humanize.Time(r.Timestamp), r.Command, description)
+	}
+	w.Flush()
+}
+
+//returns the journal directory for a shipment environment
+func historyDir(shipment string, env string) (string, error) {
+	home, err := homedir.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".harbor", "history", shipment, env), nil
+}
+
+//snapshots the parts of a shipment environment that can be rolled back
+func newRevision(shipmentEnvironment *ShipmentEnvironment, user string, command string) revision {
+	r := revision{
+		Shipment:    shipmentEnvironment.ParentShipment.Name,
+		Environment: shipmentEnvironment.Name,
+		User:        user,
+		Timestamp:   time.Now().UTC(),
+		Command:     command,
+		Replicas:    ec2Provider(shipmentEnvironment.Providers).Replicas,
+		EnvVars:     append([]EnvVarPayload{}, shipmentEnvironment.EnvVars...),
+		Containers:  []revisionContainer{},
+	}
+	for _, c := range shipmentEnvironment.Containers {
+		r.Containers = append(r.Containers, revisionContainer{
+			Name:    c.Name,
+			Image:   c.Image,
+			EnvVars: append([]EnvVarPayload{}, c.EnvVars...),
+		})
+	}
+	return r
+}
+
+//reads all revisions in a journal directory, ordered by version
+func readRevisions(dir string) ([]revision, error) {
+	revisions := []revision{}
+
+	files, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return revisions, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range files {
+		if f.IsDir() || filepath.Ext(f.Name()) != ".json" {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var r revision
+		if err := json.Unmarshal(data, &r); err != nil {
+			return nil, fmt.Errorf("error reading revision %v: %v", f.Name(), err)
+		}
+		revisions = append(revisions, r)
+	}
+
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].Version < revisions[j].Version
+	})
+	return revisions, nil
+}
+
+//writes a revision to a journal directory as the next version
+func writeRevision(dir string, r *revision) error {
+	revisions, err := readRevisions(dir)
+	if err != nil {
+		return err
+	}
+
+	r.Version = 1
+	if len(revisions) > 0 {
+		r.Version = revisions[len(revisions)-1].Version + 1
+	}
+
+	//revisions can contain hidden env vars
+	err = os.MkdirAll(dir, 0700)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, strconv.Itoa(r.Version)+".json"), data, 0600)
+}
+
+//records the current state of a shipment environment before a command changes it
+func recordRevision(shipmentEnvironment *ShipmentEnvironment, user string, command string) {
+	r := newRevision(shipmentEnvironment, user, command)
+
+	dir, err := historyDir(r.Shipment, r.Environment)
+	if err == nil {
+		err = writeRevision(dir, &r)
+	}
+
+	//a journal failure shouldn't prevent a change from being made
+	if err != nil {
+		log.Printf("WARNING: unable to record history for %v %v: %v", r.Shipment, r.Environment, err)
+		return
+	}
+	if Verbose {
+		log.Printf("recorded revision %v for %v %v", r.Version, r.Shipment, r.Environment)
+	}
+}
+
+//describes the changes needed to go from one revision to another
+func diffRevisions(from revision, to revision) []string {
+	changes := []string{}
+
+	for _, toContainer := range to.Containers {
+		fromContainer := findRevisionContainer(from.Containers, toContainer.Name)
+		if fromContainer == nil {
+			changes = append(changes, "added container "+toContainer.Name)
+			continue
+		}
+		if fromContainer.Image != toContainer.Image {
+			changes = append(changes, fmt.Sprintf("%v image %v => %v", toContainer.Name, fromContainer.Image, toContainer.Image))
+		}
+		changes = append(changes, diffEnvVars(toContainer.Name+" ", fromContainer.EnvVars, toContainer.EnvVars)...)
+	}
+	for _, fromContainer := range from.Containers {
+		if findRevisionContainer(to.Containers, fromContainer.Name) == nil {
+			changes = append(changes, "removed container "+fromContainer.Name)
+		}
+	}
+
+	changes = append(changes, diffEnvVars("", from.EnvVars, to.EnvVars)...)
+
+	if from.Replicas != to.Replicas {
+		changes = append(changes, fmt.Sprintf("replicas %v => %v", from.Replicas, to.Replicas))
+	}
+
+	return changes
+}
+
+//describes env var changes (by name only, since values can be hidden)
+func diffEnvVars(prefix string, from []EnvVarPayload, to []EnvVarPayload) []string {
+	changes := []string{}
+	fromVars := envVarsByName(from)
+	toVars := envVarsByName(to)
+
+	names := []string{}
+	for name := range toVars {
+		names = append(names, name)
+	}
+	for name := range fromVars {
+		if _, ok := toVars[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		f, inFrom := fromVars[name]
+		t, inTo := toVars[name]
+		switch {
+		case !inFrom:
+			changes = append(changes, prefix+"+"+name)
+		case !inTo:
+			changes = append(changes, prefix+"-"+name)
+		case f.Value != t.Value || f.Type != t.Type:
+			changes = append(changes, prefix+"~"+name)
+		}
+	}
+	return changes
+}
+
+func envVarsByName(envVars []EnvVarPayload) map[string]EnvVarPayload {
+	result := map[string]EnvVarPayload{}
+	for _, e := range envVars {
+		result[e.Name] = e
+	}
+	return result
+}
+
+func findRevisionContainer(containers []revisionContainer, name string) *revisionContainer {
+	for i := range containers {
+		if containers[i].Name == name {
+			return &containers[i]
+		}
+	}
+	return nil
+}
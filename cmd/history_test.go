@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func getHistoryTestShipment() *ShipmentEnvironment {
+	return &ShipmentEnvironment{
+		Name:           "dev",
+		ParentShipment: ParentShipment{Name: "my-app"},
+		EnvVars:        []EnvVarPayload{envVar("FOO", "bar")},
+		Containers: []ContainerPayload{
+			{
+				Name:    "web",
+				Image:   "registry/web:1.0",
+				EnvVars: []EnvVarPayload{envVar("HEALTHCHECK", "/health"), envVarHidden("SECRET", "1")},
+			},
+		},
+		Providers: []ProviderPayload{{Name: providerEc2, Replicas: 2}},
+	}
+}
+
+func TestWriteRevision(t *testing.T) {
+	dir, err := ioutil.TempDir("", "history")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	//a missing journal has no revisions
+	revisions, err := readRevisions(filepath.Join(dir, "my-app", "dev"))
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(revisions))
+
+	for _, command := range []string{"up", "deploy", "rollback"} {
+		r := newRevision(getHistoryTestShipment(), "user", command)
+		assert.Nil(t, writeRevision(filepath.Join(dir, "my-app", "dev"), &r))
+	}
+
+	revisions, err = readRevisions(filepath.Join(dir, "my-app", "dev"))
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(revisions))
+	assert.Equal(t, 3, revisions[2].Version)
+	assert.Equal(t, "rollback", revisions[2].Command)
+	assert.Equal(t, "user", revisions[0].User)
+	assert.Equal(t, 2, revisions[0].Replicas)
+	assert.Equal(t, "registry/web:1.0", revisions[0].Containers[0].Image)
+}
+
+func TestDiffRevisions(t *testing.T) {
+	shipment := getHistoryTestShipment()
+	from := newRevision(shipment, "user", "up")
+
+	shipment.Containers[0].Image = "registry/web:2.0"
+	shipment.Containers[0].EnvVars[1].Value = "2"
+	shipment.EnvVars = append(shipment.EnvVars, envVar("NEW", "value"))
+	shipment.Providers[0].Replicas = 3
+	to := newRevision(shipment, "user", "up")
+
+	changes := diffRevisions(from, to)
+
+	assert.Equal(t, []string{
+		"web image registry/web:1.0 => registry/web:2.0",
+		"web ~SECRET",
+		"+NEW",
+		"replicas 2 => 3",
+	}, changes)
+
+	assert.Equal(t, 0, len(diffRevisions(to, to)))
+}
+
+func TestFindRevision(t *testing.T) {
+	revisions := []revision{{Version: 1}, {Version: 2}, {Version: 3}}
+
+	r, err := findRevision(revisions, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, r.Version)
+
+	r, err = findRevision(revisions, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, r.Version)
+
+	_, err = findRevision(revisions, 4)
+	assert.NotNil(t, err)
+
+	_, err = findRevision([]revision{}, 0)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/docker/distribution/reference"
+)
+
+//dockerImage is a parsed docker image reference (e.g., registry:5000/app:1.2 or app@sha256:...)
+type dockerImage struct {
+	//the image name without a tag or digest (e.g., registry:5000/app)
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+//parses a docker image reference using the docker reference grammar
+func parseImage(image string) (dockerImage, error) {
+	ref, err := reference.Parse(image)
+	if err != nil {
+		return dockerImage{}, fmt.Errorf("invalid image %v: %v", image, err)
+	}
+
+	result := dockerImage{}
+	if named, ok := ref.(reference.Named); ok {
+		result.Repository = named.Name()
+	}
+	if tagged, ok := ref.(reference.Tagged); ok {
+		result.Tag = tagged.Tag()
+	}
+	if digested, ok := ref.(reference.Digested); ok {
+		result.Digest = digested.Digest().String()
+	}
+	return result, nil
+}
+
+//parses a docker image reference that must be pinned to a tag or digest
+func parseVersionedImage(image string) (dockerImage, error) {
+	result, err := parseImage(image)
+	if err != nil {
+		return result, err
+	}
+	if result.Tag == "" && result.Digest == "" {
+		return result, fmt.Errorf(messageImageTagRequired, image)
+	}
+	return result, nil
+}
+
+//returns the version used to catalog and deploy an image (the tag, digest or both)
+func (i dockerImage) Version() string {
+	switch {
+	case i.Tag != "" && i.Digest != "":
+		return i.Tag + "@" + i.Digest
+	case i.Digest != "":
+		return i.Digest
+	default:
+		return i.Tag
+	}
+}
+
+//returns the tag and/or digest portion of the image reference (e.g., ":1.2" or "@sha256:...")
+func (i dockerImage) Suffix() string {
+	suffix := ""
+	if i.Tag != "" {
+		suffix += ":" + i.Tag
+	}
+	if i.Digest != "" {
+		suffix += "@" + i.Digest
+	}
+	return suffix
+}
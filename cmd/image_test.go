@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseImage(t *testing.T) {
+	image, err := parseImage("registry:5000/app:1.2")
+	assert.Nil(t, err)
+	assert.Equal(t, dockerImage{Repository: "registry:5000/app", Tag: "1.2"}, image)
+	assert.Equal(t, "1.2", image.Version())
+	assert.Equal(t, ":1.2", image.Suffix())
+
+	image, err = parseImage("quay.io/turner/app")
+	assert.Nil(t, err)
+	assert.Equal(t, dockerImage{Repository: "quay.io/turner/app"}, image)
+}
+
+func TestParseImageDigest(t *testing.T) {
+	digest := "sha256:7cc4b5aefd1d0cadf8d97d4350462ba51c694ebca145b08d7d41b41acc8db5aa"
+
+	image, err := parseVersionedImage("registry:5000/app@" + digest)
+	assert.Nil(t, err)
+	assert.Equal(t, dockerImage{Repository: "registry:5000/app", Digest: digest}, image)
+	assert.Equal(t, digest, image.Version())
+	assert.Equal(t, "@"+digest, image.Suffix())
+
+	image, err = parseVersionedImage("app:1.2@" + digest)
+	assert.Nil(t, err)
+	assert.Equal(t, "1.2@"+digest, image.Version())
+	assert.Equal(t, ":1.2@"+digest, image.Suffix())
+}
+
+func TestParseVersionedImageErrors(t *testing.T) {
+	_, err := parseVersionedImage("registry:5000/app")
+	assert.NotNil(t, err)
+	assert.Equal(t, "image registry:5000/app must have a tag or digest", err.Error())
+
+	_, err = parseVersionedImage("Registry/App:1.0:2")
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "invalid image")
+}
+
+func TestMigrateImageDigest(t *testing.T) {
+	digest := "sha256:7cc4b5aefd1d0cadf8d97d4350462ba51c694ebca145b08d7d41b41acc8db5aa"
+	assert.Equal(t, "123.dkr.ecr.us-east-1.amazonaws.com/app:0.1.0", migrateImage("registry:5000/xyz:0.1.0", "123", "us-east-1", "app"))
+	assert.Equal(t, "123.dkr.ecr.us-east-1.amazonaws.com/app@"+digest, migrateImage("quay.io/turner/xyz@"+digest, "123", "us-east-1", "app"))
+}
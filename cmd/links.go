@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/docker/libcompose/project"
+)
+
+//shipmentLink is an env var that's set to the endpoint of another shipment
+type shipmentLink struct {
+	Shipment string
+	Env      string
+
+	//the env var name (e.g., API_URL)
+	Name string
+
+	//the container that uses the link (all containers when empty)
+	Container string
+}
+
+//characters that can't be used in env var names
+var linkEnvVarPattern = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+//returns the env var name for a link alias (e.g., my-api => MY_API_URL)
+func linkEnvVarName(alias string) string {
+	return strings.ToUpper(linkEnvVarPattern.ReplaceAllString(alias, "_")) + "_URL"
+}
+
+//splits a link (target[:alias]) into its target and alias
+func parseLink(link string) (string, string) {
+	parts := strings.SplitN(link, ":", 2)
+	if len(parts) == 2 && parts[1] != "" {
+		return parts[0], parts[1]
+	}
+	return parts[0], parts[0]
+}
+
+//returns the links from a shipment to other shipments
+//links come from the shipment's links (to any shipment) and from docker compose links/depends_on
+//between containers that belong to different shipments in the harbor compose file
+func getShipmentLinks(harborCompose HarborCompose, shipmentName string, dockerCompose project.APIProject) []shipmentLink {
+	shipment := harborCompose.Shipments[shipmentName]
+	links := []shipmentLink{}
+
+	//harbor compose links use the target's env when it's in the harbor compose file
+	for _, link := range shipment.Links {
+		target, alias := parseLink(link)
+		env := shipment.Env
+		if targetShipment, ok := harborCompose.Shipments[target]; ok {
+			env = targetShipment.Env
+		}
+		links = append(links, shipmentLink{Shipment: target, Env: env, Name: linkEnvVarName(alias)})
+	}
+
+	//the shipments that run each container
+	containerShipments := map[string]string{}
+	for _, name := range sortedShipmentNames(harborCompose) {
+		for _, container := range harborCompose.Shipments[name].Containers {
+			if _, ok := containerShipments[container]; !ok {
+				containerShipments[container] = name
+			}
+		}
+	}
+
+	for _, container := range shipment.Containers {
+		serviceConfig, found := dockerCompose.GetServiceConfig(container)
+		if !found {
+			continue
+		}
+
+		services := []string{}
+		services = append(services, serviceConfig.Links...)
+		services = append(services, serviceConfig.DependsOn...)
+		for _, service := range services {
+			service, alias := parseLink(service)
+
+			//containers in the same shipment can already reach each other
+			target, ok := containerShipments[service]
+			if !ok || containsString(shipment.Containers, service) {
+				continue
+			}
+
+			link := shipmentLink{Shipment: target, Env: harborCompose.Shipments[target].Env, Name: linkEnvVarName(alias), Container: container}
+			if !containsLink(links, link) {
+				links = append(links, link)
+			}
+		}
+	}
+
+	sort.SliceStable(links, func(i, j int) bool {
+		if links[i].Container != links[j].Container {
+			return links[i].Container < links[j].Container
+		}
+		return links[i].Name < links[j].Name
+	})
+
+	return links
+}
+
+func containsLink(links []shipmentLink, link shipmentLink) bool {
+	for _, l := range links {
+		if l == link {
+			return true
+		}
+	}
+	return false
+}
+
+//adds the shipments that are linked to (and are in the harbor compose file) as dependencies
+//so that they're brought up before the shipments that link to them
+func addLinkDependencies(harborCompose *HarborCompose, dockerCompose project.APIProject) {
+	for _, name := range sortedShipmentNames(*harborCompose) {
+		shipment := harborCompose.Shipments[name]
+		for _, link := range getShipmentLinks(*harborCompose, name, dockerCompose) {
+			if _, ok := harborCompose.Shipments[link.Shipment]; ok && link.Shipment != name && !containsString(shipment.DependsOn, link.Shipment) {
+				shipment.DependsOn = append(shipment.DependsOn, link.Shipment)
+			}
+		}
+		harborCompose.Shipments[name] = shipment
+	}
+}
+
+//returns the endpoint of a shipment environment
+type linkEndpointFunc func(shipment string, env string) (string, error)
+
+//sets an env var to the endpoint of each linked shipment on the desired containers
+//env vars that are already defined in the compose files are left alone
+func applyShipmentLinks(desired *ShipmentEnvironment, links []shipmentLink, endpoint linkEndpointFunc) error {
+	for _, link := range links {
+		url, err := endpoint(link.Shipment, link.Env)
+		if err != nil {
+			return err
+		}
+
+		for i := range desired.Containers {
+			container := &desired.Containers[i]
+			if link.Container != "" && link.Container != container.Name {
+				continue
+			}
+			if getEnvVar(link.Name, container.EnvVars).Name != "" || getEnvVar(link.Name, desired.EnvVars).Name != "" {
+				continue
+			}
+			container.EnvVars = append(container.EnvVars, envVar(link.Name, url))
+		}
+	}
+	return nil
+}
+
+//returns the url of a shipment environment's primary port, preferring the load balancer's dns name
+func getLinkEndpoint(username string, token string, shipment string, env string) (string, error) {
+	shipmentEnvironment, err := GetShipmentEnvironment(username, token, shipment, env)
+	if err != nil {
+		return "", err
+	}
+	if shipmentEnvironment == nil {
+		return "", fmt.Errorf(messageLinkNotFound, shipment, env)
+	}
+
+	primaryPort, err := getShipmentPrimaryPort(shipmentEnvironment)
+	if err != nil {
+		return "", err
+	}
+
+	lb, err := getLoadBalancerStatus(shipment, env)
+	if err == nil && lb != nil && lb.DNSName != "" {
+		return fmt.Sprintf("%s://%s:%v", primaryPort.Protocol, lb.DNSName, primaryPort.PublicPort), nil
+	}
+
+	provider := ec2Provider(shipmentEnvironment.Providers)
+	return getShipmentEndpoint(shipment, env, provider.Name, primaryPort), nil
+}
@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/turnerlabs/harbor-compose/harbor/client"
+	"github.com/turnerlabs/harbor-compose/harbor/mockserver"
+)
+
+const linksDockerComposeYaml = `version: "2"
+services:
+  web:
+    image: registry/web:1.0
+    ports:
+    - 80:5000
+    links:
+    - api
+    - auth:login
+  sidecar:
+    image: registry/sidecar:1.0
+    ports:
+    - 8080:8080
+    depends_on:
+    - web
+    - api
+  api:
+    image: registry/api:1.0
+    ports:
+    - 80:5000
+  auth:
+    image: registry/auth:1.0
+    ports:
+    - 80:5000
+`
+
+const linksHarborComposeYaml = `shipments:
+  mss-app-web:
+    env: dev
+    containers:
+    - web
+    - sidecar
+    links:
+    - mss-app-search
+    - mss-app-api:backend
+  mss-app-api:
+    env: qa
+    containers:
+    - api
+    - auth
+`
+
+func TestGetShipmentLinks(t *testing.T) {
+	dockerCompose, harborCompose := unmarshalCompose(linksDockerComposeYaml, linksHarborComposeYaml)
+
+	links := getShipmentLinks(harborCompose, "mss-app-web", dockerCompose)
+	assert.Equal(t, []shipmentLink{
+		//harbor compose links apply to every container
+		{Shipment: "mss-app-api", Env: "qa", Name: "BACKEND_URL"},
+		{Shipment: "mss-app-search", Env: "dev", Name: "MSS_APP_SEARCH_URL"},
+
+		//docker compose links and depends_on only apply to their container
+		//(and only to containers in other shipments)
+		{Shipment: "mss-app-api", Env: "qa", Name: "API_URL", Container: "sidecar"},
+		{Shipment: "mss-app-api", Env: "qa", Name: "API_URL", Container: "web"},
+		{Shipment: "mss-app-api", Env: "qa", Name: "LOGIN_URL", Container: "web"},
+	}, links)
+
+	assert.Equal(t, 0, len(getShipmentLinks(harborCompose, "mss-app-api", dockerCompose)))
+}
+
+func TestAddLinkDependencies(t *testing.T) {
+	dockerCompose, harborCompose := unmarshalCompose(linksDockerComposeYaml, linksHarborComposeYaml)
+
+	addLinkDependencies(&harborCompose, dockerCompose)
+
+	//shipments outside of the harbor compose file aren't dependencies
+	assert.Equal(t, []string{"mss-app-api"}, harborCompose.Shipments["mss-app-web"].DependsOn)
+	assert.Equal(t, 0, len(harborCompose.Shipments["mss-app-api"].DependsOn))
+
+	tiers, err := shipmentTiers(harborCompose)
+	assert.Nil(t, err)
+	assert.Equal(t, [][]string{{"mss-app-api"}, {"mss-app-web"}}, tiers)
+}
+
+func TestApplyShipmentLinks(t *testing.T) {
+	dockerCompose, harborCompose := unmarshalCompose(linksDockerComposeYaml, linksHarborComposeYaml)
+	shipment := harborCompose.Shipments["mss-app-web"]
+	shipment.Environment = map[string]string{"MSS_APP_SEARCH_URL": "http://search"}
+	desired := transformComposeToShipmentEnvironment("mss-app-web", shipment, dockerCompose)
+
+	//explicit env vars win
+	desired.Containers[0].EnvVars = append(desired.Containers[0].EnvVars, envVar("LOGIN_URL", "http://login"))
+
+	endpoints := map[string]string{
+		"mss-app-api qa":     "http://api.elb:80",
+		"mss-app-search dev": "http://search.elb:80",
+	}
+	err := applyShipmentLinks(&desired, getShipmentLinks(harborCompose, "mss-app-web", dockerCompose), func(shipment string, env string) (string, error) {
+		return endpoints[shipment+" "+env], nil
+	})
+	assert.Nil(t, err)
+
+	web := desired.Containers[0]
+	assert.Equal(t, "http://api.elb:80", getEnvVar("BACKEND_URL", web.EnvVars).Value)
+	assert.Equal(t, "http://api.elb:80", getEnvVar("API_URL", web.EnvVars).Value)
+	assert.Equal(t, "http://login", getEnvVar("LOGIN_URL", web.EnvVars).Value)
+	assert.Equal(t, "", getEnvVar("MSS_APP_SEARCH_URL", web.EnvVars).Value)
+
+	sidecar := desired.Containers[1]
+	assert.Equal(t, "http://api.elb:80", getEnvVar("BACKEND_URL", sidecar.EnvVars).Value)
+	assert.Equal(t, "http://api.elb:80", getEnvVar("API_URL", sidecar.EnvVars).Value)
+	assert.Equal(t, "", getEnvVar("LOGIN_URL", sidecar.EnvVars).Value)
+
+	//errors are returned
+	err = applyShipmentLinks(&desired, getShipmentLinks(harborCompose, "mss-app-web", dockerCompose), func(shipment string, env string) (string, error) {
+		return "", errors.New("boom")
+	})
+	assert.EqualError(t, err, "boom")
+}
+
+func TestGetLinkEndpointMockServer(t *testing.T) {
+	server := httptest.NewServer(mockserver.New(&mockserver.Seed{
+		Shipments: []client.ShipmentEnvironment{
+			{
+				Name:           "dev",
+				ParentShipment: client.ParentShipment{Name: "mss-app-api"},
+				Containers: []client.ContainerPayload{{
+					Name:  "api",
+					Image: "registry/api:1.0",
+					Ports: []client.PortPayload{{Name: "PORT", Value: 5000, PublicPort: 80, Protocol: "http", Primary: true}},
+				}},
+				Providers: []client.ProviderPayload{{Name: providerEc2, Replicas: 1, Barge: "corp-sandbox"}},
+			},
+		},
+	}))
+	defer server.Close()
+
+	defer useMockHarbor(server)()
+
+	_, err := getLinkEndpoint("user", "token", "mss-app-search", "dev")
+	assert.EqualError(t, err, "linked shipment environment mss-app-search dev could not be found")
+
+	//falls back to the shipment's dns name until the load balancer exists
+	endpoint, err := getLinkEndpoint("user", "token", "mss-app-api", "dev")
+	assert.Nil(t, err)
+	assert.Equal(t, "http://mss-app-api.dev.services.ec2.dmtio.net:80", endpoint)
+
+	//the load balancer is created when the shipment is triggered
+	_, err = harborClient("user", "token").Trigger.Trigger(context.Background(), "mss-app-api", "dev")
+	assert.Nil(t, err)
+	endpoint, err = getLinkEndpoint("user", "token", "mss-app-api", "dev")
+	assert.Nil(t, err)
+	assert.Equal(t, "http://mss-app-api-dev.corp-sandbox.elb.mock:80", endpoint)
+}
+
+//tests that up sets link env vars on existing containers (and doesn't prune them)
+func TestUpdateShipmentLinks(t *testing.T) {
+	existing := getPlanTestExistingShipment(t)
+	server := httptest.NewServer(mockserver.New(&mockserver.Seed{Shipments: []client.ShipmentEnvironment{existing}}))
+	defer server.Close()
+
+	defer useMockHarbor(server)()
+	defer func(prune bool, yes bool) { upPrune, upYes = prune, yes }(upPrune, upYes)
+	upPrune, upYes = true, true
+
+	dockerComposeYaml := `version: "2"
+services:
+  web:
+    image: quay.io/turner/web:1.0
+    ports:
+    - 80:5000
+    environment:
+      HEALTHCHECK: /hc
+`
+	harborComposeYaml := `shipments:
+  mss-poc-app:
+    env: dev
+    barge: digital-sandbox
+    containers:
+    - web
+    replicas: 2
+    links:
+    - mss-app-api:backend
+`
+	dockerCompose, harborCompose := unmarshalCompose(dockerComposeYaml, harborComposeYaml)
+	composeShipment := harborCompose.Shipments["mss-poc-app"]
+	endpoint := func(shipment string, env string) (string, error) {
+		return "http://api.elb:80", nil
+	}
+
+	for i := 0; i < 2; i++ {
+		desired := transformComposeToShipmentEnvironment("mss-poc-app", composeShipment, dockerCompose)
+		assert.Nil(t, applyShipmentLinks(&desired, getShipmentLinks(harborCompose, "mss-poc-app", dockerCompose), endpoint))
+
+		current, err := GetShipmentEnvironment("user", "token", "mss-poc-app", "dev")
+		assert.Nil(t, err)
+		assert.Nil(t, updateShipment(ioutil.Discard, "user", "token", current, "mss-poc-app", composeShipment, dockerCompose, &desired))
+
+		updated, err := GetShipmentEnvironment("user", "token", "mss-poc-app", "dev")
+		assert.Nil(t, err)
+		web := findContainer("web", updated.Containers)
+		assert.Equal(t, "http://api.elb:80", getEnvVar("BACKEND_URL", web.EnvVars).Value)
+		assert.Equal(t, "/hc", getEnvVar("HEALTHCHECK", web.EnvVars).Value)
+	}
+}
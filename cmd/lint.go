@@ -0,0 +1,530 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/docker/libcompose/project"
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// lintCmd represents the lint command
+var lintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Check compose files for problems without talking to Harbor",
+	Long: `Check compose files for problems without talking to Harbor
+
+Runs the validation that up does (and more) against your docker-compose.yml and harbor-compose.yml files, without logging in or fetching anything from Harbor.  Every problem is reported (with its file and line) rather than stopping at the first one.
+
+The command exits with a non-zero status when problems are found.
+
+Use --output json (or yaml) to output the problems as data (e.g., for editors and CI), or --format to print each problem using a go template.`,
+	Example: `harbor-compose lint
+harbor-compose lint --output json
+harbor-compose lint --format '{{.File}}:{{.Line}} {{.Message}}'
+harbor-compose lint -e prod`,
+	Run:    lint,
+	PreRun: preRunHook,
+}
+
+//maximum length of the "app-env" name (used for alb names)
+const maxAppEnvLength = 32
+
+//matches the line number in a yaml error
+var yamlErrorLinePattern = regexp.MustCompile(`line (\d+)`)
+
+//matches the service and option in a docker compose validation error
+var dockerComposeOptionPattern = regexp.MustCompile(`Unsupported config option for (\S+) service: '([^']+)'`)
+
+func init() {
+	addEnvFlag(lintCmd)
+	RootCmd.AddCommand(lintCmd)
+}
+
+//lintFinding is a problem found in a compose file
+type lintFinding struct {
+	File      string `json:"file"`
+	Line      int    `json:"line"`
+	Shipment  string `json:"shipment,omitempty"`
+	Container string `json:"container,omitempty"`
+	Message   string `json:"message"`
+}
+
+func (f lintFinding) String() string {
+	return fmt.Sprintf("%v:%v: %v", f.File, f.Line, f.Message)
+}
+
+//lintSource is the name and contents of a compose file, used to locate findings
+type lintSource struct {
+	Name    string
+	Content string
+}
+
+//lintSources are the compose files that are being linted
+type lintSources struct {
+	HarborCompose []lintSource
+	DockerCompose lintSource
+}
+
+func lint(cmd *cobra.Command, args []string) {
+	sources, findings := readLintSources(DockerComposeFile, getHarborComposeFiles())
+
+	//a missing file would make the other files report problems that aren't there
+	if len(findings) == 0 {
+		harborCompose, harborFindings := loadLintHarborCompose(sources, ComposeEnvironment)
+		dockerCompose, dockerFindings := loadLintDockerCompose(sources.DockerCompose)
+		findings = append(harborFindings, dockerFindings...)
+
+		//shipments can only be checked against a docker compose file that loads
+		if dockerCompose != nil {
+			findings = append(findings, lintCompose(harborCompose, dockerCompose, sources)...)
+		}
+	}
+	sortLintFindings(findings)
+
+	if isStructuredOutput() {
+		items := []interface{}{}
+		for _, finding := range findings {
+			items = append(items, finding)
+		}
+		check(writeOutput(os.Stdout, items))
+	} else {
+		for _, finding := range findings {
+			fmt.Println(finding)
+		}
+		if len(findings) == 0 {
+			fmt.Println("no problems found")
+		} else {
+			fmt.Printf("%v problem(s) found\n", len(findings))
+		}
+	}
+
+	if len(findings) > 0 {
+		os.Exit(1)
+	}
+}
+
+//reads the compose files, returning the ones that can't be read as findings rather than exiting
+func readLintSources(dockerComposeFile string, harborComposeFiles []string) (lintSources, []lintFinding) {
+	findings := []lintFinding{}
+	read := func(file string) lintSource {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			findings = append(findings, lintFinding{File: file, Message: err.Error()})
+		}
+		return lintSource{Name: file, Content: string(data)}
+	}
+
+	sources := lintSources{DockerCompose: read(dockerComposeFile)}
+	for _, file := range harborComposeFiles {
+		sources.HarborCompose = append(sources.HarborCompose, read(file))
+	}
+	return sources, findings
+}
+
+//loads the docker compose file like unmarshalDockerCompose, except that a problem that prevents loading is returned as a finding rather than exiting
+func loadLintDockerCompose(source lintSource) (project.APIProject, []lintFinding) {
+	dockerCompose, err := parseDockerCompose(source.Content)
+	if err != nil {
+		line := yamlErrorLine(err)
+		if match := dockerComposeOptionPattern.FindStringSubmatch(err.Error()); match != nil {
+			line = source.locate([]string{"services", match[1], match[2]}, []string{match[1], match[2]})
+		}
+		return nil, []lintFinding{{File: source.Name, Line: line, Message: fmt.Sprintf("docker compose error: %v", err)}}
+	}
+	return dockerCompose, []lintFinding{}
+}
+
+//loads the harbor compose files like loadHarborComposeEnv, except that problems that prevent loading
+//(variables, yaml and environment profiles) are returned as findings rather than exiting
+func loadLintHarborCompose(sources lintSources, env string) (HarborCompose, []lintFinding) {
+	findings := []lintFinding{}
+	harborCompose := HarborCompose{Shipments: map[string]ComposeShipment{}}
+
+	files := []string{}
+	for _, source := range sources.HarborCompose {
+		files = append(files, source.Name)
+	}
+	envFile := harborComposeEnvFile(files)
+	lookup, err := harborComposeEnvLookup(envFile)
+	if err != nil {
+		findings = append(findings, lintFinding{File: envFile, Message: err.Error()})
+		lookup = os.LookupEnv
+	}
+
+	documents := []string{}
+	warned := map[string]bool{}
+	for _, source := range sources.HarborCompose {
+		interpolated, unset, err := interpolateHarborCompose(source.Content, lookup)
+		if err != nil {
+			finding := lintFinding{File: source.Name, Message: err.Error()}
+			if e, ok := err.(interpolationError); ok {
+				finding.Line, finding.Message = e.Line, e.Err.Error()
+			}
+			findings = append(findings, finding)
+			continue
+		}
+		warnUnsetVariables(unset, warned)
+
+		//each file is parsed separately so that yaml errors have the right line numbers
+		var parsed HarborCompose
+		if err := yaml.Unmarshal([]byte(interpolated), &parsed); err != nil {
+			findings = append(findings, lintFinding{File: source.Name, Line: yamlErrorLine(err), Message: fmt.Sprintf("harbor compose error: %v", err)})
+			continue
+		}
+		documents = append(documents, interpolated)
+	}
+
+	//a partial config would report problems that aren't there
+	if len(documents) < len(sources.HarborCompose) {
+		return harborCompose, findings
+	}
+
+	merged := documents[0]
+	if len(documents) > 1 {
+		merged, err = mergeHarborComposeYaml(documents)
+		if err != nil {
+			findings = append(findings, lintFinding{File: files[0], Message: err.Error()})
+			return harborCompose, findings
+		}
+	}
+	if err := yaml.Unmarshal([]byte(merged), &harborCompose); err != nil {
+		findings = append(findings, lintFinding{File: files[0], Message: fmt.Sprintf("harbor compose error: %v", err)})
+		return harborCompose, findings
+	}
+
+	//shipments without a profile are still checked using their shared settings
+	for _, name := range sortedShipmentNames(harborCompose) {
+		resolved, err := applyEnvironmentProfile(name, harborCompose.Shipments[name], env)
+		if err != nil {
+			file, line := sources.locateHarborCompose([]string{"shipments", name, "env"}, []string{"shipments", name, "environments"})
+			findings = append(findings, lintFinding{File: file, Line: line, Shipment: name, Message: err.Error()})
+			continue
+		}
+		harborCompose.Shipments[name] = resolved
+	}
+
+	return harborCompose, findings
+}
+
+//returns the line number in a yaml error (or 0)
+func yamlErrorLine(err error) int {
+	match := yamlErrorLinePattern.FindStringSubmatch(err.Error())
+	if match == nil {
+		return 0
+	}
+	line, _ := strconv.Atoi(match[1])
+	return line
+}
+
+//checks compose files for all of the problems that can be found without talking to harbor
+func lintCompose(harborCompose HarborCompose, dockerCompose project.APIProject, sources lintSources) []lintFinding {
+	findings := []lintFinding{}
+
+	//report a problem with a harbor compose setting (looking in the environment profile first)
+	harborFinding := func(shipmentName string, shipment ComposeShipment, container string, message string, path ...string) {
+		paths := [][]string{}
+		if len(path) > 0 {
+			paths = append(paths, append([]string{"shipments", shipmentName, "environments", shipment.Env}, path...))
+		}
+		paths = append(paths, append([]string{"shipments", shipmentName}, path...))
+		file, line := sources.locateHarborCompose(paths...)
+		findings = append(findings, lintFinding{File: file, Line: line, Shipment: shipmentName, Container: container, Message: message})
+	}
+
+	//report a problem with a docker compose service
+	dockerFinding := func(shipmentName string, container string, message string, path ...string) {
+		line := sources.DockerCompose.locate(
+			append([]string{"services", container}, path...),
+			append([]string{container}, path...),
+		)
+		findings = append(findings, lintFinding{File: sources.DockerCompose.Name, Line: line, Shipment: shipmentName, Container: container, Message: message})
+	}
+
+	//sort shipments for consistent output
+	shipmentNames := []string{}
+	for name := range harborCompose.Shipments {
+		shipmentNames = append(shipmentNames, name)
+	}
+	sort.Strings(shipmentNames)
+
+	for _, shipmentName := range shipmentNames {
+		shipment := harborCompose.Shipments[shipmentName]
+
+		if strings.Contains(shipment.Env, "_") {
+			harborFinding(shipmentName, shipment, "", messageEnvironmentUnderscores, "env")
+		}
+
+		appEnv := fmt.Sprintf("%s-%s", shipmentName, shipment.Env)
+		if len(appEnv) > maxAppEnvLength {
+			harborFinding(shipmentName, shipment, "", fmt.Sprintf(messageAppEnvLength, appEnv))
+		}
+
+		if shipment.Barge == "" {
+			harborFinding(shipmentName, shipment, "", messageBargeRequired)
+		}
+
+		if !(shipment.Replicas >= 0 && shipment.Replicas <= 1000) {
+			harborFinding(shipmentName, shipment, "", messageReplicaValidation, "replicas")
+		}
+
+		if shipment.HealthcheckIntervalSeconds != nil && shipment.HealthcheckTimeoutSeconds != nil {
+			if !(*shipment.HealthcheckIntervalSeconds > *shipment.HealthcheckTimeoutSeconds) {
+				harborFinding(shipmentName, shipment, "", messageIntervalGreaterThanTimeout, "healthcheckIntervalSeconds")
+			}
+		}
+
+		if shipment.IamRole != "" && !iamRoleArnPattern.MatchString(shipment.IamRole) {
+			harborFinding(shipmentName, shipment, "", messageIamRoleArn, "iamRole")
+		}
+
+		for _, name := range sortedKeys(shipment.Environment) {
+			if shipment.Environment[name] == "" {
+				harborFinding(shipmentName, shipment, "", fmt.Sprintf("%v: %v", name, messageEnvvarsCannotBeEmpty), "environment", name)
+			}
+		}
+
+		for _, dependency := range shipment.DependsOn {
+			if _, ok := harborCompose.Shipments[dependency]; !ok {
+				harborFinding(shipmentName, shipment, "", fmt.Sprintf(messageDependencyNotFound, shipmentName, dependency), "dependsOn", dependency)
+			}
+		}
+
+		if len(shipment.Containers) == 0 {
+			harborFinding(shipmentName, shipment, "", messageContainerRequired)
+		}
+
+		for _, container := range shipment.Containers {
+			service, found := dockerCompose.GetServiceConfig(container)
+			if !found {
+				harborFinding(shipmentName, shipment, container, fmt.Sprintf("container %v cannot be found in %v", container, sources.DockerCompose.Name), "containers", container)
+				continue
+			}
+
+			if _, err := parseVersionedImage(service.Image); err != nil {
+				dockerFinding(shipmentName, container, fmt.Sprintf("%v: %v", container, err), "image")
+			}
+
+			if len(service.Ports) == 0 {
+				dockerFinding(shipmentName, container, fmt.Sprintf("%v: %v", container, messagePortRequired))
+			}
+
+			//the healthcheck can either be an env var or a port setting
+			environment := service.Environment.ToMap()
+			if _, ok := environment[healthCheckEnvVarName]; !ok && !hasPortHealthcheck(shipment.Ports[container]) {
+				dockerFinding(shipmentName, container, fmt.Sprintf("%v: %v", container, messageHealthCheckRequired))
+			}
+
+			for _, name := range sortedKeys(environment) {
+				if environment[name] == "" {
+					dockerFinding(shipmentName, container, fmt.Sprintf("%v: %v: %v", container, name, messageEnvvarsCannotBeEmpty), "environment", name)
+				}
+			}
+		}
+
+		//ports are transformed and validated the same way as up
+		containers := []ContainerPayload{}
+		for _, container := range shipment.Containers {
+			service, found := dockerCompose.GetServiceConfig(container)
+			if !found || len(service.Ports) == 0 {
+				continue
+			}
+
+			validMappings := true
+			for _, mapping := range service.Ports {
+				if _, _, err := parsePortMapping(mapping); err != nil {
+					dockerFinding(shipmentName, container, fmt.Sprintf("%v: %v", container, err), "ports", mapping)
+					validMappings = false
+				}
+			}
+			if !validMappings {
+				continue
+			}
+
+			ports, err := transformComposePorts(shipment, container, service.Ports, service.Environment.ToMap()[healthCheckEnvVarName])
+			if err != nil {
+				harborFinding(shipmentName, shipment, container, err.Error(), "ports", container)
+				continue
+			}
+			containers = append(containers, ContainerPayload{Name: container, Ports: ports})
+		}
+
+		for _, container := range sortedPortContainers(shipment.Ports) {
+			if !containsString(shipment.Containers, container) {
+				harborFinding(shipmentName, shipment, container, fmt.Sprintf(messagePortsContainerNotFound, container), "ports", container)
+			}
+		}
+
+		httpsErr := validateHTTPS(shipment.LoadBalancer)
+		if httpsErr != nil {
+			harborFinding(shipmentName, shipment, "", httpsErr.Error(), "loadBalancer", "https")
+		}
+
+		//the primary port can only be checked when every container's ports are known
+		if len(containers) > 0 && len(containers) == len(shipment.Containers) {
+			if !hasExplicitPrimaryPort(shipment) {
+				containers[0].Ports[0].Primary = true
+			}
+			if httpsErr == nil {
+				applyHTTPS(shipment.LoadBalancer, containers)
+			}
+			if err := validatePorts(containers); err != nil {
+				harborFinding(shipmentName, shipment, "", err.Error(), "ports")
+			}
+		}
+	}
+
+	//cycles are reported on the first shipment in the cycle (unknown dependencies are reported above)
+	addLinkDependencies(&harborCompose, dockerCompose)
+	if _, err := shipmentTiers(harborCompose); err != nil {
+		if cycle, ok := err.(dependencyCycleError); ok {
+			name := cycle.Cycle[0]
+			harborFinding(name, harborCompose.Shipments[name], "", cycle.Error(), "dependsOn")
+		}
+	}
+
+	sortLintFindings(findings)
+	return findings
+}
+
+//sorts findings by file and line
+func sortLintFindings(findings []lintFinding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].File != findings[j].File {
+			return findings[i].File < findings[j].File
+		}
+		return findings[i].Line < findings[j].Line
+	})
+}
+
+//returns the containers that have port settings, sorted
+func sortedPortContainers(ports map[string][]ComposePort) []string {
+	containers := []string{}
+	for container := range ports {
+		containers = append(containers, container)
+	}
+	sort.Strings(containers)
+	return containers
+}
+
+func hasPortHealthcheck(ports []ComposePort) bool {
+	for _, port := range ports {
+		if port.Healthcheck != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+//returns the file and line of the first path found in the harbor compose files
+//later files win since they override earlier ones
+func (s lintSources) locateHarborCompose(paths ...[]string) (string, int) {
+	for _, path := range paths {
+		for i := len(s.HarborCompose) - 1; i >= 0; i-- {
+			if line, found := findYamlLine(s.HarborCompose[i].Content, path); found {
+				return s.HarborCompose[i].Name, line
+			}
+		}
+	}
+
+	//fall back to the closest parent in the first file
+	first := s.HarborCompose[0]
+	line, _ := findYamlLine(first.Content, paths[len(paths)-1])
+	return first.Name, line
+}
+
+//returns the line of the first path found in the source (or the closest parent of the last path)
+func (s lintSource) locate(paths ...[]string) int {
+	for _, path := range paths {
+		if line, found := findYamlLine(s.Content, path); found {
+			return line
+		}
+	}
+	line, _ := findYamlLine(s.Content, paths[len(paths)-1])
+	return line
+}
+
+//finds the line number of a key path in a block style yaml document
+//list items are matched by value (e.g., "- web") or name (e.g., "- FOO=bar")
+//returns the line of the closest parent and false if the full path isn't found
+func findYamlLine(content string, path []string) (int, bool) {
+	lines := strings.Split(content, "\n")
+	result := 1
+	start := 0
+	parentIndent := -1
+
+	for _, key := range path {
+		found := false
+		childIndent := -1
+		childIsListItem := false
+		for i := start; i < len(lines); i++ {
+			trimmed := strings.TrimSpace(lines[i])
+			if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+				continue
+			}
+
+			//stop when leaving the parent block (list items can be at the parent's indentation)
+			indent := len(lines[i]) - len(strings.TrimLeft(lines[i], " "))
+			isListItem := strings.HasPrefix(trimmed, "-")
+			if indent < parentIndent || (indent == parentIndent && !isListItem) {
+				break
+			}
+
+			//only look at direct children (of the same kind as the first one, since
+			//compact lists are at the same indentation as the keys of their parent)
+			if childIndent == -1 {
+				childIndent = indent
+				childIsListItem = isListItem
+			}
+			if indent != childIndent || isListItem != childIsListItem {
+				continue
+			}
+
+			if yamlLineMatches(trimmed, key) {
+				result = i + 1
+				start = i + 1
+				parentIndent = indent
+				found = true
+				break
+			}
+		}
+		if !found {
+			return result, false
+		}
+	}
+
+	return result, true
+}
+
+//returns true if a trimmed yaml line is the key (or list item) name
+func yamlLineMatches(trimmed string, key string) bool {
+	if strings.HasPrefix(trimmed, "-") {
+		item := strings.TrimSpace(strings.TrimPrefix(trimmed, "-"))
+		item = strings.Trim(item, `"'`)
+		if item == key || strings.HasPrefix(item, key+"=") {
+			return true
+		}
+		trimmed = item
+	}
+	for _, quote := range []string{"", `"`, "'"} {
+		if strings.HasPrefix(trimmed, quote+key+quote+":") {
+			return true
+		}
+	}
+	return false
+}
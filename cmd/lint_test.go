@@ -0,0 +1,296 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func getLintFindings(t *testing.T, dockerComposeYaml string, harborComposeYaml string) []lintFinding {
+	dockerCompose, harborCompose := unmarshalCompose(dockerComposeYaml, harborComposeYaml)
+	assert.Nil(t, applyEnvironmentProfiles(&harborCompose, ""))
+	sources := lintSources{
+		HarborCompose: []lintSource{{Name: "harbor-compose.yml", Content: harborComposeYaml}},
+		DockerCompose: lintSource{Name: "docker-compose.yml", Content: dockerComposeYaml},
+	}
+	return lintCompose(harborCompose, dockerCompose, sources)
+}
+
+func TestLintValid(t *testing.T) {
+	dockerComposeYaml := `version: "2"
+services:
+  web:
+    image: registry/web:1.0
+    ports:
+    - 80:5000
+    environment:
+      HEALTHCHECK: /hc
+`
+	harborComposeYaml := `shipments:
+  mss-poc-app:
+    env: dev
+    barge: corp-sandbox
+    containers:
+    - web
+    replicas: 2
+`
+
+	findings := getLintFindings(t, dockerComposeYaml, harborComposeYaml)
+	assert.Equal(t, 0, len(findings))
+}
+
+func TestLintReportsEveryProblem(t *testing.T) {
+	dockerComposeYaml := `version: "2"
+services:
+  web:
+    image: registry:5000/web
+    ports:
+    - 80:5000
+    environment:
+      FOO: bar
+      EMPTY: ""
+  worker:
+    image: registry/worker:1.0
+`
+	harborComposeYaml := `shipments:
+  mss-poc-app-with-a-really-long-name:
+    env: dev_env
+    containers:
+    - web
+    - worker
+    - missing
+    replicas: 1001
+    healthcheckTimeoutSeconds: 10
+    healthcheckIntervalSeconds: 5
+    environment:
+      BLANK: ""
+`
+
+	findings := getLintFindings(t, dockerComposeYaml, harborComposeYaml)
+
+	expected := []lintFinding{
+		{File: "docker-compose.yml", Line: 3, Container: "web", Message: "web: " + messageHealthCheckRequired},
+		{File: "docker-compose.yml", Line: 4, Container: "web", Message: "web: image registry:5000/web must have a tag or digest"},
+		{File: "docker-compose.yml", Line: 9, Container: "web", Message: "web: EMPTY: " + messageEnvvarsCannotBeEmpty},
+		{File: "docker-compose.yml", Line: 10, Container: "worker", Message: "worker: " + messagePortRequired},
+		{File: "docker-compose.yml", Line: 10, Container: "worker", Message: "worker: " + messageHealthCheckRequired},
+		{File: "harbor-compose.yml", Line: 2, Message: "mss-poc-app-with-a-really-long-name-dev_env (app-env) must be <= 32 characters"},
+		{File: "harbor-compose.yml", Line: 2, Message: messageBargeRequired},
+		{File: "harbor-compose.yml", Line: 3, Message: messageEnvironmentUnderscores},
+		{File: "harbor-compose.yml", Line: 7, Container: "missing", Message: "container missing cannot be found in docker-compose.yml"},
+		{File: "harbor-compose.yml", Line: 8, Message: messageReplicaValidation},
+		{File: "harbor-compose.yml", Line: 10, Message: messageIntervalGreaterThanTimeout},
+		{File: "harbor-compose.yml", Line: 12, Message: "BLANK: " + messageEnvvarsCannotBeEmpty},
+	}
+
+	//only compare the fields that matter
+	actual := []lintFinding{}
+	for _, finding := range findings {
+		assert.Equal(t, "mss-poc-app-with-a-really-long-name", finding.Shipment)
+		finding.Shipment = ""
+		actual = append(actual, finding)
+	}
+	assert.Equal(t, expected, actual)
+}
+
+func TestLintEnvironmentProfile(t *testing.T) {
+	dockerComposeYaml := `version: "2"
+services:
+  web:
+    image: registry/web:1.0
+    ports:
+    - 80:5000
+    environment:
+      HEALTHCHECK: /hc
+`
+	harborComposeYaml := `shipments:
+  mss-poc-app:
+    env: prod
+    barge: corp-sandbox
+    containers:
+    - web
+    replicas: 2
+    environments:
+      prod:
+        replicas: 5000
+`
+
+	findings := getLintFindings(t, dockerComposeYaml, harborComposeYaml)
+	assert.Equal(t, 1, len(findings))
+	assert.Equal(t, 10, findings[0].Line)
+	assert.Equal(t, messageReplicaValidation, findings[0].Message)
+}
+
+func TestLintPorts(t *testing.T) {
+	dockerComposeYaml := `version: "2"
+services:
+  web:
+    image: registry/web:1.0
+    ports:
+    - 80:5000
+    - 443:5001
+    environment:
+      HEALTHCHECK: /hc
+  worker:
+    image: registry/worker:1.0
+    ports:
+    - 8080-8081:8080-8081
+    environment:
+      HEALTHCHECK: /hc
+`
+	harborComposeYaml := `shipments:
+  mss-poc-app:
+    env: dev
+    barge: corp-sandbox
+    containers:
+    - web
+    - worker
+    replicas: 2
+    ports:
+      api:
+      - port: 3000
+`
+
+	findings := getLintFindings(t, dockerComposeYaml, harborComposeYaml)
+	assert.Equal(t, 2, len(findings))
+	assert.Equal(t, lintFinding{File: "docker-compose.yml", Line: 13, Shipment: "mss-poc-app", Container: "worker", Message: "worker: invalid port: 8080-8081:8080-8081"}, findings[0])
+	assert.Equal(t, lintFinding{File: "harbor-compose.yml", Line: 10, Shipment: "mss-poc-app", Container: "api", Message: "ports settings refer to container api which is not in the containers list"}, findings[1])
+
+	//multiple primary ports
+	harborComposeYaml = `shipments:
+  mss-poc-app:
+    env: dev
+    barge: corp-sandbox
+    containers:
+    - web
+    replicas: 2
+    ports:
+      web:
+      - port: 5000
+        primary: true
+      - port: 5001
+        primary: true
+`
+	findings = getLintFindings(t, dockerComposeYaml, harborComposeYaml)
+	assert.Equal(t, []lintFinding{{File: "harbor-compose.yml", Line: 8, Shipment: "mss-poc-app", Message: messagePrimaryPortRequired}}, findings)
+
+	//settings for a port that isn't mapped
+	harborComposeYaml = strings.Replace(harborComposeYaml, "        primary: true\n", "", 1)
+	harborComposeYaml = strings.Replace(harborComposeYaml, "port: 5001", "port: 5002", 1)
+	findings = getLintFindings(t, dockerComposeYaml, harborComposeYaml)
+	assert.Equal(t, []lintFinding{{File: "harbor-compose.yml", Line: 9, Shipment: "mss-poc-app", Container: "web", Message: "ports setting for container web port 5002 does not match a port mapping in the docker compose file"}}, findings)
+}
+
+func TestLintLoadProblems(t *testing.T) {
+	os.Unsetenv("HC_LINT_UNSET")
+
+	//variables and environment profiles are reported instead of exiting
+	sources := lintSources{HarborCompose: []lintSource{{Name: "harbor-compose.yml", Content: `shipments:
+  my-app:
+    env: dev
+    barge: ${HC_LINT_UNSET:?barge is required}
+  my-api:
+    barge: corp-sandbox
+    environments:
+      prod:
+        replicas: 3
+`}}}
+	_, findings := loadLintHarborCompose(sources, "")
+	assert.Equal(t, []lintFinding{{File: "harbor-compose.yml", Line: 4, Message: "HC_LINT_UNSET: barge is required"}}, findings)
+
+	sources.HarborCompose[0].Content = strings.Replace(sources.HarborCompose[0].Content, ":?barge is required", ":-corp-sandbox", 1)
+	harborCompose, findings := loadLintHarborCompose(sources, "")
+	assert.Equal(t, []lintFinding{{File: "harbor-compose.yml", Line: 7, Shipment: "my-api", Message: "shipment my-api defines environments, please specify one using env or the --env flag"}}, findings)
+	assert.Equal(t, "corp-sandbox", harborCompose.Shipments["my-app"].Barge)
+
+	harborCompose, findings = loadLintHarborCompose(sources, "prod")
+	assert.Equal(t, 0, len(findings))
+	assert.Equal(t, 3, harborCompose.Shipments["my-api"].Replicas)
+
+	_, findings = loadLintHarborCompose(sources, "qa")
+	assert.Equal(t, []lintFinding{{File: "harbor-compose.yml", Line: 7, Shipment: "my-api", Message: "environment qa is not defined in the environments for shipment my-api"}}, findings)
+
+	//yaml errors have the line of the file they're in
+	sources.HarborCompose = append(sources.HarborCompose, lintSource{Name: "harbor-compose.prod.yml", Content: "shipments:\n  my-app:\n    replicas: [\n"})
+	_, findings = loadLintHarborCompose(sources, "")
+	assert.Equal(t, 1, len(findings))
+	assert.Equal(t, "harbor-compose.prod.yml", findings[0].File)
+	assert.Equal(t, 3, findings[0].Line)
+	assert.Contains(t, findings[0].Message, "harbor compose error: yaml: line 3")
+}
+
+func TestLintDockerComposeLoadProblems(t *testing.T) {
+
+	//files that can't be read are reported instead of exiting
+	sources, findings := readLintSources("missing-docker-compose.yml", []string{"missing-harbor-compose.yml"})
+	assert.Equal(t, 2, len(findings))
+	assert.Equal(t, "missing-docker-compose.yml", findings[0].File)
+	assert.Contains(t, findings[0].Message, "no such file or directory")
+	assert.Equal(t, "missing-harbor-compose.yml", findings[1].File)
+	assert.Equal(t, "missing-docker-compose.yml", sources.DockerCompose.Name)
+
+	//as are docker compose files that can't be parsed
+	dockerCompose, findings := loadLintDockerCompose(lintSource{Name: "docker-compose.yml", Content: "version: \"2\"\nservices:\n  web:\n    image: [\n"})
+	assert.Nil(t, dockerCompose)
+	assert.Equal(t, 1, len(findings))
+	assert.Equal(t, "docker-compose.yml", findings[0].File)
+	assert.Equal(t, 4, findings[0].Line)
+	assert.Contains(t, findings[0].Message, "docker compose error: yaml: line 4")
+
+	dockerCompose, findings = loadLintDockerCompose(lintSource{Name: "docker-compose.yml", Content: "version: \"2\"\nservices:\n  web:\n    image: registry/web:1.0\n    bogus: 1\n"})
+	assert.Nil(t, dockerCompose)
+	assert.Equal(t, []lintFinding{{File: "docker-compose.yml", Line: 5, Message: "docker compose error: Unsupported config option for web service: 'bogus'"}}, findings)
+
+	dockerCompose, findings = loadLintDockerCompose(lintSource{Name: "docker-compose.yml", Content: "version: \"2\"\nservices:\n  web:\n    image: registry/web:1.0\n"})
+	assert.NotNil(t, dockerCompose)
+	assert.Equal(t, 0, len(findings))
+}
+
+func TestLintLocateOverride(t *testing.T) {
+	sources := lintSources{
+		HarborCompose: []lintSource{
+			{Name: "harbor-compose.yml", Content: "shipments:\n  app:\n    env: dev\n    replicas: 2\n"},
+			{Name: "harbor-compose.prod.yml", Content: "shipments:\n  app:\n    replicas: 2000\n"},
+		},
+	}
+
+	file, line := sources.locateHarborCompose([]string{"shipments", "app", "replicas"})
+	assert.Equal(t, "harbor-compose.prod.yml", file)
+	assert.Equal(t, 3, line)
+
+	file, line = sources.locateHarborCompose([]string{"shipments", "app", "env"})
+	assert.Equal(t, "harbor-compose.yml", file)
+	assert.Equal(t, 3, line)
+
+	//missing keys fall back to the closest parent
+	file, line = sources.locateHarborCompose([]string{"shipments", "app", "barge"})
+	assert.Equal(t, "harbor-compose.yml", file)
+	assert.Equal(t, 2, line)
+}
+
+func TestFindYamlLine(t *testing.T) {
+	content := `version: "2"
+services:
+  web:
+    environment:
+    - FOO=bar
+    - "BAR=baz"
+  worker:
+    environment:
+      FOO: bar
+`
+	line, found := findYamlLine(content, []string{"services", "web", "environment", "BAR"})
+	assert.True(t, found)
+	assert.Equal(t, 6, line)
+
+	//doesn't leave the parent block
+	line, found = findYamlLine(content, []string{"services", "web", "FOO"})
+	assert.False(t, found)
+	assert.Equal(t, 3, line)
+
+	line, found = findYamlLine(content, []string{"services", "worker", "environment", "FOO"})
+	assert.True(t, found)
+	assert.Equal(t, 9, line)
+}
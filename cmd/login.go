@@ -30,8 +30,6 @@ var loginCmd = &cobra.Command{
 	PreRun: preRunHook,
 }
 
-var authURL = "https://auth.services.dmtio.net"
-
 func init() {
 	RootCmd.AddCommand(loginCmd)
 }
@@ -79,21 +77,14 @@ func writeAuthFile(version string, username string, token string) (bool, error)
 	return true, nil
 }
 
+//reads the stored credentials (without changing the working directory, since shipments can be processed concurrently)
 func readAuthFile() (*Auth, error) {
 	home, err := homedir.Dir()
 	if err != nil {
 		return nil, err
 	}
 
-	curpath, _ := os.Getwd()
-
-	var path = home + "/.harbor"
-	err = os.Chdir(path)
-	if err != nil {
-		return nil, err
-	}
-
-	var credPath = path + "/credentials"
+	var credPath = home + "/.harbor/credentials"
 	byteData, err := ioutil.ReadFile(credPath)
 	if err != nil {
 		return nil, err
@@ -105,8 +96,6 @@ func readAuthFile() (*Auth, error) {
 		return nil, err
 	}
 
-	_ = os.Chdir(curpath)
-
 	return &serializedAuth, nil
 }
 
@@ -152,7 +141,7 @@ func Login() (string, string, error) {
 
 //harborLogin -
 func harborLogin(username string, password string) (string, error) {
-	client, err := harborauth.NewAuthClient(authURL)
+	client, err := harborauth.NewAuthClient(GetConfig().AuthURI)
 	if err != nil {
 		if Verbose {
 			fmt.Println(err)
@@ -178,7 +167,7 @@ func harborLogin(username string, password string) (string, error) {
 }
 
 func harborAuthenticated(username string, token string) (bool, error) {
-	client, err := harborauth.NewAuthClient(authURL)
+	client, err := harborauth.NewAuthClient(GetConfig().AuthURI)
 	if err != nil {
 		return false, err
 	}
@@ -70,7 +70,7 @@ func deleteFile() (bool, error) {
 }
 
 func harborLogout(username string, token string) (bool, error) {
-	client, err := harborauth.NewAuthClient(authURL)
+	client, err := harborauth.NewAuthClient(GetConfig().AuthURI)
 	if err != nil {
 		log.Fatalf(err.Error())
 		return false, err
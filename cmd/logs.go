@@ -1,22 +1,40 @@
 package cmd
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log"
+	"os"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh/terminal"
 )
 
 var logTime bool
 var separateLogs bool
-var tail bool
+var follow bool
+var logsTail bool
+var logsLines int
+var logsSince string
+var logsUntil string
+var logsGrep string
+var logsExclude string
+var logsHosts []string
+var logsLevel string
+var logsPretty bool
+var logsNoColor bool
+
+//true when container prefixes and log levels are colorized
+var logsColor bool
 
 // logsCmd represents the logs command
 var logsCmd = &cobra.Command{
-	Use:   "logs [logid ...]",
+	Use:   "logs [container ...]",
 	Short: "View output from containers",
 	Long:  "View output of containers. There are few options available to make this easier to view.",
 	Example: `harbor-compose logs
@@ -28,17 +46,48 @@ harbor-compose logs --s my-shipment --e dev
 
 Stream the logs from all of your replicas
 Examples:
-harbor-compose logs --tail
+harbor-compose logs --follow
 harbor-compose logs -t
+harbor-compose logs --follow --lines 100
+
+Print the last 100 lines from each container
+Examples:
+harbor-compose logs --lines 100
+harbor-compose logs -n 100
+
+Print the logs from a time window (a duration like 10m or 1h30m, or a timestamp like 2017-05-01T12:00:00Z)
+Examples:
+harbor-compose logs --since 10m
+harbor-compose logs --since 2017-05-01T12:00:00Z --until 2017-05-01T13:00:00Z
+
+Print the lines that match (or don't match) a regular expression
+Examples:
+harbor-compose logs --grep 'error|panic' --exclude healthcheck
+
+Print the logs as json (or yaml, or using a go template)
+Examples:
+harbor-compose logs --output json
+harbor-compose logs --output jsonl --follow
+harbor-compose logs --format '{{.Time}} {{.Container}} {{.Log}}'
+
+Print the level and message of json logs (followed by their other fields), hiding the ones below warn
+Examples:
+harbor-compose logs --pretty --level warn
 
 Print the logs by each container
 Examples:
 harbor-compose logs --separate
 harbor-compose logs -S -T
 
-You can also pass in arguments to the function to allow for n number of specific queries.
+Download a bundle of logs, status and events (e.g., for an incident)
+Examples:
+harbor-compose logs export --since 2h
+
+Print the logs for specific containers (by name or id) or replicas (by host)
 Examples:
-harbor-compose logs 9e70dc6 14ffbf5`,
+harbor-compose logs web
+harbor-compose logs 9e70dc6 14ffbf5
+harbor-compose logs --host ip-10-0-0-1.ec2.internal`,
 	Run:    logs,
 	PreRun: preRunHook,
 }
@@ -48,10 +97,23 @@ var logsEnvironment string
 
 func init() {
 	logsCmd.PersistentFlags().StringVarP(&logsShipment, "shipment", "s", "", "shipment name")
-	logsCmd.PersistentFlags().StringVarP(&logsEnvironment, "environment", "e", "", "environment name")
+	logsCmd.PersistentFlags().StringVarP(&logsEnvironment, "environment", "e", "", "environment name (or --env)")
 	logsCmd.PersistentFlags().BoolVarP(&logTime, "time", "T", false, "append time to logs")
 	logsCmd.PersistentFlags().BoolVarP(&separateLogs, "separate", "S", false, "print logs by each container")
-	logsCmd.PersistentFlags().BoolVarP(&tail, "tail", "t", false, "continue to stream log output to stdout.")
+	logsCmd.PersistentFlags().BoolVarP(&follow, "follow", "t", false, "continue to stream log output to stdout.")
+	logsCmd.PersistentFlags().BoolVarP(&logsTail, "tail", "", false, "continue to stream log output to stdout.")
+	logsCmd.PersistentFlags().MarkHidden("tail")
+	logsCmd.PersistentFlags().IntVarP(&logsLines, "lines", "n", -1, "number of lines to show from the end of each container's logs (all by default, or none when following)")
+	logsCmd.PersistentFlags().StringVarP(&logsSince, "since", "", "", "show logs since a timestamp (e.g., 2017-05-01T12:00:00Z) or relative duration (e.g., 10m)")
+	logsCmd.PersistentFlags().StringVarP(&logsUntil, "until", "", "", "show logs before a timestamp (e.g., 2017-05-01T12:00:00Z) or relative duration (e.g., 10m)")
+	logsCmd.PersistentFlags().StringVarP(&logsGrep, "grep", "", "", "only show lines that match a regular expression")
+	logsCmd.PersistentFlags().StringVarP(&logsExclude, "exclude", "", "", "hide lines that match a regular expression")
+	logsCmd.PersistentFlags().StringVarP(&logsLevel, "level", "", "", "only show json logs at or above a level (trace, debug, info, warn, error or fatal)")
+	logsCmd.PersistentFlags().BoolVarP(&logsPretty, "pretty", "", false, "print the level and message of json logs followed by their other fields")
+	logsCmd.PersistentFlags().BoolVarP(&logsNoColor, "no-color", "", false, "don't colorize the output of --pretty")
+	logsCmd.PersistentFlags().StringArrayVarP(&logsHosts, "host", "", []string{}, "only show logs from containers running on a replica host (can be repeated)")
+	addParallelFlag(logsCmd)
+	addEnvAlias(logsCmd)
 	RootCmd.AddCommand(logsCmd)
 }
 
@@ -60,6 +122,27 @@ func init() {
 // Flags: -t: adds time to the logs
 // TODO: add the rest of the flags to match docker-compose
 func logs(cmd *cobra.Command, args []string) {
+	//--tail is the original name of --follow
+	if logsTail {
+		log.Println(messageLogsTailDeprecated)
+		follow = true
+	}
+
+	//only new lines are streamed unless --lines is specified
+	lines := logsLines
+	if follow && !cmd.Flags().Changed("lines") {
+		lines = 0
+	}
+
+	if follow && isStructuredOutput() && (outputFormat != "jsonl" || outputTemplate != "") {
+		check(errors.New(messageOutputFollow))
+	}
+
+	filter, err := newLogFilter(logsSince, logsUntil, logsGrep, logsExclude, logsLevel, lines, args, logsHosts)
+	check(err)
+
+	//colorize when writing to a terminal (like docker-compose logs)
+	logsColor = logsPretty && !logsNoColor && terminal.IsTerminal(int(os.Stdout.Fd()))
 
 	//make sure user is authenticated
 	username, token, err := Login()
@@ -69,53 +152,124 @@ func logs(cmd *cobra.Command, args []string) {
 	inputShipmentEnvironments, _ := getShipmentEnvironmentsFromInput(logsShipment, logsEnvironment)
 
 	//iterate shipment/environments
-	for _, t := range inputShipmentEnvironments {
-		shipment := t.Item1
-		env := t.Item2
+	var output shipmentOutput
+	var mu sync.Mutex
+	targets := []helmitTarget{}
+	tasks := tupleShipmentTasks(inputShipmentEnvironments)
+	runShipments(tasks, func(out io.Writer, task shipmentTask) error {
+		shipment := task.Shipment
+		env := task.Env
 
 		//lookup the shipment environment
-		shipmentEnvironment := GetShipmentEnvironment(username, token, shipment, env)
+		shipmentEnvironment, err := GetShipmentEnvironment(username, token, shipment, env)
+		if err != nil {
+			return err
+		}
 		if shipmentEnvironment == nil {
-			fmt.Println(messageShipmentEnvironmentNotFound)
-			return
-		}		
+			return errors.New(messageShipmentEnvironmentNotFound)
+		}
 
 		//lookup the provider
 		provider := ec2Provider(shipmentEnvironment.Providers)
 
-		fmt.Printf("Logs For:  %s %s\n", shipment, env)
+		//the logs of every shipment environment are followed together
+		if follow {
+			fmt.Fprintf(out, "Logs For:  %s %s\n", shipment, env)
+			mu.Lock()
+			defer mu.Unlock()
+			targets = append(targets, helmitTarget{Shipment: shipment, Env: env, Barge: provider.Barge})
+			return nil
+		}
+
+		if isStructuredOutput() {
+			helmitObject, err := GetLogs(provider.Barge, shipment, env)
+			if err != nil {
+				return err
+			}
+			output.add(task, getLogOutputs(shipment, env, *helmitObject, filter)...)
+			return nil
+		}
+
+		fmt.Fprintf(out, "Logs For:  %s %s\n", shipment, env)
 		if len(args) > 0 && Verbose == true {
-			fmt.Println("Make sure the ID is either the 7 char shortstring of the container or the entire ID")
+			fmt.Fprintln(out, "Make sure the ID is either the 7 char shortstring of the container or the entire ID")
 			for _, arg := range args {
-				fmt.Printf("Getting Logs for Container:  %s\n", arg)
+				fmt.Fprintf(out, "Getting Logs for Container:  %s\n", arg)
 			}
 		}
 
-		helmitObject := HelmitResponse{}
-
-		var response = GetLogs(provider.Barge, shipment, env)
-		err := json.Unmarshal([]byte(response), &helmitObject)
+		helmitObject, err := GetLogs(provider.Barge, shipment, env)
 		if err != nil {
-			fmt.Println(err)
+			return err
 		}
 
-		fmt.Println(args)
+		fmt.Fprintln(out, args)
 
 		if separateLogs {
-			printSeparateLogs(helmitObject, args)
+			printSeparateLogs(out, *helmitObject, filter)
 		} else {
-			printMergedLogs(helmitObject, args)
+			printMergedLogs(out, *helmitObject, filter)
+		}
+		return nil
+	})
+
+	if follow {
+		ctx, cancel := interruptContext()
+		defer cancel()
+		check(followLogs(ctx, os.Stdout, targets, filter))
+		return
+	}
+
+	if isStructuredOutput() {
+		check(output.write(os.Stdout, tasks))
+	}
+}
+
+//returns objects representing a shipment's log lines (sorted by time)
+func getLogOutputs(shipment string, env string, helmitObject HelmitResponse, filter logFilter) []interface{} {
+	logs := []LogOutput{}
+	for _, provider := range helmitObject.Replicas {
+		for _, container := range provider.Containers {
+
+			if !filter.container(provider.Host, container) {
+				continue
+			}
+
+			logsObject := logsObject{
+				Shipment:    shipment,
+				Environment: env,
+				Host:        provider.Host,
+				Name:        container.Name,
+				ID:          container.ID,
+				Image:       container.Image,
+			}
+			for _, parsedLog := range filter.logs(container) {
+				logs = append(logs, newLogOutput(logsObject, parsedLog))
+			}
 		}
 	}
+
+	sort.SliceStable(logs, func(i, j int) bool {
+		return logs[i].Time.Before(logs[j].Time)
+	})
+
+	result := []interface{}{}
+	for _, log := range logs {
+		result = append(result, log)
+	}
+	return result
 }
 
 // logsObject that contains a containers logs
 type logsObject struct {
-	Name      string
-	ID        string
-	Image     string
-	Logstream string
-	Logs      Logs
+	Shipment    string
+	Environment string
+	Host        string
+	Name        string
+	ID          string
+	Image       string
+	Logstream   string
+	Logs        Logs
 }
 
 // logObject is a log object
@@ -139,17 +293,6 @@ func (slice Logs) Swap(i, j int) {
 	slice[i], slice[j] = slice[j], slice[i]
 }
 
-// check to see if string is in the array
-// http://stackoverflow.com/questions/15323767/does-golang-have-if-x-in-construct-similar-to-python
-func stringInSlice(a string, list []string) bool {
-	for _, b := range list {
-		if b == a {
-			return true
-		}
-	}
-	return false
-}
-
 // parseContainerLog will parse a log from docker and create an object containing needed information
 func parseContainerLog(log string) (logObj logObject, errstring string) {
 	layout := time.RFC3339
@@ -171,31 +314,21 @@ func parseContainerLog(log string) (logObj logObject, errstring string) {
 	return
 }
 
-func printMergedLogs(shipment HelmitResponse, ids []string) {
+func printMergedLogs(out io.Writer, shipment HelmitResponse, filter logFilter) {
 	shipmentLogs := []logsObject{}
 	for _, provider := range shipment.Replicas {
 		for _, container := range provider.Containers {
 
-			if len(ids) > 0 && !stringInSlice(container.ID, ids) && !stringInSlice(container.ID[0:7], ids) {
+			if !filter.container(provider.Host, container) {
 				continue
 			}
 
-			var containerLogs = Logs{}
-			for _, logstring := range container.Logs {
-				parsedLog, err := parseContainerLog(logstring)
-				if err != "" {
-					continue
-				}
-				containerLogs = append(containerLogs, parsedLog)
-			}
-
 			// set current log object
 			var logsObject = logsObject{}
 			logsObject.Name = container.Name
 			logsObject.ID = container.ID
 			logsObject.Image = container.Image
-			logsObject.Logs = containerLogs
-			logsObject.Logstream = container.Logstream
+			logsObject.Logs = filter.logs(container)
 			shipmentLogs = append(shipmentLogs, logsObject)
 
 		}
@@ -204,12 +337,7 @@ func printMergedLogs(shipment HelmitResponse, ids []string) {
 	var mergedLogs Logs
 	for _, logObject := range shipmentLogs {
 		for _, logObj := range logObject.Logs {
-			newLog := logObject.Name + ":" + logObject.ID[0:7] + "  | "
-			if logTime == true {
-				newLog = newLog + logObj.Time.String() + ", "
-			}
-
-			logObj.Log = newLog + logObj.Log + "\n"
+			logObj.Log = formatLogLine(logObject, logObj) + "\n"
 			mergedLogs = append(mergedLogs, logObj)
 		}
 	}
@@ -217,80 +345,31 @@ func printMergedLogs(shipment HelmitResponse, ids []string) {
 	sort.Sort(mergedLogs)
 
 	for _, log := range mergedLogs {
-		fmt.Printf(log.Log)
-	}
-
-	if tail == true {
-		for _, streamObj := range shipmentLogs {
-			go followStream(streamObj)
-		}
-		var input string
-		fmt.Scanln(&input)
-	}
-}
-
-// followStream will take a logsObject param and print out all data that comes from the
-// logStream field. This is a normal http response, which never ends.
-func followStream(streamObj logsObject) {
-	stream := strings.Replace(streamObj.Logstream, "tail=500", "tail=0", -1)
-	streamer, err := GetLogStreamer(stream)
-	check(err)
-	for {
-		line, streamErr := streamer.ReadBytes('\n')
-		check(streamErr)
-		logObj, err := parseContainerLog(string(line)[8:])
-
-		if err != "" {
-			fmt.Println(err)
-			fmt.Println(string(line))
-			continue
-		}
-
-		newLog := streamObj.Name + ":" + streamObj.ID[0:7] + "  | "
-		if logTime == true {
-			newLog = newLog + logObj.Time.String() + ", "
-		}
-
-		logObj.Log = newLog + logObj.Log
-		fmt.Println(logObj.Log)
+		fmt.Fprint(out, log.Log)
 	}
 }
 
 // printShipmentLogs
 // prints the logs separatly for each shipment
-func printSeparateLogs(shipment HelmitResponse, ids []string) {
+func printSeparateLogs(out io.Writer, shipment HelmitResponse, filter logFilter) {
 	for _, provider := range shipment.Replicas {
 		for _, container := range provider.Containers {
 
-			if len(ids) > 0 && !stringInSlice(container.ID, ids) && !stringInSlice(container.ID[0:7], ids) {
+			if !filter.container(provider.Host, container) {
 				continue
 			}
 
-			fmt.Printf("--- Name: %s\n", container.Name)
-			fmt.Printf("--- Id: %s\n", container.ID)
-			fmt.Printf("--- Image %s\n", container.Image)
-			for _, log := range container.Logs {
-				line := strings.Fields(log)
+			fmt.Fprintf(out, "--- Name: %s\n", container.Name)
+			fmt.Fprintf(out, "--- Id: %s\n", container.ID)
+			fmt.Fprintf(out, "--- Image %s\n", container.Image)
 
-				if len(line) > 2 && logTime == false {
-					line = append(line[:0], line[1:]...)
+			for _, log := range filter.logs(container) {
+				if logTime == true {
+					fmt.Fprintln(out, log.Time.Format(time.RFC3339), formatLogMessage(log.Log))
+				} else {
+					fmt.Fprintln(out, formatLogMessage(log.Log))
 				}
-
-				fmt.Println(strings.Join(line, " "))
-			}
-
-			if tail == true {
-				logsObj := logsObject{}
-				logsObj.Logstream = container.Logstream
-				logsObj.Name = container.Name
-				logsObj.ID = container.ID
-				go followStream(logsObj)
 			}
 		}
 	}
-
-	if tail == true {
-		var input string
-		fmt.Scanln(&input)
-	}
 }
@@ -0,0 +1,267 @@
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/turnerlabs/harbor-compose/harbor/client"
+)
+
+var logsExportFile string
+
+// logsExportCmd represents the logs export command
+var logsExportCmd = &cobra.Command{
+	Use:   "export [container ...]",
+	Short: "Download a bundle of container logs",
+	Long: `Download a bundle of container logs (e.g., to attach to an incident ticket)
+
+Writes a tar.gz file that contains a log file for each container in each replica, along with a manifest.json for each shipment environment.  The manifest includes the shipment environment (with hidden environment variable values masked), its status, its events and the time that it was captured.
+
+The logs command's filters (e.g., --since, --until and --grep) can be used to limit the size of the bundle.`,
+	Example: `harbor-compose logs export
+harbor-compose logs export --since 2h --bundle incident-1234.tar.gz
+harbor-compose logs export -s my-app -e prod --since 2017-05-01T12:00:00Z --until 2017-05-01T13:00:00Z web`,
+	Run:    exportLogs,
+	PreRun: preRunHook,
+}
+
+func init() {
+	logsExportCmd.PersistentFlags().StringVarP(&logsExportFile, "bundle", "", "", "the file to write (defaults to logs-<time>.tar.gz)")
+	logsCmd.AddCommand(logsExportCmd)
+}
+
+//logsManifest describes a shipment environment in a log bundle
+type logsManifest struct {
+	Shipment            string               `json:"shipment"`
+	Environment         string               `json:"environment"`
+	Barge               string               `json:"barge"`
+	CapturedAt          time.Time            `json:"capturedAt"`
+	Since               *time.Time           `json:"since,omitempty"`
+	Until               *time.Time           `json:"until,omitempty"`
+	ShipmentEnvironment *ShipmentEnvironment `json:"shipmentEnvironment"`
+	Status              *ShipmentStatus      `json:"status,omitempty"`
+	Events              []ShipmentEvent      `json:"events"`
+	Files               []string             `json:"files"`
+
+	//helmit errors (a partial bundle is more useful than none)
+	Errors []string `json:"errors,omitempty"`
+}
+
+func exportLogs(cmd *cobra.Command, args []string) {
+	filter, err := newLogFilter(logsSince, logsUntil, logsGrep, logsExclude, logsLevel, logsLines, args, logsHosts)
+	check(err)
+
+	//make sure user is authenticated
+	username, token, err := Login()
+	check(err)
+
+	//determine which shipment/environments user wants logs for
+	inputShipmentEnvironments, _ := getShipmentEnvironmentsFromInput(logsShipment, logsEnvironment)
+
+	now := time.Now().UTC()
+	file := logsExportFile
+	if file == "" {
+		file = fmt.Sprintf("logs-%v.tar.gz", now.Format("20060102T150405Z"))
+	}
+
+	check(writeLogsExport(file, tupleShipmentTasks(inputShipmentEnvironments), username, token, filter, now))
+	fmt.Printf("wrote %v\n", file)
+}
+
+//writes a bundle for shipment environments to a file
+//the bundle is written to a temporary file that's only renamed to file when every shipment environment succeeds
+func writeLogsExport(file string, tasks []shipmentTask, username string, token string, filter logFilter, now time.Time) (err error) {
+	f, err := ioutil.TempFile(filepath.Dir(file), filepath.Base(file)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		f.Close()
+		if err != nil {
+			os.Remove(f.Name())
+		}
+	}()
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+
+	for _, task := range tasks {
+		shipmentEnvironment, err := harborClient(username, token).Shipit.GetShipmentEnvironment(context.Background(), task.Shipment, task.Env)
+		if err == client.ErrNotFound {
+			return errors.New(messageShipmentEnvironmentNotFound)
+		}
+		if err != nil {
+			return err
+		}
+
+		manifest, err := writeLogsBundle(tw, task.Shipment, task.Env, shipmentEnvironment, filter, now)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("exported %v log files for %v %v\n", len(manifest.Files), task.Shipment, task.Env)
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+
+	//temp files are only readable by their owner
+	if err := f.Chmod(0644); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), file)
+}
+
+//writes a shipment environment's log files and manifest to a bundle
+func writeLogsBundle(tw *tar.Writer, shipment string, env string, shipmentEnvironment *ShipmentEnvironment, filter logFilter, now time.Time) (*logsManifest, error) {
+	ctx := context.Background()
+	helmit := harborClient("", "").Helmit
+	barge := ec2Provider(shipmentEnvironment.Providers).Barge
+	dir := shipment + "-" + env
+
+	manifest := &logsManifest{
+		Shipment:            shipment,
+		Environment:         env,
+		Barge:               barge,
+		CapturedAt:          now,
+		ShipmentEnvironment: maskShipmentEnvironment(*shipmentEnvironment),
+		Events:              []ShipmentEvent{},
+		Files:               []string{},
+		Errors:              []string{},
+	}
+	if !filter.Since.IsZero() {
+		manifest.Since = &filter.Since
+	}
+	if !filter.Until.IsZero() {
+		manifest.Until = &filter.Until
+	}
+
+	//one file for each container in each replica
+	logs, err := helmit.GetLogs(ctx, barge, shipment, env)
+	if err != nil {
+		manifest.Errors = append(manifest.Errors, fmt.Sprintf("logs: %v", err))
+	} else {
+		for _, replica := range logs.Replicas {
+			host := replica.Host
+			if host == "" {
+				host = "unknown-host"
+			}
+			for _, container := range replica.Containers {
+				if !filter.container(replica.Host, container) {
+					continue
+				}
+				var contents bytes.Buffer
+				for _, log := range filter.logs(container) {
+					fmt.Fprintf(&contents, "%v %v\n", log.Time.Format(time.RFC3339Nano), log.Log)
+				}
+				name := path.Join(dir, host, fmt.Sprintf("%v-%v.log", container.Name, shortID(container.ID)))
+				if err := writeBundleFile(tw, name, contents.Bytes(), now); err != nil {
+					return nil, err
+				}
+				manifest.Files = append(manifest.Files, name)
+			}
+		}
+	}
+
+	if status, err := helmit.GetShipmentStatus(ctx, barge, shipment, env); err != nil {
+		manifest.Errors = append(manifest.Errors, fmt.Sprintf("status: %v", err))
+	} else {
+		manifest.Status = status
+	}
+
+	//only the events in the time window
+	if events, err := helmit.GetShipmentEvents(ctx, barge, shipment, env); err != nil {
+		manifest.Errors = append(manifest.Errors, fmt.Sprintf("events: %v", err))
+	} else {
+		for _, event := range events.Events {
+			if !filter.Since.IsZero() && event.LastTimestamp.Before(filter.Since) {
+				continue
+			}
+			if !filter.Until.IsZero() && !event.FirstTimestamp.Before(filter.Until) {
+				continue
+			}
+			manifest.Events = append(manifest.Events, event)
+		}
+	}
+
+	b, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeBundleFile(tw, path.Join(dir, "manifest.json"), b, now); err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+//writes a file to a tar archive
+func writeBundleFile(tw *tar.Writer, name string, contents []byte, modified time.Time) error {
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(contents)),
+		ModTime: modified,
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := tw.Write(contents)
+	return err
+}
+
+//returns a copy of a shipment environment with hidden env var values and tokens masked
+func maskShipmentEnvironment(shipmentEnvironment ShipmentEnvironment) *ShipmentEnvironment {
+	mask := func(envvars []EnvVarPayload) []EnvVarPayload {
+		if envvars == nil {
+			return nil
+		}
+		result := []EnvVarPayload{}
+		for _, envvar := range envvars {
+			if envvar.Type == "hidden" {
+				envvar.Value = planHiddenValue
+			}
+			result = append(result, envvar)
+		}
+		return result
+	}
+
+	masked := shipmentEnvironment
+	masked.Token = ""
+	if masked.BuildToken != "" {
+		masked.BuildToken = planHiddenValue
+	}
+	masked.EnvVars = mask(shipmentEnvironment.EnvVars)
+	masked.ParentShipment.EnvVars = mask(shipmentEnvironment.ParentShipment.EnvVars)
+
+	masked.Containers = []ContainerPayload{}
+	for _, container := range shipmentEnvironment.Containers {
+		container.EnvVars = mask(container.EnvVars)
+		masked.Containers = append(masked.Containers, container)
+	}
+
+	masked.Providers = []ProviderPayload{}
+	for _, provider := range shipmentEnvironment.Providers {
+		provider.EnvVars = mask(provider.EnvVars)
+		masked.Providers = append(masked.Providers, provider)
+	}
+
+	return &masked
+}
@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/turnerlabs/harbor-compose/harbor/client"
+	"github.com/turnerlabs/harbor-compose/harbor/mockserver"
+)
+
+func TestWriteLogsBundle(t *testing.T) {
+	now := time.Date(2017, 5, 1, 13, 0, 0, 0, time.UTC)
+	shipmentEnvironment := client.ShipmentEnvironment{
+		Name:           "prod",
+		Token:          "secret-token",
+		BuildToken:     "build-token",
+		ParentShipment: client.ParentShipment{Name: "my-app", EnvVars: []client.EnvVarPayload{envVar("CUSTOMER", "turner")}},
+		EnvVars:        []client.EnvVarPayload{envVarHidden("API_KEY", "shh"), envVar("LOG_LEVEL", "info")},
+		Containers: []client.ContainerPayload{{
+			Name:    "web",
+			Image:   "registry/web:1.0",
+			EnvVars: []client.EnvVarPayload{envVarHidden("DB_PASSWORD", "shh")},
+		}},
+		Providers: []client.ProviderPayload{{Name: providerEc2, Replicas: 2, Barge: "corp-sandbox"}},
+	}
+
+	server := httptest.NewServer(mockserver.New(&mockserver.Seed{
+		Shipments: []client.ShipmentEnvironment{shipmentEnvironment},
+		Logs: map[string]client.HelmitResponse{
+			"my-app/prod": {
+				Replicas: []client.HelmitReplica{
+					{
+						Host: "ip-10-0-0-1",
+						Containers: []client.HelmitContainer{{
+							Name: "web",
+							ID:   "9e70dc6abc",
+							Logs: []string{
+								"2017-05-01T11:00:00Z too early",
+								"2017-05-01T12:10:00Z GET /api 500 error",
+							},
+						}},
+					},
+					{
+						Host: "ip-10-0-0-2",
+						Containers: []client.HelmitContainer{{
+							Name: "web",
+							ID:   "14ffbf5abc",
+							Logs: []string{"2017-05-01T12:20:00Z GET /health 200"},
+						}},
+					},
+				},
+			},
+		},
+		Events: map[string][]client.ShipmentEvent{
+			"my-app/prod": {
+				{Type: "Normal", Reason: "Pulled", Message: "pulled image", FirstTimestamp: now.Add(-2 * time.Hour), LastTimestamp: now.Add(-2 * time.Hour)},
+				{Type: "Warning", Reason: "BackOff", Message: "back-off restarting", FirstTimestamp: now.Add(-3 * time.Hour), LastTimestamp: now.Add(-30 * time.Minute)},
+			},
+		},
+	}))
+	defer server.Close()
+
+	defer useMockHarbor(server)()
+
+	filter, err := newLogFilter("2017-05-01T12:00:00Z", "", "", "", "", -1, nil, nil)
+	assert.Nil(t, err)
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	manifest, err := writeLogsBundle(tw, "my-app", "prod", &shipmentEnvironment, filter, now)
+	assert.Nil(t, err)
+	assert.Nil(t, tw.Close())
+	assert.Equal(t, []string{"my-app-prod/ip-10-0-0-1/web-9e70dc6.log", "my-app-prod/ip-10-0-0-2/web-14ffbf5.log"}, manifest.Files)
+
+	//read the bundle back
+	files := map[string]string{}
+	tr := tar.NewReader(&buf)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.Nil(t, err)
+		b, err := ioutil.ReadAll(tr)
+		assert.Nil(t, err)
+		files[header.Name] = string(b)
+	}
+	assert.Equal(t, 3, len(files))
+
+	//logs are limited to the time window
+	assert.Equal(t, "2017-05-01T12:10:00Z GET /api 500 error\n", files["my-app-prod/ip-10-0-0-1/web-9e70dc6.log"])
+	assert.Equal(t, "2017-05-01T12:20:00Z GET /health 200\n", files["my-app-prod/ip-10-0-0-2/web-14ffbf5.log"])
+
+	var result logsManifest
+	assert.Nil(t, json.Unmarshal([]byte(files["my-app-prod/manifest.json"]), &result))
+	assert.Equal(t, "my-app", result.Shipment)
+	assert.Equal(t, "prod", result.Environment)
+	assert.Equal(t, "corp-sandbox", result.Barge)
+	assert.Equal(t, now, result.CapturedAt)
+	assert.Equal(t, filter.Since, *result.Since)
+	assert.Nil(t, result.Until)
+	assert.NotNil(t, result.Status)
+	assert.Equal(t, 0, len(result.Errors))
+
+	//so are events
+	assert.Equal(t, 1, len(result.Events))
+	assert.Equal(t, "BackOff", result.Events[0].Reason)
+
+	//secrets are masked
+	masked := result.ShipmentEnvironment
+	assert.Equal(t, "", masked.Token)
+	assert.Equal(t, planHiddenValue, masked.BuildToken)
+	assert.Equal(t, planHiddenValue, getEnvVar("API_KEY", masked.EnvVars).Value)
+	assert.Equal(t, "info", getEnvVar("LOG_LEVEL", masked.EnvVars).Value)
+	assert.Equal(t, planHiddenValue, getEnvVar("DB_PASSWORD", masked.Containers[0].EnvVars).Value)
+	assert.Equal(t, "turner", getEnvVar("CUSTOMER", masked.ParentShipment.EnvVars).Value)
+
+	//without changing the original
+	assert.Equal(t, "shh", getEnvVar("API_KEY", shipmentEnvironment.EnvVars).Value)
+	assert.Equal(t, "secret-token", shipmentEnvironment.Token)
+}
+
+func TestWriteLogsExport(t *testing.T) {
+	server := httptest.NewServer(mockserver.New(&mockserver.Seed{
+		Shipments: []client.ShipmentEnvironment{{
+			Name:           "prod",
+			ParentShipment: client.ParentShipment{Name: "my-app"},
+			Providers:      []client.ProviderPayload{{Name: providerEc2, Replicas: 1, Barge: "corp-sandbox"}},
+		}},
+	}))
+	defer server.Close()
+	defer useMockHarbor(server)()
+
+	dir, err := ioutil.TempDir("", "logs-export")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "bundle.tar.gz")
+
+	//nothing is left behind when a shipment environment fails
+	tasks := []shipmentTask{{Shipment: "my-app", Env: "prod"}, {Shipment: "my-app", Env: "qa"}}
+	err = writeLogsExport(file, tasks, "user", "token", logFilter{}, time.Now())
+	assert.EqualError(t, err, messageShipmentEnvironmentNotFound)
+	files, err := ioutil.ReadDir(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(files))
+
+	err = writeLogsExport(file, tasks[:1], "user", "token", logFilter{}, time.Now())
+	assert.Nil(t, err)
+	files, err = ioutil.ReadDir(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(files))
+	assert.Equal(t, "bundle.tar.gz", files[0].Name())
+}
@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//logFilter selects which containers and log lines are shown by the logs command
+type logFilter struct {
+	//only lines logged at or after this time (ignored when zero)
+	Since time.Time
+
+	//only lines logged before this time (ignored when zero)
+	Until time.Time
+
+	//only lines that match this expression
+	Include *regexp.Regexp
+
+	//hides lines that match this expression
+	Exclude *regexp.Regexp
+
+	//only json lines logged at or above this level (e.g., warn)
+	Level string
+
+	//the number of lines to show for each container (all when negative)
+	Lines int
+
+	//container ids, id prefixes or names
+	Containers []string
+
+	//replica hosts
+	Hosts []string
+}
+
+//builds a log filter from the logs command's flags
+func newLogFilter(since string, until string, grep string, exclude string, level string, lines int, containers []string, hosts []string) (logFilter, error) {
+	filter := logFilter{
+		Level:      strings.ToLower(level),
+		Lines:      lines,
+		Containers: containers,
+		Hosts:      hosts,
+	}
+
+	now := time.Now()
+	var err error
+	if since != "" {
+		if filter.Since, err = parseLogTime(since, now); err != nil {
+			return filter, err
+		}
+	}
+	if until != "" {
+		if filter.Until, err = parseLogTime(until, now); err != nil {
+			return filter, err
+		}
+	}
+	if !filter.Since.IsZero() && !filter.Until.IsZero() && !filter.Since.Before(filter.Until) {
+		return filter, fmt.Errorf(messageLogsTimeWindow, since, until)
+	}
+
+	if _, ok := logLevels[filter.Level]; filter.Level != "" && !ok {
+		return filter, fmt.Errorf(messageLogsLevel, level)
+	}
+
+	if grep != "" {
+		if filter.Include, err = regexp.Compile(grep); err != nil {
+			return filter, err
+		}
+	}
+	if exclude != "" {
+		if filter.Exclude, err = regexp.Compile(exclude); err != nil {
+			return filter, err
+		}
+	}
+
+	return filter, nil
+}
+
+//parses a relative duration (e.g., 10m or 2h30m) or a timestamp (RFC3339 or a date)
+func parseLogTime(value string, now time.Time) (time.Time, error) {
+	if duration, err := time.ParseDuration(value); err == nil {
+		return now.Add(-duration), nil
+	}
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf(messageLogsTimeInvalid, value)
+}
+
+//returns true when a container's logs should be shown
+func (f logFilter) container(host string, container HelmitContainer) bool {
+	if len(f.Hosts) > 0 && !containsString(f.Hosts, host) {
+		return false
+	}
+	if len(f.Containers) == 0 {
+		return true
+	}
+	for _, selector := range f.Containers {
+		if selector == container.Name || (len(selector) > 0 && strings.HasPrefix(container.ID, selector)) {
+			return true
+		}
+	}
+	return false
+}
+
+//returns true when a log line should be shown
+func (f logFilter) line(log logObject) bool {
+	if !f.Since.IsZero() && log.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !log.Time.Before(f.Until) {
+		return false
+	}
+	if f.Include != nil && !f.Include.MatchString(log.Log) {
+		return false
+	}
+	if f.Exclude != nil && f.Exclude.MatchString(log.Log) {
+		return false
+	}
+	if f.Level != "" {
+		_, level := logField(parseLogFields(log.Log), logLevelKeys)
+		if logSeverity(level) < logLevels[f.Level] {
+			return false
+		}
+	}
+	return true
+}
+
+//parses and filters a container's logs, keeping the last lines
+func (f logFilter) logs(container HelmitContainer) Logs {
+	logs := Logs{}
+	for _, logstring := range container.Logs {
+		parsedLog, err := parseContainerLog(logstring)
+		if err != "" {
+			continue
+		}
+		if f.line(parsedLog) {
+			logs = append(logs, parsedLog)
+		}
+	}
+	if f.Lines >= 0 && len(logs) > f.Lines {
+		logs = logs[len(logs)-f.Lines:]
+	}
+	return logs
+}
+
+//returns a container's log stream uri with the number of lines that helmit should send before following
+//(helmit's default is used when lines is negative)
+func logStreamURL(logstream string, lines int) string {
+	if lines < 0 {
+		return logstream
+	}
+	u, err := url.Parse(logstream)
+	if err != nil {
+		return logstream
+	}
+	query := u.Query()
+	query.Set("tail", strconv.Itoa(lines))
+	u.RawQuery = query.Encode()
+	return u.String()
+}
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func getFilterLogs() HelmitResponse {
+	return HelmitResponse{
+		Replicas: []HelmitReplica{
+			{
+				Host: "ip-10-0-0-1",
+				Containers: []HelmitContainer{
+					{
+						Name:  "web",
+						ID:    "9e70dc6abc",
+						Image: "registry/web:1.0",
+						Logs: []string{
+							"2017-05-01T12:00:00Z server started",
+							"2017-05-01T12:05:00Z GET /health 200",
+							"2017-05-01T12:10:00Z GET /api 500 error",
+							"2017-05-01T12:15:00Z GET /health 200",
+						},
+					},
+				},
+			},
+			{
+				Host: "ip-10-0-0-2",
+				Containers: []HelmitContainer{
+					{
+						Name:  "worker",
+						ID:    "14ffbf5abc",
+						Image: "registry/worker:1.0",
+						Logs: []string{
+							"2017-05-01T12:01:00Z processing job",
+							"2017-05-01T12:11:00Z job error",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestParseLogTime(t *testing.T) {
+	now := time.Date(2017, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	since, err := parseLogTime("10m", now)
+	assert.Nil(t, err)
+	assert.Equal(t, now.Add(-10*time.Minute), since)
+
+	since, err = parseLogTime("2017-05-01T11:00:00Z", now)
+	assert.Nil(t, err)
+	assert.Equal(t, now.Add(-time.Hour), since)
+
+	since, err = parseLogTime("2017-05-01", now)
+	assert.Nil(t, err)
+	assert.Equal(t, now.Add(-12*time.Hour), since)
+
+	_, err = parseLogTime("yesterday", now)
+	assert.EqualError(t, err, "invalid time: yesterday (use a timestamp like 2017-05-01T12:00:00Z or a duration like 10m)")
+}
+
+func TestNewLogFilter(t *testing.T) {
+	_, err := newLogFilter("", "", "(", "", "", -1, nil, nil)
+	assert.NotNil(t, err)
+
+	_, err = newLogFilter("2017-05-01T12:00:00Z", "2017-05-01T11:00:00Z", "", "", "", -1, nil, nil)
+	assert.EqualError(t, err, "--since (2017-05-01T12:00:00Z) must be before --until (2017-05-01T11:00:00Z)")
+
+	filter, err := newLogFilter("", "", "error", "health", "", 10, []string{"web"}, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 10, filter.Lines)
+	assert.True(t, filter.Include.MatchString("an error"))
+	assert.True(t, filter.Exclude.MatchString("/health"))
+}
+
+func TestLogFilterContainers(t *testing.T) {
+	helmitObject := getFilterLogs()
+	web := helmitObject.Replicas[0].Containers[0]
+
+	assert.True(t, logFilter{}.container("ip-10-0-0-1", web))
+
+	//by name, id or id prefix
+	assert.True(t, logFilter{Containers: []string{"web"}}.container("ip-10-0-0-1", web))
+	assert.True(t, logFilter{Containers: []string{"9e70dc6"}}.container("ip-10-0-0-1", web))
+	assert.True(t, logFilter{Containers: []string{"worker", "9e70dc6abc"}}.container("ip-10-0-0-1", web))
+	assert.False(t, logFilter{Containers: []string{"worker"}}.container("ip-10-0-0-1", web))
+	assert.False(t, logFilter{Containers: []string{""}}.container("ip-10-0-0-1", web))
+
+	//by replica host
+	assert.True(t, logFilter{Hosts: []string{"ip-10-0-0-1"}}.container("ip-10-0-0-1", web))
+	assert.False(t, logFilter{Hosts: []string{"ip-10-0-0-2"}}.container("ip-10-0-0-1", web))
+}
+
+func TestLogFilterLogs(t *testing.T) {
+	web := getFilterLogs().Replicas[0].Containers[0]
+
+	logs := logFilter{Lines: -1}.logs(web)
+	assert.Equal(t, 4, len(logs))
+
+	//the last lines
+	logs = logFilter{Lines: 1}.logs(web)
+	assert.Equal(t, 1, len(logs))
+	assert.Equal(t, "GET /health 200", logs[0].Log)
+	assert.Equal(t, 0, len(logFilter{Lines: 0}.logs(web)))
+
+	//a time window (since is inclusive and until is exclusive)
+	filter, err := newLogFilter("2017-05-01T12:05:00Z", "2017-05-01T12:15:00Z", "", "", "", -1, nil, nil)
+	assert.Nil(t, err)
+	logs = filter.logs(web)
+	assert.Equal(t, 2, len(logs))
+	assert.Equal(t, "GET /health 200", logs[0].Log)
+	assert.Equal(t, "GET /api 500 error", logs[1].Log)
+
+	//include and exclude
+	filter, err = newLogFilter("", "", "GET", "health", "", -1, nil, nil)
+	assert.Nil(t, err)
+	logs = filter.logs(web)
+	assert.Equal(t, 1, len(logs))
+	assert.Equal(t, "GET /api 500 error", logs[0].Log)
+
+	//lines are counted after filtering
+	filter, err = newLogFilter("", "", "health", "", "", 1, nil, nil)
+	assert.Nil(t, err)
+	logs = filter.logs(web)
+	assert.Equal(t, 1, len(logs))
+	assert.Equal(t, time.Date(2017, 5, 1, 12, 15, 0, 0, time.UTC), logs[0].Time)
+}
+
+func TestPrintLogsFilter(t *testing.T) {
+	filter, err := newLogFilter("", "", "error", "", "", -1, nil, []string{"ip-10-0-0-2"})
+	assert.Nil(t, err)
+
+	var out bytes.Buffer
+	printMergedLogs(&out, getFilterLogs(), filter)
+	assert.Equal(t, "worker:14ffbf5  | job error\n", out.String())
+
+	out.Reset()
+	filter, err = newLogFilter("", "", "error", "", "", -1, []string{"web"}, nil)
+	assert.Nil(t, err)
+	printSeparateLogs(&out, getFilterLogs(), filter)
+	assert.Equal(t, "--- Name: web\n--- Id: 9e70dc6abc\n--- Image registry/web:1.0\nGET /api 500 error\n", out.String())
+}
+
+func TestLogStreamURL(t *testing.T) {
+	stream := "http://helmit/logstream/9e70dc6abc?tail=500&follow=true"
+	assert.Equal(t, stream, logStreamURL(stream, -1))
+	assert.Equal(t, "http://helmit/logstream/9e70dc6abc?follow=true&tail=0", logStreamURL(stream, 0))
+	assert.Equal(t, "http://helmit/logstream/9e70dc6abc?tail=25", logStreamURL("http://helmit/logstream/9e70dc6abc", 25))
+}
@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+//how long to wait before reconnecting to a log stream that ended (doubles each time, up to logsReconnectMaxDelay)
+var logsReconnectDelay = time.Second
+
+//the longest time to wait before reconnecting to a log stream
+var logsReconnectMaxDelay = 30 * time.Second
+
+//how often to look for replicas that started after the logs command
+var logsRefreshInterval = 15 * time.Second
+
+//helmitTarget is a shipment environment and the barge that it runs on (which helmit needs)
+type helmitTarget struct {
+	Shipment string
+	Env      string
+	Barge    string
+}
+
+//logLine is a line from a container's log stream
+type logLine struct {
+	Container logsObject
+	Log       logObject
+}
+
+//returns a context that's canceled on ctrl-c (or SIGTERM)
+func interruptContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-interrupt:
+			cancel()
+		case <-ctx.Done():
+		}
+		signal.Stop(interrupt)
+	}()
+	return ctx, cancel
+}
+
+//parses a line from a log stream (which starts with docker's 8 byte multiplexing header)
+func parseStreamLine(line []byte) (logObject, bool) {
+	if len(line) <= 8 {
+		return logObject{}, false
+	}
+	logObj, err := parseContainerLog(string(line[8:]))
+	return logObj, err == ""
+}
+
+//follows the logs of shipment environments until the context is done
+//lines from every container are written as they arrive, and containers that start later are picked up
+func followLogs(ctx context.Context, out io.Writer, targets []helmitTarget, filter logFilter) error {
+	lines := make(chan logLine)
+	streams := map[string]context.CancelFunc{}
+	var wg sync.WaitGroup
+
+	//starts streaming containers that aren't already streaming and stops the ones that went away
+	attach := func(tail int) error {
+		current := map[string]bool{}
+		for _, target := range targets {
+			result, err := harborClient("", "").Helmit.GetLogs(ctx, target.Barge, target.Shipment, target.Env)
+			if err != nil {
+				return err
+			}
+			for _, replica := range result.Replicas {
+				for _, container := range replica.Containers {
+					if !filter.container(replica.Host, container) {
+						continue
+					}
+					current[container.ID] = true
+					if _, ok := streams[container.ID]; ok {
+						continue
+					}
+
+					streamCtx, cancel := context.WithCancel(ctx)
+					streams[container.ID] = cancel
+					wg.Add(1)
+					go func(container logsObject) {
+						defer wg.Done()
+						streamContainerLogs(streamCtx, container, tail, filter, lines)
+					}(logsObject{
+						Shipment:    target.Shipment,
+						Environment: target.Env,
+						Host:        replica.Host,
+						Name:        container.Name,
+						ID:          container.ID,
+						Image:       container.Image,
+						Logstream:   container.Logstream,
+					})
+				}
+			}
+		}
+
+		for id, cancel := range streams {
+			if !current[id] {
+				cancel()
+				delete(streams, id)
+			}
+		}
+		return nil
+	}
+
+	if err := attach(filter.Lines); err != nil {
+		return err
+	}
+
+	refresh := time.NewTicker(logsRefreshInterval)
+	defer refresh.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return nil
+
+		case line := <-lines:
+			if err := writeLogLine(out, line); err != nil {
+				return err
+			}
+
+		case <-refresh.C:
+			//new replicas send everything they've logged so far
+			if err := attach(-1); err != nil && ctx.Err() == nil && Verbose {
+				log.Printf("unable to look for new containers: %v", err)
+			}
+		}
+	}
+}
+
+//sends a container's log lines to a channel until the context is done
+//the stream is reopened (with backoff) when it ends, skipping lines that were already sent
+func streamContainerLogs(ctx context.Context, container logsObject, tail int, filter logFilter, lines chan<- logLine) {
+	delay := logsReconnectDelay
+	var last time.Time
+
+	for {
+		stream, err := harborClient("", "").Helmit.GetLogStream(ctx, logStreamURL(container.Logstream, tail))
+		if err == nil {
+			resume := last
+			reader := bufio.NewReader(stream)
+			for {
+				line, err := reader.ReadBytes('\n')
+				if err != nil {
+					break
+				}
+				logObj, ok := parseStreamLine(line)
+				if !ok || (!resume.IsZero() && !logObj.Time.After(resume)) {
+					continue
+				}
+				last = logObj.Time
+				delay = logsReconnectDelay
+
+				if !filter.line(logObj) {
+					continue
+				}
+				select {
+				case lines <- logLine{Container: container, Log: logObj}:
+				case <-ctx.Done():
+				}
+			}
+			stream.Close()
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+		if Verbose {
+			log.Printf("log stream for %v ended, reconnecting in %v", shortID(container.ID), delay)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay *= 2
+		if delay > logsReconnectMaxDelay {
+			delay = logsReconnectMaxDelay
+		}
+
+		//helmit's default tail covers the gap since the stream ended
+		tail = -1
+	}
+}
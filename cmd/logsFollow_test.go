@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//a helmit server whose log streams end (once) and whose replicas change
+type followServer struct {
+	mu          sync.Mutex
+	containers  []HelmitContainer
+	connections map[string]int
+	tails       map[string][]string
+}
+
+func (s *followServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if strings.HasPrefix(r.URL.Path, "/harbor/") {
+		containers := []HelmitContainer{}
+		for _, container := range s.containers {
+			container.Logstream = "http://" + r.Host + "/logstream/" + container.ID + "?tail=500"
+			containers = append(containers, container)
+		}
+		json.NewEncoder(w).Encode(HelmitResponse{Replicas: []HelmitReplica{{Host: "ip-10-0-0-1", Containers: containers}}})
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/logstream/")
+	s.connections[id]++
+	s.tails[id] = append(s.tails[id], r.URL.Query().Get("tail"))
+	connection := s.connections[id]
+	s.mu.Unlock()
+	defer s.mu.Lock()
+
+	write := func(line string) {
+		w.Write(append([]byte{1, 0, 0, 0, 0, 0, 0, 0}, []byte(line+"\n")...))
+		w.(http.Flusher).Flush()
+	}
+	write("2017-05-01T12:00:00Z " + id + " line one")
+	write("2017-05-01T12:00:01Z " + id + " line two")
+
+	//the first stream ends (the second one resends the lines and then stays open)
+	if connection == 1 {
+		return
+	}
+	write("2017-05-01T12:00:02Z " + id + " line three")
+	<-r.Context().Done()
+}
+
+func TestFollowLogs(t *testing.T) {
+	server := &followServer{
+		containers:  []HelmitContainer{{Name: "web", ID: "aaaaaaa111"}},
+		connections: map[string]int{},
+		tails:       map[string][]string{},
+	}
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	defer useMockHarbor(httpServer)()
+
+	defer func(delay time.Duration, refresh time.Duration) {
+		logsReconnectDelay, logsRefreshInterval = delay, refresh
+	}(logsReconnectDelay, logsRefreshInterval)
+	logsReconnectDelay, logsRefreshInterval = 10*time.Millisecond, 50*time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var out bytes.Buffer
+	done := make(chan error)
+	go func() {
+		done <- followLogs(ctx, &out, []helmitTarget{{Shipment: "my-app", Env: "dev", Barge: "corp-sandbox"}}, logFilter{Lines: 10, Exclude: regexp.MustCompile("two")})
+	}()
+
+	//a replica starts after the command
+	time.Sleep(100 * time.Millisecond)
+	server.mu.Lock()
+	server.containers = append(server.containers, HelmitContainer{Name: "web", ID: "bbbbbbb222"})
+	server.mu.Unlock()
+	time.Sleep(200 * time.Millisecond)
+
+	//exits cleanly
+	cancel()
+	select {
+	case err := <-done:
+		assert.Nil(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("followLogs didn't return")
+	}
+
+	//lines that were already sent aren't repeated after reconnecting
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	count := func(line string) int {
+		n := 0
+		for _, l := range lines {
+			if l == line {
+				n++
+			}
+		}
+		return n
+	}
+	for _, id := range []string{"aaaaaaa111", "bbbbbbb222"} {
+		assert.Equal(t, 1, count(fmt.Sprintf("web:%v  | %v line one", shortID(id), id)))
+		assert.Equal(t, 0, count(fmt.Sprintf("web:%v  | %v line two", shortID(id), id)))
+		assert.Equal(t, 1, count(fmt.Sprintf("web:%v  | %v line three", shortID(id), id)))
+	}
+	assert.Equal(t, 4, len(lines))
+
+	//the first stream uses --lines, new replicas and reconnects use helmit's default
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	assert.Equal(t, []string{"10", "500"}, server.tails["aaaaaaa111"])
+	assert.Equal(t, []string{"500", "500"}, server.tails["bbbbbbb222"])
+}
+
+func TestParseStreamLine(t *testing.T) {
+	logObj, ok := parseStreamLine(append([]byte{1, 0, 0, 0, 0, 0, 0, 0}, []byte("2017-05-01T12:00:00Z server started\n")...))
+	assert.True(t, ok)
+	assert.Equal(t, "server started", logObj.Log)
+	assert.Equal(t, time.Date(2017, 5, 1, 12, 0, 0, 0, time.UTC), logObj.Time)
+
+	_, ok = parseStreamLine([]byte("short"))
+	assert.False(t, ok)
+}
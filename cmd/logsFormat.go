@@ -0,0 +1,226 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"sort"
+	"strings"
+)
+
+//log level severities (higher is more severe)
+var logLevels = map[string]int{
+	"trace":    0,
+	"debug":    1,
+	"info":     2,
+	"warn":     3,
+	"warning":  3,
+	"error":    4,
+	"err":      4,
+	"fatal":    5,
+	"critical": 5,
+	"panic":    5,
+}
+
+//the names shown by --pretty for each severity
+var logLevelNames = []string{"TRACE", "DEBUG", "INFO", "WARN", "ERROR", "FATAL"}
+
+//the keys that json logs commonly use for their level, message and time
+var logLevelKeys = []string{"level", "lvl", "severity"}
+var logMessageKeys = []string{"msg", "message"}
+var logTimeKeys = []string{"time", "ts", "timestamp", "@timestamp"}
+
+//the colors of container prefixes (like docker-compose logs)
+var logColors = []string{"\033[36m", "\033[33m", "\033[32m", "\033[35m", "\033[34m", "\033[1;36m", "\033[1;33m", "\033[1;32m", "\033[1;35m", "\033[1;34m"}
+
+//returns the fields of a log line that's a json object (nil otherwise)
+func parseLogFields(log string) map[string]interface{} {
+	if !strings.HasPrefix(log, "{") {
+		return nil
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(log), &fields); err != nil {
+		return nil
+	}
+	return fields
+}
+
+//returns the first field found with one of the keys
+func logField(fields map[string]interface{}, keys []string) (string, interface{}) {
+	for _, key := range keys {
+		if value, ok := fields[key]; ok {
+			return key, value
+		}
+	}
+	return "", nil
+}
+
+//returns the severity of a log level (-1 when it's unknown)
+//numeric levels are supported for bunyan and pino (10 = trace, 20 = debug, 30 = info, ...)
+func logSeverity(level interface{}) int {
+	switch value := level.(type) {
+	case string:
+		if severity, ok := logLevels[strings.ToLower(value)]; ok {
+			return severity
+		}
+	case float64:
+		if value >= 10 {
+			severity := int(value)/10 - 1
+			if severity >= len(logLevelNames) {
+				severity = len(logLevelNames) - 1
+			}
+			return severity
+		}
+	}
+	return -1
+}
+
+// MarshalJSON merges in the fields of logs that are json objects (without overwriting the standard fields)
+func (o LogOutput) MarshalJSON() ([]byte, error) {
+	type logOutput LogOutput
+	b, err := json.Marshal(logOutput(o))
+	if err != nil || len(o.Fields) == 0 {
+		return b, err
+	}
+
+	var standard map[string]json.RawMessage
+	if err := json.Unmarshal(b, &standard); err != nil {
+		return nil, err
+	}
+
+	keys := []string{}
+	for key := range o.Fields {
+		if _, ok := standard[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	buf.Write(b[:len(b)-1])
+	for _, key := range keys {
+		value, err := json.Marshal(o.Fields[key])
+		if err != nil {
+			return nil, err
+		}
+		name, _ := json.Marshal(key)
+		buf.WriteString(",")
+		buf.Write(name)
+		buf.WriteString(":")
+		buf.Write(value)
+	}
+	buf.WriteString("}")
+	return buf.Bytes(), nil
+}
+
+//returns the structured output of a container's log line
+func newLogOutput(container logsObject, logObj logObject) LogOutput {
+	return LogOutput{
+		Shipment:    container.Shipment,
+		Environment: container.Environment,
+		Container:   container.Name,
+		ID:          container.ID,
+		Image:       container.Image,
+		Host:        container.Host,
+		Time:        logObj.Time,
+		Log:         logObj.Log,
+		Fields:      parseLogFields(logObj.Log),
+	}
+}
+
+//returns a container's prefix color (which doesn't change between runs)
+func logColor(id string) string {
+	hash := fnv.New32a()
+	hash.Write([]byte(id))
+	return logColors[hash.Sum32()%uint32(len(logColors))]
+}
+
+//formats a log line with the container it came from
+func formatLogLine(container logsObject, logObj logObject) string {
+	newLog := container.Name + ":" + shortID(container.ID) + "  | "
+	if logsColor {
+		newLog = logColor(container.ID) + newLog + terminalReset
+	}
+	if logTime == true {
+		newLog = newLog + logObj.Time.String() + ", "
+	}
+	return newLog + formatLogMessage(logObj.Log)
+}
+
+//formats a log's message
+//with --pretty, json logs are shown as their level and message followed by the rest of their fields
+func formatLogMessage(log string) string {
+	if !logsPretty {
+		return log
+	}
+	fields := parseLogFields(log)
+	if fields == nil {
+		return log
+	}
+
+	parts := []string{}
+	levelKey, level := logField(fields, logLevelKeys)
+	if level != nil {
+		name := strings.ToUpper(fmt.Sprint(level))
+		severity := logSeverity(level)
+		if severity >= 0 {
+			name = logLevelNames[severity]
+		}
+		name = fmt.Sprintf("%-5v", name)
+		if logsColor && severity >= logLevels["error"] {
+			name = terminalWarning + name + terminalReset
+		} else if logsColor && severity == logLevels["warn"] {
+			name = terminalHighlight + name + terminalReset
+		}
+		parts = append(parts, name)
+	}
+
+	messageKey, message := logField(fields, logMessageKeys)
+	if message != nil {
+		parts = append(parts, fmt.Sprint(message))
+	}
+
+	keys := []string{}
+	for key := range fields {
+		if key != levelKey && key != messageKey && !containsString(logTimeKeys, key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		parts = append(parts, key+"="+formatLogValue(fields[key]))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+//formats a json log field's value for --pretty
+func formatLogValue(value interface{}) string {
+	if s, ok := value.(string); ok {
+		if s == "" || strings.ContainsAny(s, " =\"") {
+			return fmt.Sprintf("%q", s)
+		}
+		return s
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(b)
+}
+
+//writes a followed log line as text (or as json with --output jsonl)
+func writeLogLine(out io.Writer, line logLine) error {
+	if outputFormat == "jsonl" {
+		b, err := json.Marshal(newLogOutput(line.Container, line.Log))
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(out, string(b))
+		return err
+	}
+	_, err := fmt.Fprintln(out, formatLogLine(line.Container, line.Log))
+	return err
+}
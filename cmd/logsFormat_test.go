@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setLogsPretty(pretty bool, color bool) func() {
+	originalPretty, originalColor := logsPretty, logsColor
+	logsPretty, logsColor = pretty, color
+	return func() {
+		logsPretty, logsColor = originalPretty, originalColor
+	}
+}
+
+func TestLogSeverity(t *testing.T) {
+	assert.Equal(t, 2, logSeverity("info"))
+	assert.Equal(t, 3, logSeverity("WARNING"))
+	assert.Equal(t, 4, logSeverity("error"))
+	assert.Equal(t, -1, logSeverity("verbose"))
+	assert.Equal(t, -1, logSeverity(nil))
+
+	//bunyan and pino
+	assert.Equal(t, 0, logSeverity(float64(10)))
+	assert.Equal(t, 3, logSeverity(float64(40)))
+	assert.Equal(t, 5, logSeverity(float64(60)))
+	assert.Equal(t, 5, logSeverity(float64(70)))
+}
+
+func TestLogFilterLevel(t *testing.T) {
+	_, err := newLogFilter("", "", "", "", "loud", -1, nil, nil)
+	assert.EqualError(t, err, "unsupported log level: loud (use trace, debug, info, warn, error or fatal)")
+
+	filter, err := newLogFilter("", "", "", "", "WARN", -1, nil, nil)
+	assert.Nil(t, err)
+	assert.False(t, filter.line(logObject{Log: `{"level":"info","msg":"started"}`}))
+	assert.True(t, filter.line(logObject{Log: `{"level":"warn","msg":"slow"}`}))
+	assert.True(t, filter.line(logObject{Log: `{"severity":"ERROR","message":"failed"}`}))
+	assert.True(t, filter.line(logObject{Log: `{"level":50,"msg":"failed"}`}))
+
+	//logs without a level are hidden
+	assert.False(t, filter.line(logObject{Log: "plain text"}))
+}
+
+func TestLogOutputJSON(t *testing.T) {
+	container := logsObject{Shipment: "my-app", Environment: "dev", Host: "ip-10-0-0-1", Name: "web", ID: "9e70dc6abc", Image: "registry/web:1.0"}
+	timestamp := time.Date(2017, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	//json logs have their fields merged in (without replacing the standard fields)
+	output := newLogOutput(container, logObject{Time: timestamp, Log: `{"level":"info","msg":"started","host":"app","port":5000}`})
+	b, err := json.Marshal(output)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"shipment":"my-app","environment":"dev","container":"web","id":"9e70dc6abc","image":"registry/web:1.0","host":"ip-10-0-0-1","time":"2017-05-01T12:00:00Z","log":"{\"level\":\"info\",\"msg\":\"started\",\"host\":\"app\",\"port\":5000}","level":"info","msg":"started","port":5000}`, string(b))
+
+	//other logs aren't
+	output = newLogOutput(container, logObject{Time: timestamp, Log: "server started"})
+	assert.Nil(t, output.Fields)
+	b, err = json.Marshal(output)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"shipment":"my-app","environment":"dev","container":"web","id":"9e70dc6abc","image":"registry/web:1.0","host":"ip-10-0-0-1","time":"2017-05-01T12:00:00Z","log":"server started"}`, string(b))
+}
+
+func TestWriteOutputJSONL(t *testing.T) {
+	defer setOutput("jsonl", "")()
+	assert.Nil(t, validateOutput())
+
+	var out bytes.Buffer
+	assert.Nil(t, writeOutput(&out, getBuildTokenOutputs()))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Equal(t, 2, len(lines))
+	assert.Equal(t, `{"shipment":"my-app","environment":"dev","cicdEnvVar":"MY_APP_DEV_TOKEN","token":"abc"}`, lines[0])
+
+	//followed lines
+	out.Reset()
+	container := logsObject{Shipment: "my-app", Environment: "dev", Name: "web", ID: "9e70dc6abc"}
+	assert.Nil(t, writeLogLine(&out, logLine{Container: container, Log: logObject{Log: `{"msg":"hi"}`}}))
+	var result map[string]interface{}
+	assert.Nil(t, json.Unmarshal(out.Bytes(), &result))
+	assert.Equal(t, "hi", result["msg"])
+	assert.Equal(t, "my-app", result["shipment"])
+}
+
+func TestFormatLogMessage(t *testing.T) {
+	defer setLogsPretty(false, false)()
+
+	log := `{"time":"2017-05-01T12:00:00Z","level":"warn","msg":"slow request","path":"/api","ms":1500,"user":"jane doe"}`
+	assert.Equal(t, log, formatLogMessage(log))
+
+	logsPretty = true
+	assert.Equal(t, `WARN  slow request ms=1500 path=/api user="jane doe"`, formatLogMessage(log))
+	assert.Equal(t, `INFO  started`, formatLogMessage(`{"level":30,"message":"started"}`))
+	assert.Equal(t, `debug=true`, formatLogMessage(`{"debug":true}`))
+	assert.Equal(t, "plain text", formatLogMessage("plain text"))
+
+	//levels and container prefixes are colorized
+	logsColor = true
+	assert.Equal(t, terminalWarning+"ERROR"+terminalReset+" failed", formatLogMessage(`{"level":"error","msg":"failed"}`))
+	line := formatLogLine(logsObject{Name: "web", ID: "9e70dc6abc"}, logObject{Log: "plain text"})
+	assert.Equal(t, logColor("9e70dc6abc")+"web:9e70dc6  | "+terminalReset+"plain text", line)
+	assert.Equal(t, logColor("9e70dc6abc"), logColor("9e70dc6abc"))
+}
@@ -5,10 +5,34 @@ const (
 	messageEnvvarsCannotBeEmpty             = "environment variable names and value can not be empty"
 	messageIntervalGreaterThanTimeout       = "healthcheckIntervalSeconds must be > healthcheckTimeoutSeconds"
 	messagePortRequired                     = "at least one port is required"
+	messagePortNameUnique                   = "port names must be unique within a container"
+	messagePortProtocol                     = "port protocol must be http, https or tcp"
+	messagePrimaryPortRequired              = "exactly one port must be primary"
+	messageLoadBalancerType                 = "loadBalancer type must be alb or elb"
+	messageHTTPSCertificate                 = "loadBalancer https requires either a certificateArn or an iamCertificate"
+	messageCertificateArn                   = "loadBalancer https certificateArn must be an ACM certificate ARN (arn:aws:acm:...)"
 	messageContainerRequired                = "at least 1 container is required"
 	messageReplicaValidation                = "replicas must be between 1 and 1000"
 	messageBargeRequired                    = "barge is required for a shipment"
+	messageIamRoleArn                       = "iamRole must be an IAM role ARN (arn:aws:iam::<account>:role/<name>)"
 	messageEnvironmentUnderscores           = "environment can not contain underscores ('_')"
+	messageParallelPositive                 = "--parallel must be at least 1"
+	messageParallelInteractive              = "--parallel can't be used interactively (e.g., with --watch or with prompts that need --yes)"
+	messageDependencyNotFound               = "shipment %v depends on %v which is not a shipment in harbor-compose.yml"
+	messageOutputUnsupported                = "unsupported output format: %v (use text, json, jsonl or yaml)"
+	messageOutputFormatConflict             = "--format can't be used with --output json, jsonl or yaml"
+	messageEventsFailOn                     = "%v event %v found for %v %v"
+	messageWatchOutput                      = "--output and --format can't be used with --watch"
+	messageLogsTimeInvalid                  = "invalid time: %v (use a timestamp like 2017-05-01T12:00:00Z or a duration like 10m)"
+	messageLogsTimeWindow                   = "--since (%v) must be before --until (%v)"
+	messageLogsLevel                        = "unsupported log level: %v (use trace, debug, info, warn, error or fatal)"
+	messageLogsTailDeprecated               = "WARNING: --tail is deprecated, use --follow (or -t) instead"
+	messageOutputFollow                     = "--output and --format can't be used with --follow (except --output jsonl)"
+	messageLinkNotFound                     = "linked shipment environment %v %v could not be found"
+	messageDependencyCycle                  = "shipment dependencies can't be cyclic: %v"
+	messageDependencyFailed                 = "dependency %v did not succeed"
+	messageImageTagRequired                 = "image %v must have a tag or digest"
+	messageAppEnvLength                     = "%s (app-env) must be <= 32 characters"
 	messageShipmentEnvironmentNotFound      = "shipment environment not found"
 	messageReplicasMustBeNumber             = "replicas must be a number"
 	messageEnableMonitoringTrueFalse        = "please enter true or false for enableMonitoring"
@@ -16,8 +40,15 @@ const (
 	messageIntervalValidNumber              = "please enter a valid number for healthcheckIntervalSeconds"
 	messageChangeBarge                      = "changing barges involves downtime. Please run the 'down' command first, then change barge and then run 'up' again"
 	messageChangePort                       = "port changes involve downtime.  Please run the 'down --delete' command first"
+	messageChangeLoadBalancer               = "load balancer type changes involve downtime.  Please run the 'down --delete' command first"
+	messageChangePrimaryPort                = "primary port changes involve downtime.  Please run the 'down --delete' command first"
 	messageChangeHealthCheck                = "healthcheck changes involve downtime.  Please run the 'down --delete' command first"
-	messageChangeContainer                  = "container changes involve downtime.  Please run the 'down --delete' command first"
+	messageRemoveContainer                  = "containers that are no longer listed in harbor-compose.yml are only removed when the --remove-orphans flag is specified"
+	messageRemovePrimaryContainer           = "the container with the primary port can not be removed.  Please run the 'down --delete' command first"
 	messageShipmentEnvironmentFlagsRequired = "both --shipment and --environment flags are required"
+	messageEnvironmentRequired              = "shipment %v defines environments, please specify one using env or the --env flag"
+	messageEnvironmentNotDefined            = "environment %v is not defined in the environments for shipment %v"
 	messageSamlUserRequired                 = "Please specify a federated SAML user in the form role/email (e.g.; aws-digital-sandbox-devops/First.Last@turner.com)"
+	messagePortsContainerNotFound           = "ports settings refer to container %v which is not in the containers list"
+	messageVariableNotSet                   = "WARNING: The %v variable is not set. Defaulting to a blank string (use $$ for a literal $)."
 )
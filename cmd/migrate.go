@@ -113,8 +113,8 @@ func migrate(cmd *cobra.Command, args []string) {
 		app = migrateAppName
 	}
 	appEnv := fmt.Sprintf("%s-%s", app, env)
-	if len(appEnv) > 32 {
-		check(fmt.Errorf("%s (app-env) must be <= 32 characters", appEnv))
+	if len(appEnv) > maxAppEnvLength {
+		check(fmt.Errorf(messageAppEnvLength, appEnv))
 	}
 
 	//instantiate a build provider if specified
@@ -128,7 +128,8 @@ func migrate(cmd *cobra.Command, args []string) {
 	if Verbose {
 		log.Printf("fetching shipment...")
 	}
-	shipmentObject := GetShipmentEnvironment(username, token, shipment, env)
+	shipmentObject, err := GetShipmentEnvironment(username, token, shipment, env)
+	check(err)
 	if shipmentObject == nil {
 		fmt.Println(messageShipmentEnvironmentNotFound)
 		return
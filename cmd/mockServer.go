@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/spf13/cobra"
+	"github.com/turnerlabs/harbor-compose/harbor/mockserver"
+)
+
+// mockServerCmd represents the mock-server command
+var mockServerCmd = &cobra.Command{
+	Use:   "mock-server",
+	Short: "Run a local, in-memory fake of the Harbor APIs",
+	Long: `Run a local, in-memory fake of the Harbor APIs
+
+The mock server implements the shipit, helmit, trigger, customs, catalogit, barges and auth endpoints that harbor-compose uses.  State is kept in memory across calls (and lost when the server stops), and can optionally be seeded from a json file.
+
+On startup, the server prints a harbor config that points all endpoints at itself.  Save it to a file and set HC_CONFIG to that file in order to run harbor-compose commands against the mock server.  Any username and password (8 or more characters) will be accepted by login.`,
+	Example: `harbor-compose mock-server > /tmp/harbor-mock.json
+HC_CONFIG=/tmp/harbor-mock.json harbor-compose up
+
+harbor-compose mock-server --port 9000 --seed seed.json`,
+	Run:    mockServer,
+	PreRun: preRunHook,
+}
+
+var mockServerPort int
+var mockServerSeed string
+
+func init() {
+	mockServerCmd.PersistentFlags().IntVar(&mockServerPort, "port", 8000, "port to listen on")
+	mockServerCmd.PersistentFlags().StringVar(&mockServerSeed, "seed", "", "json file containing data to seed the server with")
+	RootCmd.AddCommand(mockServerCmd)
+}
+
+func mockServer(cmd *cobra.Command, args []string) {
+
+	var seed *mockserver.Seed
+	if mockServerSeed != "" {
+		var err error
+		seed, err = mockserver.LoadSeed(mockServerSeed)
+		check(err)
+	}
+
+	//output a config that points harbor-compose at this server
+	uri := fmt.Sprintf("http://localhost:%v", mockServerPort)
+	config := Config{
+		ShipitURI:    uri,
+		CatalogitURI: uri,
+		TriggerURI:   uri,
+		AuthURI:      uri,
+		HelmitURI:    uri,
+		CustomsURI:   uri,
+		TelemetryURI: uri,
+		BargesURI:    uri,
+	}
+	b, err := json.MarshalIndent(config, "", "  ")
+	check(err)
+	fmt.Println(string(b))
+
+	log.Printf("mock harbor server listening on %v", uri)
+	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%v", mockServerPort), mockserver.New(seed)))
+}
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"text/template"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+//the output format of read commands (text, json, jsonl or yaml)
+var outputFormat string
+
+//a go template that's executed for each item (like docker's --format)
+var outputTemplate string
+
+func init() {
+	RootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", "text", "output format of read commands (text, json, jsonl or yaml)")
+	RootCmd.PersistentFlags().StringVarP(&outputTemplate, "format", "", "", "format the output of read commands using a go template (e.g., '{{.Name}}')")
+}
+
+//returns an error if the output flags are invalid
+func validateOutput() error {
+	if outputFormat != "text" && outputFormat != "json" && outputFormat != "jsonl" && outputFormat != "yaml" {
+		return fmt.Errorf(messageOutputUnsupported, outputFormat)
+	}
+	if outputTemplate != "" && outputFormat != "text" {
+		return errors.New(messageOutputFormatConflict)
+	}
+	return nil
+}
+
+//returns true when read commands should write data (json, yaml or a template) rather than text
+func isStructuredOutput() bool {
+	return outputFormat != "text" || outputTemplate != ""
+}
+
+//writes items as a json or yaml list (or one json object per line), or executes the --format template for each item
+func writeOutput(out io.Writer, items []interface{}) error {
+	if items == nil {
+		items = []interface{}{}
+	}
+
+	if outputTemplate != "" {
+		tmpl, err := template.New("format").Parse(outputTemplate)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			if err := tmpl.Execute(out, item); err != nil {
+				return err
+			}
+			fmt.Fprintln(out)
+		}
+		return nil
+	}
+
+	if outputFormat == "jsonl" {
+		for _, item := range items {
+			b, err := json.Marshal(item)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(out, string(b))
+		}
+		return nil
+	}
+
+	b, err := json.MarshalIndent(items, "", "  ")
+	if err != nil {
+		return err
+	}
+	if outputFormat == "json" {
+		fmt.Fprintln(out, string(b))
+		return nil
+	}
+
+	//convert the json to yaml so that both use the same field names (and order)
+	var list []yaml.MapSlice
+	if err := yaml.Unmarshal(b, &list); err != nil {
+		return err
+	}
+	b, err = yaml.Marshal(list)
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(out, string(b))
+	return nil
+}
+
+//shipmentOutput collects the structured output of shipments (which can be processed in parallel)
+//so that it can be written in task order
+type shipmentOutput struct {
+	mu    sync.Mutex
+	items map[string][]interface{}
+}
+
+func shipmentOutputKey(task shipmentTask) string {
+	return task.Shipment + "/" + task.Env
+}
+
+//adds items to a shipment's output
+func (o *shipmentOutput) add(task shipmentTask, items ...interface{}) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.items == nil {
+		o.items = map[string][]interface{}{}
+	}
+	key := shipmentOutputKey(task)
+	o.items[key] = append(o.items[key], items...)
+}
+
+//writes the output of every shipment in task order
+func (o *shipmentOutput) write(out io.Writer, tasks []shipmentTask) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	items := []interface{}{}
+	for _, task := range tasks {
+		items = append(items, o.items[shipmentOutputKey(task)]...)
+	}
+	return writeOutput(out, items)
+}
@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setOutput(format string, template string) func() {
+	originalFormat, originalTemplate := outputFormat, outputTemplate
+	outputFormat, outputTemplate = format, template
+	return func() {
+		outputFormat, outputTemplate = originalFormat, originalTemplate
+	}
+}
+
+func getBuildTokenOutputs() []interface{} {
+	return []interface{}{
+		BuildTokenOutput{Shipment: "my-app", Environment: "dev", CiCdEnvVar: "MY_APP_DEV_TOKEN", Token: "abc"},
+		BuildTokenOutput{Shipment: "my-app", Environment: "qa", CiCdEnvVar: "MY_APP_QA_TOKEN", Token: "xyz"},
+	}
+}
+
+func TestValidateOutput(t *testing.T) {
+	defer setOutput("text", "")()
+	assert.Nil(t, validateOutput())
+	assert.False(t, isStructuredOutput())
+
+	outputFormat = "yaml"
+	assert.Nil(t, validateOutput())
+	assert.True(t, isStructuredOutput())
+
+	outputFormat = "xml"
+	assert.EqualError(t, validateOutput(), "unsupported output format: xml (use text, json, jsonl or yaml)")
+
+	outputFormat, outputTemplate = "text", "{{.Name}}"
+	assert.Nil(t, validateOutput())
+	assert.True(t, isStructuredOutput())
+
+	outputFormat = "json"
+	assert.EqualError(t, validateOutput(), messageOutputFormatConflict)
+}
+
+func TestWriteOutputJSON(t *testing.T) {
+	defer setOutput("json", "")()
+
+	var out bytes.Buffer
+	assert.Nil(t, writeOutput(&out, getBuildTokenOutputs()))
+
+	var result []map[string]string
+	assert.Nil(t, json.Unmarshal(out.Bytes(), &result))
+	assert.Equal(t, 2, len(result))
+	assert.Equal(t, "MY_APP_DEV_TOKEN", result[0]["cicdEnvVar"])
+	assert.Equal(t, "qa", result[1]["environment"])
+
+	//empty lists are still valid json
+	out.Reset()
+	assert.Nil(t, writeOutput(&out, nil))
+	assert.Equal(t, "[]\n", out.String())
+}
+
+func TestWriteOutputYAML(t *testing.T) {
+	defer setOutput("yaml", "")()
+
+	var out bytes.Buffer
+	assert.Nil(t, writeOutput(&out, getBuildTokenOutputs()))
+
+	//yaml uses the json field names (in order)
+	expected := `- shipment: my-app
+  environment: dev
+  cicdEnvVar: MY_APP_DEV_TOKEN
+  token: abc
+- shipment: my-app
+  environment: qa
+  cicdEnvVar: MY_APP_QA_TOKEN
+  token: xyz
+`
+	assert.Equal(t, expected, out.String())
+}
+
+func TestWriteOutputTemplate(t *testing.T) {
+	defer setOutput("text", "{{.Environment}}={{.Token}}")()
+
+	var out bytes.Buffer
+	assert.Nil(t, writeOutput(&out, getBuildTokenOutputs()))
+	assert.Equal(t, "dev=abc\nqa=xyz\n", out.String())
+
+	outputTemplate = "{{.Missing}}"
+	assert.NotNil(t, writeOutput(&out, getBuildTokenOutputs()))
+}
+
+func TestShipmentOutputOrder(t *testing.T) {
+	defer setOutput("text", "{{.Shipment}} {{.Environment}} {{.Name}}")()
+
+	tasks := []shipmentTask{{Shipment: "b", Env: "dev"}, {Shipment: "a", Env: "dev"}}
+
+	//shipments can finish in any order
+	var output shipmentOutput
+	output.add(tasks[1], EnvVarOutput{Shipment: "a", Environment: "dev", EnvVarPayload: envVar("FOO", "1")})
+	output.add(tasks[0], EnvVarOutput{Shipment: "b", Environment: "dev", EnvVarPayload: envVar("BAR", "2")})
+	output.add(tasks[1], EnvVarOutput{Shipment: "a", Environment: "dev", EnvVarPayload: envVar("BAZ", "3")})
+
+	var out bytes.Buffer
+	assert.Nil(t, output.write(&out, tasks))
+	assert.Equal(t, "b dev BAR\na dev FOO\na dev BAZ\n", out.String())
+}
+
+func TestGetEnvVarOutputs(t *testing.T) {
+	defer setOutput("json", "")()
+
+	envvars := []EnvVarPayload{
+		envVar("FOO", "bar"),
+		envVarHidden("SECRET", "shh"),
+		envVar("BARGE", "corp-sandbox"),
+	}
+
+	var out bytes.Buffer
+	assert.Nil(t, writeOutput(&out, getEnvVarOutputs("my-app", "dev", "web", envvars)))
+
+	var result []map[string]string
+	assert.Nil(t, json.Unmarshal(out.Bytes(), &result))
+	assert.Equal(t, []map[string]string{
+		{"shipment": "my-app", "environment": "dev", "container": "web", "name": "FOO", "value": "bar", "type": "basic"},
+		{"shipment": "my-app", "environment": "dev", "container": "web", "name": "SECRET", "value": planHiddenValue, "type": "hidden"},
+	}, result)
+}
+
+func TestGetLogOutputs(t *testing.T) {
+	helmitObject := HelmitResponse{
+		Replicas: []HelmitReplica{
+			{
+				Containers: []HelmitContainer{
+					{
+						Name:  "web",
+						ID:    "9e70dc6abc",
+						Image: "registry/web:1.0",
+						Logs: []string{
+							"2017-05-01T12:00:02Z GET /health 200",
+							"2017-05-01T12:00:00Z server started",
+						},
+					},
+					{
+						Name:  "worker",
+						ID:    "14ffbf5abc",
+						Image: "registry/worker:1.0",
+						Logs: []string{
+							"2017-05-01T12:00:01Z processing job",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	logs := getLogOutputs("my-app", "dev", helmitObject, logFilter{Lines: -1})
+	assert.Equal(t, 3, len(logs))
+	assert.Equal(t, "server started", logs[0].(LogOutput).Log)
+	assert.Equal(t, "worker", logs[1].(LogOutput).Container)
+	assert.Equal(t, "GET /health 200", logs[2].(LogOutput).Log)
+	assert.Equal(t, "my-app", logs[2].(LogOutput).Shipment)
+
+	//filter by container id
+	logs = getLogOutputs("my-app", "dev", helmitObject, logFilter{Lines: -1, Containers: []string{"14ffbf5"}})
+	assert.Equal(t, 1, len(logs))
+	assert.Equal(t, "processing job", logs[0].(LogOutput).Log)
+}
@@ -0,0 +1,217 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+//number of shipments that are processed at the same time (--parallel)
+var parallelism int
+
+//adds the --parallel flag that processes shipments concurrently
+func addParallelFlag(cmd *cobra.Command) {
+	cmd.PersistentFlags().IntVarP(&parallelism, "parallel", "", 1, "number of shipments to process at the same time")
+}
+
+//shipmentTask is a shipment environment to process
+type shipmentTask struct {
+	Shipment string
+	Env      string
+
+	//only set when the shipment environment comes from a harbor compose file
+	Config ComposeShipment
+
+	//true when other shipments depend on this one (so it needs to be healthy before they start)
+	HasDependents bool
+}
+
+//shipmentResult is the outcome of processing a shipment environment
+type shipmentResult struct {
+	Shipment string
+	Env      string
+	Err      error
+	Duration time.Duration
+
+	//true when the shipment wasn't processed because a shipment it depends on failed
+	Skipped bool
+}
+
+//processes a shipment environment, writing output to out
+type shipmentFunc func(out io.Writer, task shipmentTask) error
+
+//returns the tasks for each shipment in a harbor compose file
+func composeShipmentTasks(harborCompose HarborCompose) []shipmentTask {
+	tasks := []shipmentTask{}
+	for _, name := range sortedShipmentNames(harborCompose) {
+		shipment := harborCompose.Shipments[name]
+		tasks = append(tasks, shipmentTask{Shipment: name, Env: shipment.Env, Config: shipment})
+	}
+	return tasks
+}
+
+//returns the tasks for a list of shipment/environment tuples
+func tupleShipmentTasks(tuples []tuple) []shipmentTask {
+	tasks := []shipmentTask{}
+	for _, t := range tuples {
+		tasks = append(tasks, shipmentTask{Shipment: t.Item1, Env: t.Item2})
+	}
+	return tasks
+}
+
+func sortedShipmentNames(harborCompose HarborCompose) []string {
+	names := []string{}
+	for name := range harborCompose.Shipments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+//runs fn for each task using --parallel workers
+//
+//errors don't stop the other shipments from being processed. they are collected,
+//summarized and reflected in the exit code once every shipment has finished
+func runShipments(tasks []shipmentTask, fn shipmentFunc) {
+	out := shipmentProgressWriter()
+	results := runShipmentWorkers(tasks, fn, parallelism, out)
+	exitOnShipmentFailure(out, results)
+}
+
+//returns the writer that shipment progress is written to
+//(stdout is kept for the data when using structured output)
+func shipmentProgressWriter() io.Writer {
+	if isStructuredOutput() {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+//prints a summary of the results and exits with a non-zero status if any of them failed
+func exitOnShipmentFailure(out io.Writer, results []shipmentResult) {
+	printShipmentResults(out, results)
+
+	for _, result := range results {
+		if result.Err != nil {
+			writeMetricError(currentCommand, currentUser, result.Err)
+			//pause here to allow async telemetry call to go through
+			time.Sleep(2 * time.Second)
+			os.Exit(1)
+		}
+	}
+}
+
+//runs fn for each task and returns the results in task order
+//
+//with a single worker, tasks run one at a time and their output is streamed.
+//otherwise, tasks run concurrently and each shipment's output is buffered and
+//printed (prefixed) when it finishes so that it doesn't interleave
+func runShipmentWorkers(tasks []shipmentTask, fn shipmentFunc, workers int, out io.Writer) []shipmentResult {
+	results := make([]shipmentResult, len(tasks))
+
+	if workers <= 1 {
+		for i, task := range tasks {
+			start := time.Now()
+			err := fn(out, task)
+			results[i] = shipmentResult{Shipment: task.Shipment, Env: task.Env, Err: err, Duration: time.Since(start)}
+			if err != nil {
+				fmt.Fprintf(out, "ERROR: %v\n", err)
+			}
+		}
+		return results
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	semaphore := make(chan struct{}, workers)
+
+	for i, task := range tasks {
+		wg.Add(1)
+		semaphore <- struct{}{}
+
+		go func(i int, task shipmentTask) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			var buffer bytes.Buffer
+			start := time.Now()
+			err := fn(&buffer, task)
+			results[i] = shipmentResult{Shipment: task.Shipment, Env: task.Env, Err: err, Duration: time.Since(start)}
+
+			//print all of this shipment's output at once so that it doesn't interleave
+			mu.Lock()
+			defer mu.Unlock()
+			writePrefixed(out, fmt.Sprintf("%v %v | ", task.Shipment, task.Env), buffer.String())
+			if err != nil {
+				writePrefixed(out, fmt.Sprintf("%v %v | ", task.Shipment, task.Env), fmt.Sprintf("ERROR: %v\n", err))
+			}
+		}(i, task)
+	}
+	wg.Wait()
+
+	return results
+}
+
+//writes each line of text to out with a prefix
+func writePrefixed(out io.Writer, prefix string, text string) {
+	if text == "" {
+		return
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		fmt.Fprintf(out, "%v%v\n", prefix, line)
+	}
+}
+
+//prints a summary table of shipment results
+func printShipmentResults(out io.Writer, results []shipmentResult) {
+	const padding = 3
+	w := tabwriter.NewWriter(out, 0, 0, padding, ' ', 0)
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "SHIPMENT\tENVIRONMENT\tRESULT\tDURATION\tERROR\t")
+	failed := 0
+	skipped := 0
+	for _, result := range results {
+		status := "ok"
+		message := ""
+		if result.Err != nil {
+			message = strings.Split(result.Err.Error(), "\n")[0]
+		}
+		switch {
+		case result.Skipped:
+			status = "skipped"
+			skipped++
+		case result.Err != nil:
+			status = "failed"
+			failed++
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t\n", result.Shipment, result.Env, status, result.Duration.Round(time.Millisecond), message)
+	}
+	w.Flush()
+
+	if skipped > 0 {
+		fmt.Fprintf(out, "\n%v of %v shipment(s) failed, %v skipped\n", failed, len(results), skipped)
+		return
+	}
+	fmt.Fprintf(out, "\n%v of %v shipment(s) failed\n", failed, len(results))
+}
+
+//returns an error if the flags can't be used with --parallel
+func validateParallel(interactive bool) error {
+	if parallelism < 1 {
+		return errors.New(messageParallelPositive)
+	}
+	if parallelism > 1 && interactive {
+		return errors.New(messageParallelInteractive)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunShipmentWorkers(t *testing.T) {
+	tasks := []shipmentTask{
+		{Shipment: "app1", Env: "dev"},
+		{Shipment: "app2", Env: "dev"},
+		{Shipment: "app3", Env: "qa"},
+	}
+
+	var out bytes.Buffer
+	results := runShipmentWorkers(tasks, func(out io.Writer, task shipmentTask) error {
+		fmt.Fprintln(out, "starting")
+		fmt.Fprintln(out, "done")
+		switch task.Shipment {
+		case "app2":
+			return errors.New("boom")
+		case "app3":
+			return errors.New("not found")
+		}
+		return nil
+	}, 2, &out)
+
+	//results are in task order
+	assert.Equal(t, 3, len(results))
+	assert.Equal(t, "app1", results[0].Shipment)
+	assert.Nil(t, results[0].Err)
+	assert.Equal(t, "app2", results[1].Shipment)
+	assert.EqualError(t, results[1].Err, "boom")
+	assert.Equal(t, "app3", results[2].Shipment)
+	assert.EqualError(t, results[2].Err, "not found")
+
+	//each shipment's output is prefixed and not interleaved
+	output := out.String()
+	assert.Contains(t, output, "app1 dev | starting\napp1 dev | done\n")
+	assert.Contains(t, output, "app2 dev | starting\napp2 dev | done\napp2 dev | ERROR: boom\n")
+	assert.Contains(t, output, "app3 qa | starting\napp3 qa | done\napp3 qa | ERROR: not found\n")
+}
+
+func TestRunShipmentWorkersSerial(t *testing.T) {
+	tasks := []shipmentTask{
+		{Shipment: "app1", Env: "dev"},
+		{Shipment: "app2", Env: "dev"},
+		{Shipment: "app3", Env: "qa"},
+	}
+
+	var out bytes.Buffer
+	results := runShipmentWorkers(tasks, func(out io.Writer, task shipmentTask) error {
+		fmt.Fprintf(out, "starting %v\n", task.Shipment)
+		if task.Shipment == "app1" {
+			return errors.New("boom")
+		}
+		return nil
+	}, 1, &out)
+
+	//a failure doesn't stop the remaining shipments
+	assert.Equal(t, 3, len(results))
+	assert.EqualError(t, results[0].Err, "boom")
+	assert.Nil(t, results[1].Err)
+	assert.Nil(t, results[2].Err)
+
+	//output is streamed in order without a prefix
+	assert.Equal(t, "starting app1\nERROR: boom\nstarting app2\nstarting app3\n", out.String())
+}
+
+func TestPrintShipmentResults(t *testing.T) {
+	results := []shipmentResult{
+		{Shipment: "app1", Env: "dev"},
+		{Shipment: "app2", Env: "dev", Err: errors.New("boom\nmore details")},
+		{Shipment: "app3", Env: "dev", Err: errors.New("dependency app2 did not succeed"), Skipped: true},
+	}
+
+	var out bytes.Buffer
+	printShipmentResults(&out, results)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Equal(t, 6, len(lines))
+	assert.Equal(t, []string{"SHIPMENT", "ENVIRONMENT", "RESULT", "DURATION", "ERROR"}, strings.Fields(lines[0]))
+	assert.Equal(t, []string{"app1", "dev", "ok", "0s"}, strings.Fields(lines[1]))
+	assert.Equal(t, []string{"app2", "dev", "failed", "0s", "boom"}, strings.Fields(lines[2]))
+	assert.Equal(t, []string{"app3", "dev", "skipped", "0s", "dependency", "app2", "did", "not", "succeed"}, strings.Fields(lines[3]))
+	assert.Equal(t, "1 of 3 shipment(s) failed, 1 skipped", lines[5])
+}
+
+func TestValidateParallel(t *testing.T) {
+	defer func(p int) { parallelism = p }(parallelism)
+
+	parallelism = 1
+	assert.Nil(t, validateParallel(true))
+
+	parallelism = 4
+	assert.Nil(t, validateParallel(false))
+	assert.EqualError(t, validateParallel(true), messageParallelInteractive)
+
+	parallelism = 0
+	assert.EqualError(t, validateParallel(false), messageParallelPositive)
+}
@@ -0,0 +1,310 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//planAction represents what up would do to a single shipment setting
+type planAction int
+
+const (
+	planNoop planAction = iota
+	planCreate
+	planUpdate
+	planDelete
+)
+
+//symbols used when rendering a plan (terraform-style)
+var planSymbols = map[planAction]string{
+	planNoop:   " ",
+	planCreate: "+",
+	planUpdate: "~",
+	planDelete: "-",
+}
+
+const planHiddenValue = "(hidden)"
+
+//planChange represents a single difference between the desired and existing state of a shipment environment
+type planChange struct {
+	Action    planAction
+	Container string
+	Setting   string
+	Old       string
+	New       string
+}
+
+//shipmentPlan represents all of the changes that up would make to a shipment environment
+type shipmentPlan struct {
+	Shipment string
+	Env      string
+	Create   bool
+	Changes  []planChange
+}
+
+//planOptions controls which optional changes are included in a plan
+type planOptions struct {
+
+	//include env vars that would be deleted because they no longer exist locally
+	Prune bool
+
+	//include containers that would be removed because they're no longer listed
+	RemoveOrphans bool
+}
+
+//returns the number of changes for a particular action
+func (p *shipmentPlan) count(action planAction) int {
+	result := 0
+	for _, c := range p.Changes {
+		if c.Action == action {
+			result++
+		}
+	}
+	return result
+}
+
+//returns true if applying this plan would result in changes
+func (p *shipmentPlan) pending() bool {
+	return p.Create || p.count(planCreate) > 0 || p.count(planUpdate) > 0 || p.count(planDelete) > 0
+}
+
+func (p *shipmentPlan) add(action planAction, container string, setting string, old string, new string) {
+	p.Changes = append(p.Changes, planChange{
+		Action:    action,
+		Container: container,
+		Setting:   setting,
+		Old:       old,
+		New:       new,
+	})
+}
+
+//compares two values and adds a create, update, or no-op change
+func (p *shipmentPlan) compare(container string, setting string, old string, new string) {
+	action := planNoop
+	if old != new {
+		action = planUpdate
+	}
+	p.add(action, container, setting, old, new)
+}
+
+//diffs desired env vars against existing ones
+func (p *shipmentPlan) compareEnvVars(container string, existing []EnvVarPayload, desired []EnvVarPayload) {
+
+	//sort by name so that output is stable
+	sorted := make([]EnvVarPayload, len(desired))
+	copy(sorted, desired)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	for _, envvar := range sorted {
+		if envvar.Name == "" {
+			continue
+		}
+		setting := "envvar " + envvar.Name
+		current := getEnvVar(envvar.Name, existing)
+
+		//not found
+		if current.Name == "" {
+			p.add(planCreate, container, setting, "", planEnvVarValue(envvar, envvar))
+			continue
+		}
+
+		//mask both sides if either is hidden
+		action := planNoop
+		if current.Value != envvar.Value || current.Type != envvar.Type {
+			action = planUpdate
+		}
+		p.add(action, container, setting, planEnvVarValue(*current, envvar), planEnvVarValue(envvar, *current))
+	}
+}
+
+//adds creates for a new container's image, env vars and ports
+func (p *shipmentPlan) addContainer(container ContainerPayload) {
+	p.add(planCreate, container.Name, "image", "", strconv.Quote(container.Image))
+	p.compareEnvVars(container.Name, nil, container.EnvVars)
+	for _, port := range container.Ports {
+		p.add(planCreate, container.Name, "port "+port.Name, "", planPort(port))
+	}
+}
+
+//returns a printable representation of a port's certificate
+func planCertificate(port PortPayload) string {
+	if port.SslArn == "" {
+		return "(none)"
+	}
+	return fmt.Sprintf("%v %v", port.SslManagementType, strconv.Quote(port.SslArn))
+}
+
+//returns a printable representation of a port mapping
+func planPort(port PortPayload) string {
+	return fmt.Sprintf("%v:%v", port.PublicPort, port.Value)
+}
+
+//adds deletes for existing env vars that are no longer desired (used by --prune)
+func (p *shipmentPlan) pruneEnvVars(container string, existing []EnvVarPayload, desired []EnvVarPayload) {
+	for _, change := range pruneEnvVarChanges(container, existing, desired) {
+		p.add(planDelete, container, "envvar "+change.EnvVar.Name, planEnvVarValue(change.EnvVar, change.EnvVar), "")
+	}
+}
+
+//returns a printable env var value, masking values that are hidden on either side of the comparison
+func planEnvVarValue(envvar EnvVarPayload, other EnvVarPayload) string {
+	if envvar.Type == "hidden" || other.Type == "hidden" {
+		return planHiddenValue
+	}
+	return strconv.Quote(envvar.Value)
+}
+
+//returns a printable representation of an optional int
+func planInt(i *int) string {
+	if i == nil {
+		return "(default)"
+	}
+	return strconv.Itoa(*i)
+}
+
+//planShipment computes the changes that up would make in order to converge an existing shipment environment
+//to its desired state (without making any changes)
+func planShipment(shipmentName string, shipment ComposeShipment, desired *ShipmentEnvironment, existing *ShipmentEnvironment, options planOptions) shipmentPlan {
+
+	plan := shipmentPlan{
+		Shipment: shipmentName,
+		Env:      shipment.Env,
+		Changes:  []planChange{},
+	}
+
+	//everything is new when the shipment environment doesn't exist
+	if existing == nil {
+		plan.Create = true
+		for _, container := range desired.Containers {
+			plan.addContainer(container)
+		}
+		plan.compareEnvVars("", nil, desired.EnvVars)
+		plan.add(planCreate, "", "replicas", "", strconv.Itoa(ec2Provider(desired.Providers).Replicas))
+		plan.add(planCreate, "", "enableMonitoring", "", strconv.FormatBool(desired.EnableMonitoring))
+		if desired.IamRole != "" {
+			plan.add(planCreate, "", "iamRole", "", strconv.Quote(desired.IamRole))
+		}
+		return plan
+	}
+
+	//containers
+	for _, desiredContainer := range desired.Containers {
+		existingContainer := findContainer(desiredContainer.Name, existing.Containers)
+		if existingContainer == nil {
+			plan.addContainer(desiredContainer)
+			continue
+		}
+
+		//images
+		if !shipment.IgnoreImageVersion {
+			plan.compare(desiredContainer.Name, "image", strconv.Quote(existingContainer.Image), strconv.Quote(desiredContainer.Image))
+		}
+
+		//container-level env vars
+		plan.compareEnvVars(desiredContainer.Name, existingContainer.EnvVars, desiredContainer.EnvVars)
+		if options.Prune {
+			plan.pruneEnvVars(desiredContainer.Name, existingContainer.EnvVars, desiredContainer.EnvVars)
+		}
+
+		//new ports and certificates
+		for _, port := range desiredContainer.Ports {
+			existingPort := getPort(existingContainer.Ports, port.Name)
+			if existingPort.Name == "" {
+				plan.add(planCreate, desiredContainer.Name, "port "+port.Name, "", planPort(port))
+				continue
+			}
+			if port.SslArn != "" {
+				plan.compare(desiredContainer.Name, "port "+port.Name+" certificate", planCertificate(existingPort), planCertificate(port))
+			}
+		}
+	}
+
+	//environment-level env vars (including the barge, same as update)
+	environment := []EnvVarPayload{}
+	for name, value := range shipment.Environment {
+		environment = append(environment, envVar(name, value))
+	}
+	if len(shipment.Barge) > 0 {
+		environment = append(environment, envVar(envVarNameBarge, shipment.Barge))
+	}
+	plan.compareEnvVars("", existing.EnvVars, environment)
+	if options.Prune {
+		plan.pruneEnvVars("", existing.EnvVars, environment)
+	}
+
+	//containers that are no longer listed
+	if options.RemoveOrphans {
+		for _, existingContainer := range existing.Containers {
+			if findContainer(existingContainer.Name, desired.Containers) == nil {
+				plan.add(planDelete, existingContainer.Name, "", strconv.Quote(existingContainer.Image), "")
+			}
+		}
+	}
+
+	//healthcheck settings
+	for _, container := range existing.Containers {
+		for _, port := range container.Ports {
+			if shipment.HealthcheckTimeoutSeconds != nil {
+				plan.compare(container.Name, "port "+port.Name+" healthcheckTimeoutSeconds", planInt(port.HealthcheckTimeout), planInt(shipment.HealthcheckTimeoutSeconds))
+			}
+			if shipment.HealthcheckIntervalSeconds != nil {
+				plan.compare(container.Name, "port "+port.Name+" healthcheckIntervalSeconds", planInt(port.HealthcheckInterval), planInt(shipment.HealthcheckIntervalSeconds))
+			}
+		}
+	}
+
+	//monitoring
+	if shipment.EnableMonitoring != nil {
+		plan.compare("", "enableMonitoring", strconv.FormatBool(existing.EnableMonitoring), strconv.FormatBool(*shipment.EnableMonitoring))
+	}
+
+	//iam role
+	if shipment.IamRole != "" {
+		plan.compare("", "iamRole", strconv.Quote(existing.IamRole), strconv.Quote(shipment.IamRole))
+	}
+
+	//replicas
+	plan.compare("", "replicas", strconv.Itoa(ec2Provider(existing.Providers).Replicas), strconv.Itoa(shipment.Replicas))
+
+	return plan
+}
+
+//prints a shipment plan
+func printPlan(out io.Writer, plan shipmentPlan) {
+
+	fmt.Fprintf(out, "%v %v\n", plan.Shipment, plan.Env)
+	if plan.Create {
+		fmt.Fprintln(out, "  + shipment environment will be created")
+	}
+
+	for _, change := range plan.Changes {
+
+		//only show no-ops in verbose mode
+		if change.Action == planNoop && !Verbose {
+			continue
+		}
+
+		target := change.Setting
+		if change.Container != "" {
+			target = strings.TrimSpace("container " + change.Container + " " + change.Setting)
+		}
+
+		switch change.Action {
+		case planCreate:
+			fmt.Fprintf(out, "  %s %s: %s\n", planSymbols[change.Action], target, change.New)
+		case planUpdate:
+			fmt.Fprintf(out, "  %s %s: %s => %s\n", planSymbols[change.Action], target, change.Old, change.New)
+		case planDelete:
+			fmt.Fprintf(out, "  %s %s: %s\n", planSymbols[change.Action], target, change.Old)
+		default:
+			fmt.Fprintf(out, "  %s %s: %s\n", planSymbols[change.Action], target, change.New)
+		}
+	}
+
+	fmt.Fprintf(out, "\nPlan: %v to create, %v to update, %v to delete, %v unchanged.\n\n", plan.count(planCreate), plan.count(planUpdate), plan.count(planDelete), plan.count(planNoop))
+}
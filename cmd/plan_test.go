@@ -0,0 +1,297 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func getPlanTestExistingShipment(t *testing.T) ShipmentEnvironment {
+	shipmentJSON := getSampleShipmentJSONForValidation()
+	shipmentJSON = strings.Replace(shipmentJSON, "${env}", "dev", 1)
+	shipmentJSON = strings.Replace(shipmentJSON, "${barge}", "digital-sandbox", 1)
+	shipmentJSON = strings.Replace(shipmentJSON, "${replicas}", "2", 1)
+	shipmentJSON = strings.Replace(shipmentJSON, "${container}", "web", 1)
+
+	var existingShipment ShipmentEnvironment
+	err := json.Unmarshal([]byte(shipmentJSON), &existingShipment)
+	assert.Nil(t, err)
+	return existingShipment
+}
+
+func findPlanChange(plan shipmentPlan, container string, setting string) *planChange {
+	for _, c := range plan.Changes {
+		if c.Container == container && c.Setting == setting {
+			return &c
+		}
+	}
+	return nil
+}
+
+func TestPlanNewShipment(t *testing.T) {
+
+	dockerComposeYaml := `
+version: "2"
+services:
+  web:
+    image: quay.io/turner/web:1.0
+    ports:
+    - 80:5000
+    environment:
+      HEALTHCHECK: /hc
+`
+
+	harborComposeYaml := `
+shipments:
+  mss-poc-app:
+    env: dev
+    barge: digital-sandbox
+    containers:
+    - web
+    replicas: 2
+    group: mss
+`
+
+	dockerCompose, harborCompose := unmarshalCompose(dockerComposeYaml, harborComposeYaml)
+	composeShipment := harborCompose.Shipments["mss-poc-app"]
+	desired := transformComposeToShipmentEnvironment("mss-poc-app", composeShipment, dockerCompose)
+
+	//test
+	plan := planShipment("mss-poc-app", composeShipment, &desired, nil, planOptions{})
+
+	//assertions
+	assert.True(t, plan.Create)
+	assert.True(t, plan.pending())
+	assert.Equal(t, 0, plan.count(planUpdate))
+	assert.Equal(t, `"quay.io/turner/web:1.0"`, findPlanChange(plan, "web", "image").New)
+	assert.Equal(t, "2", findPlanChange(plan, "", "replicas").New)
+}
+
+func TestPlanNoChanges(t *testing.T) {
+
+	existing := getPlanTestExistingShipment(t)
+
+	dockerComposeYaml := `
+version: "2"
+services:
+  web:
+    image: quay.io/turner/web:1.0
+    ports:
+    - 80:5000
+    environment:
+      HEALTHCHECK: /hc
+      CONTAINER_LEVEL: containerLevel
+`
+
+	harborComposeYaml := `
+shipments:
+  mss-poc-app:
+    env: dev
+    containers:
+    - web
+    replicas: 2
+    environment:
+      ENV_LEVEL: envLevel
+`
+
+	dockerCompose, harborCompose := unmarshalCompose(dockerComposeYaml, harborComposeYaml)
+	composeShipment := harborCompose.Shipments["mss-poc-app"]
+	desired := transformComposeToShipmentEnvironment("mss-poc-app", composeShipment, dockerCompose)
+
+	//test
+	plan := planShipment("mss-poc-app", composeShipment, &desired, &existing, planOptions{})
+
+	//assertions
+	assert.False(t, plan.Create)
+	assert.False(t, plan.pending())
+	assert.True(t, plan.count(planNoop) > 0)
+}
+
+func TestPlanChanges(t *testing.T) {
+
+	existing := getPlanTestExistingShipment(t)
+
+	dockerComposeYaml := `
+version: "2"
+services:
+  web:
+    image: quay.io/turner/web:1.1
+    ports:
+    - 80:5000
+    environment:
+      HEALTHCHECK: /hc
+      CONTAINER_LEVEL: changed
+      NEW_VAR: foo
+`
+
+	harborComposeYaml := `
+shipments:
+  mss-poc-app:
+    env: dev
+    containers:
+    - web
+    replicas: 4
+    enableMonitoring: true
+    healthcheckTimeoutSeconds: 2
+`
+
+	dockerCompose, harborCompose := unmarshalCompose(dockerComposeYaml, harborComposeYaml)
+	composeShipment := harborCompose.Shipments["mss-poc-app"]
+	desired := transformComposeToShipmentEnvironment("mss-poc-app", composeShipment, dockerCompose)
+
+	//test
+	plan := planShipment("mss-poc-app", composeShipment, &desired, &existing, planOptions{})
+
+	//assertions
+	assert.True(t, plan.pending())
+
+	image := findPlanChange(plan, "web", "image")
+	assert.Equal(t, planUpdate, image.Action)
+	assert.Equal(t, `"quay.io/turner/web:1.0"`, image.Old)
+	assert.Equal(t, `"quay.io/turner/web:1.1"`, image.New)
+
+	assert.Equal(t, planUpdate, findPlanChange(plan, "web", "envvar CONTAINER_LEVEL").Action)
+	assert.Equal(t, planCreate, findPlanChange(plan, "web", "envvar NEW_VAR").Action)
+	assert.Equal(t, planNoop, findPlanChange(plan, "web", "envvar HEALTHCHECK").Action)
+
+	replicas := findPlanChange(plan, "", "replicas")
+	assert.Equal(t, planUpdate, replicas.Action)
+	assert.Equal(t, "2", replicas.Old)
+	assert.Equal(t, "4", replicas.New)
+
+	assert.Equal(t, planUpdate, findPlanChange(plan, "", "enableMonitoring").Action)
+	assert.Equal(t, planUpdate, findPlanChange(plan, "web", "port PORT healthcheckTimeoutSeconds").Action)
+}
+
+func TestPlanHiddenEnvVarsAreMasked(t *testing.T) {
+
+	existing := getPlanTestExistingShipment(t)
+	existing.Containers[0].EnvVars = append(existing.Containers[0].EnvVars, envVarHidden("SECRET", "old-secret"))
+
+	desired := existing
+	desired.Containers = []ContainerPayload{
+		{
+			Name:    "web",
+			Image:   existing.Containers[0].Image,
+			EnvVars: []EnvVarPayload{envVarHidden("SECRET", "new-secret")},
+		},
+	}
+	composeShipment := ComposeShipment{Env: "dev", Replicas: 2}
+
+	//test
+	plan := planShipment("mss-poc-app", composeShipment, &desired, &existing, planOptions{})
+
+	//assertions
+	secret := findPlanChange(plan, "web", "envvar SECRET")
+	assert.Equal(t, planUpdate, secret.Action)
+	assert.Equal(t, planHiddenValue, secret.Old)
+	assert.Equal(t, planHiddenValue, secret.New)
+}
+
+func TestPlanPrune(t *testing.T) {
+
+	existing := getPlanTestExistingShipment(t)
+	existing.EnvVars = append(existing.EnvVars, envVar(envVarNameBarge, "digital-sandbox"), envVar(envVarNameLogsEndpoint, "https://logs"))
+
+	dockerComposeYaml := `
+version: "2"
+services:
+  web:
+    image: quay.io/turner/web:1.0
+    ports:
+    - 80:5000
+    environment:
+      HEALTHCHECK: /hc
+`
+
+	harborComposeYaml := `
+shipments:
+  mss-poc-app:
+    env: dev
+    barge: digital-sandbox
+    containers:
+    - web
+    replicas: 2
+`
+
+	dockerCompose, harborCompose := unmarshalCompose(dockerComposeYaml, harborComposeYaml)
+	composeShipment := harborCompose.Shipments["mss-poc-app"]
+	desired := transformComposeToShipmentEnvironment("mss-poc-app", composeShipment, dockerCompose)
+
+	//without --prune, nothing is deleted
+	plan := planShipment("mss-poc-app", composeShipment, &desired, &existing, planOptions{})
+	assert.Equal(t, 0, plan.count(planDelete))
+	assert.False(t, plan.pending())
+
+	//test
+	plan = planShipment("mss-poc-app", composeShipment, &desired, &existing, planOptions{Prune: true})
+
+	//assertions
+	assert.True(t, plan.pending())
+	assert.Equal(t, 2, plan.count(planDelete))
+
+	containerLevel := findPlanChange(plan, "web", "envvar CONTAINER_LEVEL")
+	assert.Equal(t, planDelete, containerLevel.Action)
+	assert.Equal(t, `"containerLevel"`, containerLevel.Old)
+	assert.Equal(t, planDelete, findPlanChange(plan, "", "envvar ENV_LEVEL").Action)
+
+	//reserved env vars are never deleted
+	assert.Nil(t, findPlanChange(plan, "", "envvar "+envVarNameLogsEndpoint))
+	assert.Equal(t, planNoop, findPlanChange(plan, "", "envvar "+envVarNameBarge).Action)
+}
+
+func TestPlanContainers(t *testing.T) {
+
+	existing := getPlanTestExistingShipment(t)
+	existing.Containers = append(existing.Containers, ContainerPayload{Name: "worker", Image: "registry/worker:1.0"})
+
+	dockerComposeYaml := `
+version: "2"
+services:
+  web:
+    image: quay.io/turner/web:1.0
+    ports:
+    - 80:5000
+    environment:
+      HEALTHCHECK: /hc
+  sidecar:
+    image: registry/sidecar:1.0
+    ports:
+    - 8080:8080
+    environment:
+      HEALTHCHECK: /health
+`
+
+	harborComposeYaml := `
+shipments:
+  mss-poc-app:
+    env: dev
+    barge: digital-sandbox
+    containers:
+    - web
+    - sidecar
+    replicas: 2
+`
+
+	dockerCompose, harborCompose := unmarshalCompose(dockerComposeYaml, harborComposeYaml)
+	composeShipment := harborCompose.Shipments["mss-poc-app"]
+	desired := transformComposeToShipmentEnvironment("mss-poc-app", composeShipment, dockerCompose)
+
+	//test
+	plan := planShipment("mss-poc-app", composeShipment, &desired, &existing, planOptions{})
+
+	//new containers are created
+	assert.Equal(t, `"registry/sidecar:1.0"`, findPlanChange(plan, "sidecar", "image").New)
+	assert.Equal(t, planCreate, findPlanChange(plan, "sidecar", "port PORT").Action)
+	assert.Equal(t, planCreate, findPlanChange(plan, "sidecar", "envvar HEALTHCHECK").Action)
+
+	//orphans are only removed with --remove-orphans
+	assert.Nil(t, findPlanChange(plan, "worker", ""))
+
+	plan = planShipment("mss-poc-app", composeShipment, &desired, &existing, planOptions{RemoveOrphans: true})
+	worker := findPlanChange(plan, "worker", "")
+	assert.Equal(t, planDelete, worker.Action)
+	assert.Equal(t, `"registry/worker:1.0"`, worker.Old)
+}
@@ -0,0 +1,469 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+//the name given to a container's first port (which harbor exposes to the container as an env var)
+const defaultPortName = "PORT"
+
+const defaultPortProtocol = "http"
+
+//port protocols supported by harbor
+var portProtocols = []string{"http", "https", "tcp"}
+
+//maps harbor-compose.yml load balancer types to harbor's
+var loadBalancerTypes = map[string]string{
+	"elb": "default",
+	"alb": "alb",
+}
+
+//ssl management types (where a certificate comes from)
+const (
+	sslManagementTypeIam = "iam"
+	sslManagementTypeAcm = "acm"
+)
+
+//parses a docker compose port mapping ([ip:]public:container[/protocol] or container) into its public and container ports
+func parsePortMapping(mapping string) (int, int, error) {
+	spec := strings.Split(mapping, "/")[0]
+	parts := strings.Split(spec, ":")
+	if len(parts) > 3 {
+		return 0, 0, fmt.Errorf("invalid port: %v", mapping)
+	}
+
+	value, err := strconv.Atoi(parts[len(parts)-1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid port: %v", mapping)
+	}
+
+	//the public port defaults to the container port
+	public := value
+	if len(parts) > 1 {
+		public, err = strconv.Atoi(parts[len(parts)-2])
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid port: %v", mapping)
+		}
+	}
+
+	return public, value, nil
+}
+
+//returns the name of a port that doesn't have a name specified in harbor-compose.yml
+func getDefaultPortName(index int, value int) string {
+	if index == 0 {
+		return defaultPortName
+	}
+	return fmt.Sprintf("%v_%v", defaultPortName, value)
+}
+
+//maps a container's docker compose port mappings to harbor ports, applying any settings from harbor-compose.yml
+//the first port uses the container's HEALTHCHECK env var unless a healthcheck is specified
+func transformComposePorts(shipment ComposeShipment, container string, mappings []string, healthcheck string) ([]PortPayload, error) {
+
+	settings := shipment.Ports[container]
+	matched := map[int]bool{}
+	ports := []PortPayload{}
+	lbType, lbPublic := getLoadBalancerDefaults(shipment.LoadBalancer)
+
+	for i, mapping := range mappings {
+		public, value, err := parsePortMapping(mapping)
+		if err != nil {
+			return nil, err
+		}
+
+		port := PortPayload{
+			Name:                getDefaultPortName(i, value),
+			Value:               value,
+			PublicPort:          public,
+			Protocol:            defaultPortProtocol,
+			External:            true,
+			PublicVip:           lbPublic,
+			LBType:              lbType,
+			SslManagementType:   sslManagementTypeIam,
+			HealthcheckTimeout:  shipment.HealthcheckTimeoutSeconds,
+			HealthcheckInterval: shipment.HealthcheckIntervalSeconds,
+		}
+		if i == 0 {
+			port.Healthcheck = healthcheck
+		}
+
+		if s := findComposePort(settings, value); s != nil {
+			matched[value] = true
+			applyComposePort(&port, *s)
+		}
+
+		ports = append(ports, port)
+	}
+
+	//settings have to refer to a port mapping
+	for _, s := range settings {
+		if !matched[s.Port] {
+			return nil, fmt.Errorf("ports setting for container %v port %v does not match a port mapping in the docker compose file", container, s.Port)
+		}
+	}
+
+	return ports, nil
+}
+
+//overrides the defaults of a harbor port with the settings from harbor-compose.yml
+func applyComposePort(port *PortPayload, settings ComposePort) {
+	if settings.Name != "" {
+		port.Name = settings.Name
+	}
+	if settings.Protocol != "" {
+		port.Protocol = settings.Protocol
+	}
+	if settings.Healthcheck != "" {
+		port.Healthcheck = settings.Healthcheck
+	}
+	if settings.Primary != nil {
+		port.Primary = *settings.Primary
+	}
+	if settings.External != nil {
+		port.External = *settings.External
+	}
+	if settings.PublicVip != nil {
+		port.PublicVip = *settings.PublicVip
+	}
+	port.EnableProxyProtocol = settings.EnableProxyProtocol
+}
+
+//returns the harbor load balancer type and whether ports are public by default
+//unknown types are passed through so that validation can report them
+func getLoadBalancerDefaults(lb *ComposeLoadBalancer) (string, bool) {
+	if lb == nil {
+		return "", false
+	}
+	lbType := lb.Type
+	if t, ok := loadBalancerTypes[lb.Type]; ok {
+		lbType = t
+	}
+	public := false
+	if lb.Public != nil {
+		public = *lb.Public
+	}
+	return lbType, public
+}
+
+//serves the primary port over https and applies the load balancer certificate to https ports
+func applyHTTPS(lb *ComposeLoadBalancer, containers []ContainerPayload) {
+	if lb == nil || lb.HTTPS == nil {
+		return
+	}
+	check(validateHTTPS(lb))
+
+	sslArn := lb.HTTPS.IamCertificate
+	sslManagementType := sslManagementTypeIam
+	if lb.HTTPS.CertificateArn != "" {
+		sslArn = lb.HTTPS.CertificateArn
+		sslManagementType = sslManagementTypeAcm
+	}
+
+	for i := range containers {
+		for j := range containers[i].Ports {
+			port := &containers[i].Ports[j]
+			if port.Primary {
+				port.Protocol = "https"
+			}
+			if port.Protocol == "https" {
+				port.SslArn = sslArn
+				port.SslManagementType = sslManagementType
+			}
+		}
+	}
+}
+
+//validates the load balancer's https listener (exactly one certificate source is required)
+func validateHTTPS(lb *ComposeLoadBalancer) error {
+	if lb == nil || lb.HTTPS == nil {
+		return nil
+	}
+	if (lb.HTTPS.CertificateArn == "") == (lb.HTTPS.IamCertificate == "") {
+		return errors.New(messageHTTPSCertificate)
+	}
+	return nil
+}
+
+//finds the settings for a container port
+func findComposePort(settings []ComposePort, value int) *ComposePort {
+	for i := range settings {
+		if settings[i].Port == value {
+			return &settings[i]
+		}
+	}
+	return nil
+}
+
+//returns true if harbor-compose.yml specifies which port is primary
+func hasExplicitPrimaryPort(shipment ComposeShipment) bool {
+	for _, settings := range shipment.Ports {
+		for _, s := range settings {
+			if s.Primary != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+//keeps an existing shipment environment's primary port when harbor-compose.yml doesn't specify one,
+//so that listing a new container first doesn't move the primary port
+func keepPrimaryPort(shipment ComposeShipment, desired *ShipmentEnvironment, existing *ShipmentEnvironment) {
+	container, port := findPrimaryPort(existing.Containers)
+	if container == "" {
+		return
+	}
+
+	//the existing primary port has to be desired
+	found := false
+	for _, c := range desired.Containers {
+		if c.Name == container && getPort(c.Ports, port).Name != "" {
+			found = true
+		}
+	}
+	if !found {
+		return
+	}
+
+	for i := range desired.Containers {
+		for j := range desired.Containers[i].Ports {
+			p := &desired.Containers[i].Ports[j]
+			primary := (desired.Containers[i].Name == container && p.Name == port)
+
+			//undo the https listener that comes with being primary
+			if p.Primary && !primary {
+				p.Protocol = defaultPortProtocol
+				if s := findComposePort(shipment.Ports[desired.Containers[i].Name], p.Value); s != nil && s.Protocol != "" {
+					p.Protocol = s.Protocol
+				}
+				if p.Protocol != "https" {
+					p.SslArn = ""
+					p.SslManagementType = sslManagementTypeIam
+				}
+			}
+			p.Primary = primary
+		}
+	}
+	applyHTTPS(shipment.LoadBalancer, desired.Containers)
+}
+
+//returns the container and port names of the primary port in a list of containers
+func findPrimaryPort(containers []ContainerPayload) (string, string) {
+	for _, c := range containers {
+		if port := getPrimaryPort(c.Ports); port.Primary {
+			return c.Name, port.Name
+		}
+	}
+	return "", ""
+}
+
+//validates the ports of the desired containers
+func validatePorts(containers []ContainerPayload) error {
+	primaryPorts := 0
+
+	for _, container := range containers {
+		names := map[string]bool{}
+
+		for _, port := range container.Ports {
+			if names[port.Name] {
+				return fmt.Errorf("%v (container %v port %v)", messagePortNameUnique, container.Name, port.Name)
+			}
+			names[port.Name] = true
+
+			valid := false
+			for _, protocol := range portProtocols {
+				if port.Protocol == protocol {
+					valid = true
+				}
+			}
+			if !valid {
+				return fmt.Errorf("%v (container %v port %v)", messagePortProtocol, container.Name, port.Name)
+			}
+
+			if port.Primary {
+				primaryPorts++
+			}
+
+			if port.LBType != "" && port.LBType != loadBalancerTypes["elb"] && port.LBType != loadBalancerTypes["alb"] {
+				return errors.New(messageLoadBalancerType)
+			}
+
+			if port.SslManagementType == sslManagementTypeAcm && !strings.HasPrefix(port.SslArn, "arn:aws:acm:") {
+				return errors.New(messageCertificateArn)
+			}
+		}
+	}
+
+	if primaryPorts != 1 {
+		return errors.New(messagePrimaryPortRequired)
+	}
+	return nil
+}
+
+//validates that ports on existing containers aren't changed or removed (which involves downtime)
+func validatePortChanges(desired *ShipmentEnvironment, existing *ShipmentEnvironment) error {
+
+	//the primary port can't move
+	existingContainer, existingPort := findPrimaryPort(existing.Containers)
+	desiredContainer, desiredPort := findPrimaryPort(desired.Containers)
+	if existingContainer != "" && (existingContainer != desiredContainer || existingPort != desiredPort) {
+		return errors.New(messageChangePrimaryPort)
+	}
+
+	for _, desiredContainer := range desired.Containers {
+
+		//new containers are added
+		existingContainer := findContainer(desiredContainer.Name, existing.Containers)
+		if existingContainer == nil {
+			continue
+		}
+
+		//new ports are added
+		for _, desiredPort := range desiredContainer.Ports {
+			existingPort := getPort(existingContainer.Ports, desiredPort.Name)
+			if existingPort.Name == "" {
+				continue
+			}
+
+			if !(existingPort.Value == desiredPort.Value &&
+				existingPort.PublicPort == desiredPort.PublicPort &&
+				existingPort.Protocol == desiredPort.Protocol &&
+				existingPort.External == desiredPort.External &&
+				existingPort.PublicVip == desiredPort.PublicVip &&
+				existingPort.EnableProxyProtocol == desiredPort.EnableProxyProtocol) {
+				return errors.New(messageChangePort)
+			}
+
+			if existingPort.Healthcheck != desiredPort.Healthcheck {
+				return errors.New(messageChangeHealthCheck)
+			}
+
+			//the load balancer type is only enforced when it's specified
+			if desiredPort.LBType != "" && getLBType(existingPort) != desiredPort.LBType {
+				return errors.New(messageChangeLoadBalancer)
+			}
+		}
+
+		//ports can't be removed
+		for _, existingPort := range existingContainer.Ports {
+			if getPort(desiredContainer.Ports, existingPort.Name).Name == "" {
+				return errors.New(messageChangePort)
+			}
+		}
+	}
+
+	return nil
+}
+
+//returns a port's load balancer type (harbor reports the default type as either empty or "default")
+func getLBType(port PortPayload) string {
+	if port.LBType == "" {
+		return loadBalancerTypes["elb"]
+	}
+	return port.LBType
+}
+
+//returns the harbor-compose.yml load balancer settings needed to reproduce a shipment environment's primary port
+//(nil when the defaults are used)
+func transformLoadBalancerToCompose(containers []ContainerPayload) *ComposeLoadBalancer {
+	var primary PortPayload
+	for _, c := range containers {
+		if port := getPrimaryPort(c.Ports); port.Primary {
+			primary = port
+		}
+	}
+	if !primary.Primary {
+		return nil
+	}
+
+	lb := ComposeLoadBalancer{}
+	if getLBType(primary) == loadBalancerTypes["alb"] {
+		lb.Type = "alb"
+	}
+	if primary.PublicVip {
+		public := true
+		lb.Public = &public
+	}
+	if primary.Protocol == "https" && primary.SslArn != "" {
+		lb.HTTPS = &ComposeHTTPS{}
+		if primary.SslManagementType == sslManagementTypeAcm {
+			lb.HTTPS.CertificateArn = primary.SslArn
+		} else {
+			lb.HTTPS.IamCertificate = primary.SslArn
+		}
+	}
+
+	if lb == (ComposeLoadBalancer{}) {
+		return nil
+	}
+	return &lb
+}
+
+//returns the harbor-compose.yml port settings needed to reproduce the ports of a list of containers
+//(only ports whose settings differ from the defaults, including the load balancer defaults, are included)
+func transformPortsToCompose(containers []ContainerPayload, lb *ComposeLoadBalancer) map[string][]ComposePort {
+	result := map[string][]ComposePort{}
+	_, public := getLoadBalancerDefaults(lb)
+	https := lb != nil && lb.HTTPS != nil
+
+	//the primary port only needs to be specified when it isn't the first port of the first container
+	explicitPrimary := len(containers) > 0 && len(containers[0].Ports) > 0 && !containers[0].Ports[0].Primary
+
+	for _, container := range containers {
+		for i, port := range container.Ports {
+			settings := ComposePort{Port: port.Value}
+			changed := false
+
+			if port.Name != getDefaultPortName(i, port.Value) {
+				settings.Name = port.Name
+				changed = true
+			}
+			protocol := defaultPortProtocol
+			if https && port.Primary {
+				protocol = "https"
+			}
+			if port.Protocol != "" && port.Protocol != protocol {
+				settings.Protocol = port.Protocol
+				changed = true
+			}
+
+			//the first port's healthcheck is generated as the container's HEALTHCHECK env var
+			if i > 0 && port.Healthcheck != "" {
+				settings.Healthcheck = port.Healthcheck
+				changed = true
+			}
+			if explicitPrimary && port.Primary {
+				primary := true
+				settings.Primary = &primary
+				changed = true
+			}
+			if !port.External {
+				external := false
+				settings.External = &external
+				changed = true
+			}
+			if port.PublicVip != public {
+				publicVip := port.PublicVip
+				settings.PublicVip = &publicVip
+				changed = true
+			}
+			if port.EnableProxyProtocol {
+				settings.EnableProxyProtocol = true
+				changed = true
+			}
+
+			if changed {
+				result[container.Name] = append(result[container.Name], settings)
+			}
+		}
+	}
+
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
@@ -0,0 +1,433 @@
+package cmd
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/turnerlabs/harbor-compose/harbor/client"
+	"github.com/turnerlabs/harbor-compose/harbor/mockserver"
+)
+
+func TestParsePortMapping(t *testing.T) {
+	tests := []struct {
+		mapping string
+		public  int
+		value   int
+	}{
+		{"80:5000", 80, 5000},
+		{"5000", 5000, 5000},
+		{"127.0.0.1:80:5000", 80, 5000},
+		{"9090:9090/tcp", 9090, 9090},
+	}
+	for _, test := range tests {
+		public, value, err := parsePortMapping(test.mapping)
+		assert.Nil(t, err)
+		assert.Equal(t, test.public, public, test.mapping)
+		assert.Equal(t, test.value, value, test.mapping)
+	}
+
+	_, _, err := parsePortMapping("80:abc")
+	assert.NotNil(t, err)
+}
+
+func getPortsTestShipment(t *testing.T, harborComposeYaml string) ShipmentEnvironment {
+	dockerComposeYaml := `
+version: "2"
+services:
+  web:
+    image: registry/web:1.0
+    ports:
+    - 80:5000
+    - 9090:9090
+    environment:
+      HEALTHCHECK: /hc
+  sidecar:
+    image: registry/sidecar:1.0
+    ports:
+    - 8443:8443
+    environment:
+      HEALTHCHECK: /health
+`
+	dockerCompose, harborCompose := unmarshalCompose(dockerComposeYaml, harborComposeYaml)
+	return transformComposeToShipmentEnvironment("mss-poc-app", harborCompose.Shipments["mss-poc-app"], dockerCompose)
+}
+
+func TestTransformComposePorts(t *testing.T) {
+
+	harborComposeYaml := `
+shipments:
+  mss-poc-app:
+    env: dev
+    barge: digital-sandbox
+    containers:
+    - web
+    - sidecar
+    replicas: 2
+    healthcheckTimeoutSeconds: 2
+    ports:
+      web:
+      - port: 9090
+        name: METRICS
+        protocol: tcp
+        external: false
+`
+
+	shipment := getPortsTestShipment(t, harborComposeYaml)
+
+	web := shipment.Containers[0].Ports
+	assert.Equal(t, 2, len(web))
+	assert.Equal(t, "PORT", web[0].Name)
+	assert.Equal(t, 5000, web[0].Value)
+	assert.Equal(t, 80, web[0].PublicPort)
+	assert.Equal(t, "http", web[0].Protocol)
+	assert.Equal(t, "/hc", web[0].Healthcheck)
+	assert.True(t, web[0].Primary)
+	assert.True(t, web[0].External)
+	assert.Equal(t, 2, *web[0].HealthcheckTimeout)
+
+	assert.Equal(t, "METRICS", web[1].Name)
+	assert.Equal(t, 9090, web[1].Value)
+	assert.Equal(t, "tcp", web[1].Protocol)
+	assert.Equal(t, "", web[1].Healthcheck)
+	assert.False(t, web[1].Primary)
+	assert.False(t, web[1].External)
+	assert.Equal(t, 2, *web[1].HealthcheckTimeout)
+
+	sidecar := shipment.Containers[1].Ports
+	assert.Equal(t, "PORT", sidecar[0].Name)
+	assert.Equal(t, "/health", sidecar[0].Healthcheck)
+	assert.False(t, sidecar[0].Primary)
+
+	assert.Nil(t, validatePorts(shipment.Containers))
+}
+
+func TestTransformComposePortsPrimary(t *testing.T) {
+
+	harborComposeYaml := `
+shipments:
+  mss-poc-app:
+    env: dev
+    barge: digital-sandbox
+    containers:
+    - web
+    - sidecar
+    replicas: 2
+    ports:
+      sidecar:
+      - port: 8443
+        protocol: https
+        primary: true
+        publicVip: true
+        enableProxyProtocol: true
+`
+
+	shipment := getPortsTestShipment(t, harborComposeYaml)
+
+	assert.False(t, shipment.Containers[0].Ports[0].Primary)
+	assert.False(t, shipment.Containers[0].Ports[1].Primary)
+	sidecar := shipment.Containers[1].Ports[0]
+	assert.True(t, sidecar.Primary)
+	assert.Equal(t, "https", sidecar.Protocol)
+	assert.True(t, sidecar.PublicVip)
+	assert.True(t, sidecar.EnableProxyProtocol)
+
+	assert.Nil(t, validatePorts(shipment.Containers))
+}
+
+func TestValidatePorts(t *testing.T) {
+	containers := []ContainerPayload{
+		{
+			Name: "web",
+			Ports: []PortPayload{
+				{Name: "PORT", Protocol: "http", Primary: true},
+				{Name: "METRICS", Protocol: "udp"},
+			},
+		},
+	}
+	err := validatePorts(containers)
+	assert.Contains(t, err.Error(), messagePortProtocol)
+
+	containers[0].Ports[1] = PortPayload{Name: "PORT", Protocol: "tcp"}
+	err = validatePorts(containers)
+	assert.Contains(t, err.Error(), messagePortNameUnique)
+
+	containers[0].Ports[1] = PortPayload{Name: "METRICS", Protocol: "tcp", Primary: true}
+	err = validatePorts(containers)
+	assert.Equal(t, messagePrimaryPortRequired, err.Error())
+}
+
+func TestValidatePortChanges(t *testing.T) {
+	existing := getPlanTestExistingShipment(t)
+
+	harborComposeYaml := `
+shipments:
+  mss-poc-app:
+    env: dev
+    barge: digital-sandbox
+    containers:
+    - web
+    replicas: 2
+`
+	desired := getPortsTestShipment(t, harborComposeYaml)
+
+	//adding a port is allowed
+	assert.Nil(t, validatePortChanges(&desired, &existing))
+
+	//changing a port's settings isn't
+	desired.Containers[0].Ports[0].Protocol = "tcp"
+	assert.Equal(t, messageChangePort, validatePortChanges(&desired, &existing).Error())
+
+	//neither is removing one
+	desired.Containers[0].Ports[0].Protocol = "http"
+	existing.Containers[0].Ports = append(existing.Containers[0].Ports, desired.Containers[0].Ports[1])
+	desired.Containers[0].Ports = desired.Containers[0].Ports[:1]
+	assert.Equal(t, messageChangePort, validatePortChanges(&desired, &existing).Error())
+
+	//or moving the primary port
+	desired.Containers[0].Ports[0].Primary = false
+	assert.Equal(t, messageChangePrimaryPort, validatePortChanges(&desired, &existing).Error())
+}
+
+func TestTransformPortsToCompose(t *testing.T) {
+	harborComposeYaml := `
+shipments:
+  mss-poc-app:
+    env: dev
+    barge: digital-sandbox
+    containers:
+    - web
+    - sidecar
+    replicas: 2
+    ports:
+      web:
+      - port: 9090
+        name: METRICS
+        protocol: tcp
+        healthcheck: /metrics
+        external: false
+      sidecar:
+      - port: 8443
+        primary: true
+        publicVip: true
+`
+	harborCompose := unmarshalHarborCompose(harborComposeYaml)
+	shipment := getPortsTestShipment(t, harborComposeYaml)
+
+	//round trip
+	assert.Equal(t, harborCompose.Shipments["mss-poc-app"].Ports, transformPortsToCompose(shipment.Containers, nil))
+
+	//defaults aren't written
+	shipment = getPortsTestShipment(t, `
+shipments:
+  mss-poc-app:
+    env: dev
+    barge: digital-sandbox
+    containers:
+    - web
+    replicas: 2
+`)
+	shipment.Containers[0].Ports = shipment.Containers[0].Ports[:1]
+	assert.Nil(t, transformPortsToCompose(shipment.Containers, nil))
+}
+
+func TestUpdatePortsAddsPort(t *testing.T) {
+	existing := getPlanTestExistingShipment(t)
+	server := httptest.NewServer(mockserver.New(&mockserver.Seed{Shipments: []client.ShipmentEnvironment{existing}}))
+	defer server.Close()
+
+	defer useMockHarbor(server)()
+
+	desired := getPortsTestShipment(t, `
+shipments:
+  mss-poc-app:
+    env: dev
+    barge: digital-sandbox
+    containers:
+    - web
+    replicas: 2
+`)
+
+	//test
+	err := updatePorts(&existing, &desired, "user", "token")
+	assert.Nil(t, err)
+
+	updated, err := GetShipmentEnvironment("user", "token", "mss-poc-app", "dev")
+	assert.Nil(t, err)
+	metrics := getPort(updated.Containers[0].Ports, "PORT_9090")
+	assert.Equal(t, 9090, metrics.Value)
+	assert.False(t, metrics.Primary)
+}
+
+func TestTransformLoadBalancer(t *testing.T) {
+
+	harborComposeYaml := `
+shipments:
+  mss-poc-app:
+    env: dev
+    barge: digital-sandbox
+    containers:
+    - web
+    replicas: 2
+    loadBalancer:
+      type: alb
+      public: true
+      https:
+        certificateArn: arn:aws:acm:us-east-1:123456789012:certificate/abc
+    ports:
+      web:
+      - port: 9090
+        protocol: tcp
+        publicVip: false
+`
+
+	shipment := getPortsTestShipment(t, harborComposeYaml)
+
+	primary := shipment.Containers[0].Ports[0]
+	assert.True(t, primary.Primary)
+	assert.Equal(t, "https", primary.Protocol)
+	assert.Equal(t, "arn:aws:acm:us-east-1:123456789012:certificate/abc", primary.SslArn)
+	assert.Equal(t, sslManagementTypeAcm, primary.SslManagementType)
+	assert.Equal(t, "alb", primary.LBType)
+	assert.True(t, primary.PublicVip)
+
+	metrics := shipment.Containers[0].Ports[1]
+	assert.Equal(t, "tcp", metrics.Protocol)
+	assert.Equal(t, "", metrics.SslArn)
+	assert.Equal(t, "alb", metrics.LBType)
+	assert.False(t, metrics.PublicVip)
+
+	assert.Nil(t, validatePorts(shipment.Containers))
+
+	//generate round trip
+	lb := transformLoadBalancerToCompose(shipment.Containers)
+	harborCompose := unmarshalHarborCompose(harborComposeYaml)
+	assert.Equal(t, harborCompose.Shipments["mss-poc-app"].LoadBalancer, lb)
+	assert.Equal(t, harborCompose.Shipments["mss-poc-app"].Ports, transformPortsToCompose(shipment.Containers, lb))
+}
+
+func TestValidateLoadBalancer(t *testing.T) {
+
+	shipment := getPortsTestShipment(t, `
+shipments:
+  mss-poc-app:
+    env: dev
+    barge: digital-sandbox
+    containers:
+    - web
+    replicas: 2
+    loadBalancer:
+      type: nlb
+`)
+	assert.Equal(t, messageLoadBalancerType, validatePorts(shipment.Containers).Error())
+
+	shipment = getPortsTestShipment(t, `
+shipments:
+  mss-poc-app:
+    env: dev
+    barge: digital-sandbox
+    containers:
+    - web
+    replicas: 2
+    loadBalancer:
+      https:
+        certificateArn: my-cert
+`)
+	assert.Equal(t, messageCertificateArn, validatePorts(shipment.Containers).Error())
+
+	//iam certificates are referenced by name
+	shipment = getPortsTestShipment(t, `
+shipments:
+  mss-poc-app:
+    env: dev
+    barge: digital-sandbox
+    containers:
+    - web
+    replicas: 2
+    loadBalancer:
+      type: elb
+      https:
+        iamCertificate: my-cert
+`)
+	assert.Nil(t, validatePorts(shipment.Containers))
+	assert.Equal(t, "my-cert", shipment.Containers[0].Ports[0].SslArn)
+	assert.Equal(t, sslManagementTypeIam, shipment.Containers[0].Ports[0].SslManagementType)
+	assert.Equal(t, "default", shipment.Containers[0].Ports[0].LBType)
+
+	//changing the load balancer type involves downtime
+	existing := getPlanTestExistingShipment(t)
+	shipment.Containers[0].Ports = shipment.Containers[0].Ports[:1]
+	shipment.Containers[0].Ports[0].Protocol = "http"
+	assert.Nil(t, validatePortChanges(&shipment, &existing))
+	shipment.Containers[0].Ports[0].LBType = "alb"
+	assert.Equal(t, messageChangeLoadBalancer, validatePortChanges(&shipment, &existing).Error())
+}
+
+func TestKeepPrimaryPortHTTPS(t *testing.T) {
+	existing := getPlanTestExistingShipment(t)
+
+	harborComposeYaml := `
+shipments:
+  mss-poc-app:
+    env: dev
+    barge: digital-sandbox
+    containers:
+    - sidecar
+    - web
+    replicas: 2
+    loadBalancer:
+      https:
+        iamCertificate: my-cert
+`
+	harborCompose := unmarshalHarborCompose(harborComposeYaml)
+	desired := getPortsTestShipment(t, harborComposeYaml)
+	assert.Equal(t, "https", desired.Containers[0].Ports[0].Protocol)
+
+	//test
+	keepPrimaryPort(harborCompose.Shipments["mss-poc-app"], &desired, &existing)
+
+	//the https listener moves with the primary port
+	sidecar := desired.Containers[0].Ports[0]
+	assert.False(t, sidecar.Primary)
+	assert.Equal(t, "http", sidecar.Protocol)
+	assert.Equal(t, "", sidecar.SslArn)
+
+	web := desired.Containers[1].Ports[0]
+	assert.True(t, web.Primary)
+	assert.Equal(t, "https", web.Protocol)
+	assert.Equal(t, "my-cert", web.SslArn)
+}
+
+func TestUpdatePortsCertificate(t *testing.T) {
+	existing := getPlanTestExistingShipment(t)
+	existing.Containers[0].Ports[0].Protocol = "https"
+	existing.Containers[0].Ports[0].SslArn = "old-cert"
+	server := httptest.NewServer(mockserver.New(&mockserver.Seed{Shipments: []client.ShipmentEnvironment{existing}}))
+	defer server.Close()
+
+	defer useMockHarbor(server)()
+
+	desired := getPortsTestShipment(t, `
+shipments:
+  mss-poc-app:
+    env: dev
+    barge: digital-sandbox
+    containers:
+    - web
+    replicas: 2
+    loadBalancer:
+      https:
+        certificateArn: arn:aws:acm:us-east-1:123456789012:certificate/new
+`)
+	desired.Containers[0].Ports = desired.Containers[0].Ports[:1]
+
+	//test
+	err := updatePorts(&existing, &desired, "user", "token")
+	assert.Nil(t, err)
+
+	updated, err := GetShipmentEnvironment("user", "token", "mss-poc-app", "dev")
+	assert.Nil(t, err)
+	port := updated.Containers[0].Ports[0]
+	assert.Equal(t, "arn:aws:acm:us-east-1:123456789012:certificate/new", port.SslArn)
+	assert.Equal(t, sslManagementTypeAcm, port.SslManagementType)
+}
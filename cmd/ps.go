@@ -4,9 +4,11 @@ import (
 	"errors"
 	"fmt"
 	"html/template"
+	"io"
 	"os"
 	"strconv"
 	"text/tabwriter"
+	"time"
 
 	"github.com/dustin/go-humanize"
 	"github.com/spf13/cobra"
@@ -16,24 +18,39 @@ import (
 var psCmd = &cobra.Command{
 	Use:   "ps",
 	Short: "Lists shipment and container status",
-	Long:  "Lists shipment and container status for shipment environments listed in a harbor-compose.yml file or using the flags.",
+	Long: `Lists shipment and container status for shipment environments listed in a harbor-compose.yml file or using the flags.
+
+Use --watch to refresh the status (every --interval) in a full-screen view that shows the host each replica runs on, container state transitions, restarts since the watch started and the newest warning events.  Changes since the last refresh are highlighted.  Press ctrl-c to exit.`,
 	Example: `harbor-compose ps
 harbor-compose ps --shipment my-shipment --environment dev
-harbor-compose ps -s my-shipment -e dev`,
+harbor-compose ps -s my-shipment -e dev
+harbor-compose ps --watch
+harbor-compose ps --output json
+harbor-compose ps --format '{{.Shipment}} {{.Environment}} {{.Status}}'`,
 	Run:    ps,
 	PreRun: preRunHook,
 }
 
 var psShipment string
 var psEnvironment string
+var psWatch bool
+var psInterval time.Duration
 
 func init() {
 	psCmd.PersistentFlags().StringVarP(&psShipment, "shipment", "s", "", "shipment name")
-	psCmd.PersistentFlags().StringVarP(&psEnvironment, "environment", "e", "", "environment name")
+	psCmd.PersistentFlags().StringVarP(&psEnvironment, "environment", "e", "", "environment name (or --env)")
+	psCmd.PersistentFlags().BoolVarP(&psWatch, "watch", "w", false, "refresh status and events in a full-screen view until ctrl-c")
+	psCmd.PersistentFlags().DurationVarP(&psInterval, "interval", "", 2*time.Second, "how often to refresh when using --watch")
+	addParallelFlag(psCmd)
+	addEnvAlias(psCmd)
 	RootCmd.AddCommand(psCmd)
 }
 
 func ps(cmd *cobra.Command, args []string) {
+	check(validateParallel(psWatch))
+	if psWatch && isStructuredOutput() {
+		check(errors.New(messageWatchOutput))
+	}
 
 	//make sure user is authenticated
 	username, token, err := Login()
@@ -42,69 +59,74 @@ func ps(cmd *cobra.Command, args []string) {
 	//determine which shipment/environments user wants status for
 	inputShipmentEnvironments, _ := getShipmentEnvironmentsFromInput(psShipment, psEnvironment)
 
+	if psWatch {
+		watchPs(getPsWatchSnapshots(username, token, inputShipmentEnvironments), psInterval)
+		return
+	}
+
 	//iterate shipment/environments
-	for _, t := range inputShipmentEnvironments {
-		shipment := t.Item1
-		env := t.Item2
+	var output shipmentOutput
+	tasks := tupleShipmentTasks(inputShipmentEnvironments)
+	runShipments(tasks, func(out io.Writer, task shipmentTask) error {
+		shipment := task.Shipment
+		env := task.Env
 
 		//lookup the shipment environment
-		shipmentEnvironment := GetShipmentEnvironment(username, token, shipment, env)
+		shipmentEnvironment, err := GetShipmentEnvironment(username, token, shipment, env)
+		if err != nil {
+			return err
+		}
 		if shipmentEnvironment == nil {
-			fmt.Println(messageShipmentEnvironmentNotFound)
-			return
-		}		
+			return errors.New(messageShipmentEnvironmentNotFound)
+		}
 
 		//lookup the provider
 		provider := ec2Provider(shipmentEnvironment.Providers)
 
 		//fetch container status using helmit api
-		shipmentStatus := GetShipmentStatus(provider.Barge, shipment, env)
+		shipmentStatus, err := GetShipmentStatus(provider.Barge, shipment, env)
+		if err != nil {
+			return err
+		}
 
 		//get shipment's primary port
 		primaryPort, err := getShipmentPrimaryPort(shipmentEnvironment)
-		check(err)
+		if err != nil {
+			return err
+		}
 
 		//get shipment endpoint
 		endpoint := getShipmentEndpoint(shipment, env, provider.Name, primaryPort)
 
 		//print status to console
-		printShipmentStatus(shipment, shipmentEnvironment, provider, shipmentStatus, endpoint)
+		shipmentOutput := getShipmentStatusOutput(shipment, shipmentEnvironment, provider, shipmentStatus, endpoint)
+		if isStructuredOutput() {
+			output.add(task, shipmentOutput)
+		} else {
+			printShipmentStatus(out, shipmentOutput)
+		}
+		return nil
+	})
+
+	if isStructuredOutput() {
+		check(output.write(os.Stdout, tasks))
 	}
 }
 
-func printShipmentStatus(name string, shipment *ShipmentEnvironment, provider *ProviderPayload, shipmentStatus *ShipmentStatus, endpoint string) {
-
-	const padding = 3
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, padding, ' ', tabwriter.DiscardEmptyColumns)
-
-	//create a formatted template
-	tmpl, err := template.New("shipment").Parse("SHIPMENT:\t{{.Shipment}}\t\nENVIRONMENT:\t{{.Environment}}\nBARGE:\t{{.Barge}}\t\nENDPOINT:\t{{.Endpoint}}\t\nSTATUS:\t{{.Status}}\t\nCONTAINERS:\t{{.Containers}}\t\nREPLICAS:\t{{.Replicas}}\t")
-
-	fmt.Fprintln(w)
-	check(err)
-
-	//build an object to pass to the template
+//builds an object representing a shipment environment's status
+func getShipmentStatusOutput(name string, shipment *ShipmentEnvironment, provider *ProviderPayload, shipmentStatus *ShipmentStatus, endpoint string) ShipmentStatusOutput {
 	shipmentOutput := ShipmentStatusOutput{
-		Shipment:    name,
-		Environment: shipment.Name,
-		Barge:       provider.Barge,
-		Endpoint:    endpoint,
-		Status:      shipmentStatus.Status.Phase,
-		Containers:  strconv.Itoa(len(shipment.Containers)),
-		Replicas:    strconv.Itoa(provider.Replicas),
+		Shipment:        name,
+		Environment:     shipment.Name,
+		Barge:           provider.Barge,
+		Endpoint:        endpoint,
+		Status:          shipmentStatus.Status.Phase,
+		Containers:      strconv.Itoa(len(shipment.Containers)),
+		Replicas:        strconv.Itoa(provider.Replicas),
+		IamRole:         shipment.IamRole,
+		ContainerStatus: []ContainerStatusOutput{},
 	}
 
-	//execute the template with the data
-	err = tmpl.Execute(w, shipmentOutput)
-	check(err)
-	w.Flush()
-
-	//format containers
-	fmt.Println("")
-	fmt.Println("")
-
-	fmt.Fprintln(w, "ID\tIMAGE\tSTATUS\tSTARTED\tRESTARTS\tLAST STATE\t")
-
 	for _, container := range shipmentStatus.Status.Containers {
 
 		//get the container state info
@@ -126,18 +148,46 @@ func printShipmentStatus(name string, shipment *ShipmentEnvironment, provider *P
 		}
 
 		//create an object representing data
-		output := ContainerStatusOutput{
+		shipmentOutput.ContainerStatus = append(shipmentOutput.ContainerStatus, ContainerStatusOutput{
 			ID:        container.ID[0:7],
 			Image:     container.Image,
 			Status:    container.Status,
 			Started:   started,
 			Restarts:  strconv.Itoa(container.Restarts),
 			LastState: lastState,
-		}
+		})
+	}
 
-		//create a formatted template
-		tmpl, err := template.New("replicas").Parse("{{.ID}}\t{{.Image}}\t{{.Status}}\t{{.Started}}\t{{.Restarts}}\t{{.LastState}}\t")
-		check(err)
+	return shipmentOutput
+}
+
+func printShipmentStatus(out io.Writer, shipmentOutput ShipmentStatusOutput) {
+
+	const padding = 3
+	w := tabwriter.NewWriter(out, 0, 0, padding, ' ', tabwriter.DiscardEmptyColumns)
+
+	//create a formatted template
+	tmpl, err := template.New("shipment").Parse("SHIPMENT:\t{{.Shipment}}\t\nENVIRONMENT:\t{{.Environment}}\nBARGE:\t{{.Barge}}\t\nENDPOINT:\t{{.Endpoint}}\t\nSTATUS:\t{{.Status}}\t\nCONTAINERS:\t{{.Containers}}\t\nREPLICAS:\t{{.Replicas}}\t{{if .IamRole}}\nIAM ROLE:\t{{.IamRole}}\t{{end}}")
+
+	fmt.Fprintln(w)
+	check(err)
+
+	//execute the template with the data
+	err = tmpl.Execute(w, shipmentOutput)
+	check(err)
+	w.Flush()
+
+	//format containers
+	fmt.Fprintln(out, "")
+	fmt.Fprintln(out, "")
+
+	fmt.Fprintln(w, "ID\tIMAGE\tSTATUS\tSTARTED\tRESTARTS\tLAST STATE\t")
+
+	//create a formatted template
+	tmpl, err = template.New("replicas").Parse("{{.ID}}\t{{.Image}}\t{{.Status}}\t{{.Started}}\t{{.Restarts}}\t{{.LastState}}\t")
+	check(err)
+
+	for _, output := range shipmentOutput.ContainerStatus {
 
 		//execute the template with the data
 		err = tmpl.Execute(w, output)
@@ -147,7 +197,31 @@ func printShipmentStatus(name string, shipment *ShipmentEnvironment, provider *P
 	}
 	w.Flush()
 
-	fmt.Println("-----")
+	fmt.Fprintln(out, "-----")
+}
+
+//looks up the shipment environments to watch
+func getPsWatchSnapshots(username string, token string, shipmentEnvironments []tuple) []psWatchSnapshot {
+	snapshots := []psWatchSnapshot{}
+	for _, t := range shipmentEnvironments {
+		shipmentEnvironment, err := GetShipmentEnvironment(username, token, t.Item1, t.Item2)
+		check(err)
+		if shipmentEnvironment == nil {
+			check(fmt.Errorf("%v %v: %v", t.Item1, t.Item2, messageShipmentEnvironmentNotFound))
+		}
+		provider := ec2Provider(shipmentEnvironment.Providers)
+
+		primaryPort, err := getShipmentPrimaryPort(shipmentEnvironment)
+		check(err)
+
+		snapshots = append(snapshots, psWatchSnapshot{
+			Shipment: t.Item1,
+			Env:      t.Item2,
+			Barge:    provider.Barge,
+			Endpoint: getShipmentEndpoint(t.Item1, t.Item2, provider.Name, primaryPort),
+		})
+	}
+	return snapshots
 }
 
 //returns the primary port of a shipment
@@ -0,0 +1,368 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+//the number of warning events shown for each shipment environment
+const watchWarningEvents = 5
+
+//how often to fetch the replica hosts (which come with the whole log payload) when no new containers appear
+var psWatchHostsInterval = time.Minute
+
+//terminal escape sequences used by the full-screen view
+const (
+	terminalEnterScreen = "\033[?1049h\033[?25l"
+	terminalLeaveScreen = "\033[?25h\033[?1049l"
+	terminalClearScreen = "\033[H\033[2J"
+	terminalHighlight   = "\033[1;33m"
+	terminalWarning     = "\033[31m"
+	terminalReset       = "\033[0m"
+)
+
+//psWatchRow is a container's state in the ps --watch view
+type psWatchRow struct {
+	ID     string
+	Name   string
+	Host   string
+	Image  string
+	Status string
+	Ready  bool
+
+	//the previous status when it changed since the last refresh (e.g., waiting (ContainerCreating))
+	PreviousStatus string
+
+	Restarts int
+
+	//restarts since the watch started
+	RestartDelta int
+
+	//true when anything about the container changed since the last refresh
+	Changed bool
+}
+
+//psWatchEvent is a warning event in the ps --watch view
+type psWatchEvent struct {
+	ShipmentEvent
+
+	//true when the event is new (or happened again) since the last refresh
+	New bool
+}
+
+//psWatchSnapshot is a shipment environment's state at a point in time
+type psWatchSnapshot struct {
+	Shipment string
+	Env      string
+	Barge    string
+	Endpoint string
+	Phase    string
+	Rows     []psWatchRow
+	Warnings []psWatchEvent
+	Err      error
+}
+
+//psWatchState tracks a shipment environment between refreshes so that changes can be highlighted
+type psWatchState struct {
+	refreshed       bool
+	containers      map[string]psWatchRow
+	baseline        map[string]int
+	eventsRefreshed bool
+	events          map[string]int
+
+	//the replica host and name of each container, along with when (and for which containers) they were fetched
+	hosts        map[string]string
+	names        map[string]string
+	hostsFetched time.Time
+	hostsChecked map[string]bool
+}
+
+func newPsWatchState() *psWatchState {
+	return &psWatchState{
+		containers:   map[string]psWatchRow{},
+		baseline:     map[string]int{},
+		events:       map[string]int{},
+		hosts:        map[string]string{},
+		names:        map[string]string{},
+		hostsChecked: map[string]bool{},
+	}
+}
+
+//returns true when the replica hosts should be fetched again, which is when a container appeared
+//since they were last fetched or when they're older than psWatchHostsInterval
+func (s *psWatchState) needsHosts(status *ShipmentStatus, now time.Time) bool {
+	if now.Sub(s.hostsFetched) >= psWatchHostsInterval {
+		return true
+	}
+	for _, container := range status.Status.Containers {
+		if !s.hostsChecked[container.ID] {
+			return true
+		}
+	}
+	return false
+}
+
+//returns a container's status, including why it's waiting or terminated
+func watchContainerStatus(container ContainerStatus) string {
+	if state, ok := container.State[container.Status]; ok && state.Reason != "" {
+		return fmt.Sprintf("%v (%v)", container.Status, state.Reason)
+	}
+	return container.Status
+}
+
+//compares container status with the last refresh
+//hosts maps container ids to the replica host that they run on
+func (s *psWatchState) rows(status *ShipmentStatus, hosts map[string]string, names map[string]string) []psWatchRow {
+	rows := []psWatchRow{}
+	current := map[string]psWatchRow{}
+
+	for _, container := range status.Status.Containers {
+		if _, seen := s.baseline[container.ID]; !seen {
+			s.baseline[container.ID] = container.Restarts
+		}
+
+		row := psWatchRow{
+			ID:           shortID(container.ID),
+			Name:         names[container.ID],
+			Host:         hosts[container.ID],
+			Image:        container.Image,
+			Status:       watchContainerStatus(container),
+			Ready:        container.Ready,
+			Restarts:     container.Restarts,
+			RestartDelta: container.Restarts - s.baseline[container.ID],
+		}
+
+		previous, existed := s.containers[container.ID]
+		if s.refreshed {
+			row.Changed = !existed || previous.Status != row.Status || previous.Ready != row.Ready || previous.Restarts != row.Restarts
+			if existed && previous.Status != row.Status {
+				row.PreviousStatus = previous.Status
+			}
+		}
+
+		current[container.ID] = row
+		rows = append(rows, row)
+	}
+
+	//containers that went away since the last refresh are shown once
+	ids := []string{}
+	for id := range s.containers {
+		if _, ok := current[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		row := s.containers[id]
+		row.PreviousStatus = row.Status
+		row.Status = "gone"
+		row.Ready = false
+		row.Changed = true
+		rows = append(rows, row)
+	}
+
+	s.containers = current
+	s.refreshed = true
+	return rows
+}
+
+//returns the newest warning events, flagging the ones that are new since the last refresh
+func (s *psWatchState) warnings(events []ShipmentEvent) []psWatchEvent {
+	warnings := []ShipmentEvent{}
+	for _, event := range events {
+		if strings.ToLower(event.Type) == "warning" {
+			warnings = append(warnings, event)
+		}
+	}
+	sort.SliceStable(warnings, func(i, j int) bool {
+		return warnings[i].LastTimestamp.After(warnings[j].LastTimestamp)
+	})
+	if len(warnings) > watchWarningEvents {
+		warnings = warnings[:watchWarningEvents]
+	}
+
+	result := []psWatchEvent{}
+	for _, event := range warnings {
+		count, seen := s.events[watchEventKey(event)]
+		result = append(result, psWatchEvent{ShipmentEvent: event, New: s.eventsRefreshed && (!seen || count != event.Count)})
+	}
+
+	//remember every warning (not just the newest) so that older ones aren't flagged as new later
+	for _, event := range events {
+		s.events[watchEventKey(event)] = event.Count
+	}
+	s.eventsRefreshed = true
+
+	return result
+}
+
+//identifies an event across refreshes (the count and last timestamp change when it happens again)
+func watchEventKey(event ShipmentEvent) string {
+	return event.Reason + "|" + event.Message + "|" + event.FirstTimestamp.String()
+}
+
+//fetches the current state of a shipment environment
+//errors are returned in the snapshot so that the view keeps refreshing
+func fetchPsWatchSnapshot(snapshot psWatchSnapshot, state *psWatchState) psWatchSnapshot {
+	ctx := context.Background()
+	helmit := harborClient("", "").Helmit
+
+	status, err := helmit.GetShipmentStatus(ctx, snapshot.Barge, snapshot.Shipment, snapshot.Env)
+	if err != nil {
+		snapshot.Err = err
+		return snapshot
+	}
+	snapshot.Phase = status.Status.Phase
+
+	//the replica hosts come from the logs endpoint
+	if now := time.Now(); state.needsHosts(status, now) {
+		if replicas, err := helmit.GetLogs(ctx, snapshot.Barge, snapshot.Shipment, snapshot.Env); err == nil {
+			state.hosts = map[string]string{}
+			state.names = map[string]string{}
+			for _, replica := range replicas.Replicas {
+				for _, container := range replica.Containers {
+					state.hosts[container.ID] = replica.Host
+					state.names[container.ID] = container.Name
+				}
+			}
+		}
+
+		//containers that aren't in the logs (yet) don't cause a fetch on every refresh
+		state.hostsFetched = now
+		state.hostsChecked = map[string]bool{}
+		for _, container := range status.Status.Containers {
+			state.hostsChecked[container.ID] = true
+		}
+	}
+	snapshot.Rows = state.rows(status, state.hosts, state.names)
+
+	events, err := helmit.GetShipmentEvents(ctx, snapshot.Barge, snapshot.Shipment, snapshot.Env)
+	if err != nil {
+		snapshot.Err = err
+		return snapshot
+	}
+	snapshot.Warnings = state.warnings(events.Events)
+
+	return snapshot
+}
+
+//renders the ps --watch view
+func renderPsWatch(out io.Writer, snapshots []psWatchSnapshot, now time.Time, interval time.Duration, color bool) {
+	highlight := func(line string, style string) string {
+		if !color {
+			return line
+		}
+		return style + line + terminalReset
+	}
+
+	fmt.Fprintf(out, "Every %v: harbor-compose ps --watch    %v    (ctrl-c to exit)\n", interval, now.Format("15:04:05"))
+
+	for _, snapshot := range snapshots {
+		fmt.Fprintln(out)
+		header := []string{snapshot.Shipment + " " + snapshot.Env}
+		for _, value := range []string{snapshot.Barge, snapshot.Phase, snapshot.Endpoint} {
+			if value != "" {
+				header = append(header, value)
+			}
+		}
+		fmt.Fprintln(out, strings.Join(header, "   "))
+		if snapshot.Err != nil {
+			fmt.Fprintln(out, highlight("ERROR: "+snapshot.Err.Error(), terminalWarning))
+			continue
+		}
+		fmt.Fprintln(out)
+
+		//render the table first so that whole lines can be highlighted without affecting alignment
+		var table bytes.Buffer
+		const padding = 3
+		w := tabwriter.NewWriter(&table, 0, 0, padding, ' ', 0)
+		fmt.Fprintln(w, "ID\tCONTAINER\tHOST\tIMAGE\tSTATUS\tREADY\tRESTARTS\t")
+		for _, row := range snapshot.Rows {
+			status := row.Status
+			if row.PreviousStatus != "" {
+				status = row.PreviousStatus + " -> " + row.Status
+			}
+			restarts := fmt.Sprint(row.Restarts)
+			if row.RestartDelta > 0 {
+				restarts = fmt.Sprintf("%v (+%v)", row.Restarts, row.RestartDelta)
+			}
+			fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\t\n", row.ID, row.Name, row.Host, row.Image, status, row.Ready, restarts)
+		}
+		w.Flush()
+
+		lines := strings.Split(strings.TrimSuffix(table.String(), "\n"), "\n")
+		for i, line := range lines {
+			if i > 0 && snapshot.Rows[i-1].Changed {
+				line = highlight(line, terminalHighlight)
+			}
+			fmt.Fprintln(out, line)
+		}
+
+		if len(snapshot.Warnings) > 0 {
+			fmt.Fprintln(out)
+			fmt.Fprintln(out, "WARNINGS:")
+			for _, event := range snapshot.Warnings {
+				line := fmt.Sprintf("  %v  %v - %v (x%v)", humanize.RelTime(event.LastTimestamp, now, "ago", "from now"), event.Reason, event.Message, event.Count)
+				style := terminalWarning
+				if event.New {
+					style = terminalHighlight
+				}
+				fmt.Fprintln(out, highlight(line, style))
+			}
+		}
+	}
+}
+
+//refreshes the status of shipment environments in a full-screen view until interrupted
+//when stdout isn't a terminal, each refresh is printed after the last one (without escape codes)
+func watchPs(snapshots []psWatchSnapshot, interval time.Duration) {
+	states := make([]*psWatchState, len(snapshots))
+	for i := range snapshots {
+		states[i] = newPsWatchState()
+	}
+	tty := terminal.IsTerminal(int(os.Stdout.Fd()))
+
+	//restore the terminal on ctrl-c
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-interrupt
+		if tty {
+			fmt.Print(terminalLeaveScreen)
+		}
+		os.Exit(0)
+	}()
+
+	if tty {
+		fmt.Print(terminalEnterScreen)
+	}
+	for {
+		current := make([]psWatchSnapshot, len(snapshots))
+		for i, snapshot := range snapshots {
+			current[i] = fetchPsWatchSnapshot(snapshot, states[i])
+		}
+
+		//render everything before clearing the screen to avoid flicker
+		var view bytes.Buffer
+		renderPsWatch(&view, current, time.Now(), interval, tty)
+		if tty {
+			fmt.Print(terminalClearScreen + view.String())
+		} else {
+			fmt.Println(view.String())
+		}
+
+		time.Sleep(interval)
+	}
+}
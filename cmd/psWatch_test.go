@@ -0,0 +1,229 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/turnerlabs/harbor-compose/harbor/client"
+	"github.com/turnerlabs/harbor-compose/harbor/mockserver"
+)
+
+func getWatchStatus(containers ...ContainerStatus) *ShipmentStatus {
+	status := &ShipmentStatus{}
+	status.Status.Phase = "Running"
+	status.Status.Containers = containers
+	return status
+}
+
+func TestPsWatchRows(t *testing.T) {
+	state := newPsWatchState()
+	hosts := map[string]string{"aaaaaaa111": "ip-10-0-0-1", "bbbbbbb222": "ip-10-0-0-2"}
+	names := map[string]string{"aaaaaaa111": "web", "bbbbbbb222": "web"}
+
+	//nothing is highlighted on the first refresh
+	rows := state.rows(getWatchStatus(
+		ContainerStatus{ID: "aaaaaaa111", Image: "web:1", Status: "running", Ready: true, Restarts: 3},
+		ContainerStatus{ID: "bbbbbbb222", Image: "web:2", Status: "waiting", State: map[string]ContainerState{"waiting": {Reason: "ContainerCreating"}}},
+	), hosts, names)
+	assert.Equal(t, 2, len(rows))
+	assert.Equal(t, "aaaaaaa", rows[0].ID)
+	assert.Equal(t, "ip-10-0-0-1", rows[0].Host)
+	assert.Equal(t, "web", rows[0].Name)
+	assert.Equal(t, 0, rows[0].RestartDelta)
+	assert.False(t, rows[0].Changed)
+	assert.Equal(t, "waiting (ContainerCreating)", rows[1].Status)
+	assert.False(t, rows[1].Changed)
+
+	//state transitions, restarts and new containers are highlighted
+	rows = state.rows(getWatchStatus(
+		ContainerStatus{ID: "aaaaaaa111", Image: "web:1", Status: "running", Ready: true, Restarts: 4},
+		ContainerStatus{ID: "bbbbbbb222", Image: "web:2", Status: "running", Ready: true},
+		ContainerStatus{ID: "ccccccc333", Image: "web:2", Status: "running"},
+	), hosts, names)
+	assert.Equal(t, 3, len(rows))
+	assert.True(t, rows[0].Changed)
+	assert.Equal(t, 4, rows[0].Restarts)
+	assert.Equal(t, 1, rows[0].RestartDelta)
+	assert.Equal(t, "", rows[0].PreviousStatus)
+	assert.True(t, rows[1].Changed)
+	assert.Equal(t, "waiting (ContainerCreating)", rows[1].PreviousStatus)
+	assert.Equal(t, "running", rows[1].Status)
+	assert.True(t, rows[2].Changed)
+
+	//unchanged containers aren't highlighted and containers that went away are shown once
+	rows = state.rows(getWatchStatus(
+		ContainerStatus{ID: "bbbbbbb222", Image: "web:2", Status: "running", Ready: true},
+		ContainerStatus{ID: "ccccccc333", Image: "web:2", Status: "running"},
+	), hosts, names)
+	assert.Equal(t, 3, len(rows))
+	assert.False(t, rows[0].Changed)
+	assert.Equal(t, "", rows[0].PreviousStatus)
+	assert.False(t, rows[1].Changed)
+	assert.Equal(t, "aaaaaaa", rows[2].ID)
+	assert.Equal(t, "gone", rows[2].Status)
+	assert.Equal(t, "running", rows[2].PreviousStatus)
+	assert.True(t, rows[2].Changed)
+
+	rows = state.rows(getWatchStatus(
+		ContainerStatus{ID: "bbbbbbb222", Image: "web:2", Status: "running", Ready: true},
+		ContainerStatus{ID: "ccccccc333", Image: "web:2", Status: "running"},
+	), hosts, names)
+	assert.Equal(t, 2, len(rows))
+}
+
+func TestPsWatchWarnings(t *testing.T) {
+	state := newPsWatchState()
+	start := time.Now().Add(-time.Hour)
+
+	events := []ShipmentEvent{
+		{Type: "Normal", Reason: "Pulled", Message: "pulled image", FirstTimestamp: start, LastTimestamp: start},
+		{Type: "Warning", Reason: "BackOff", Message: "back-off restarting", Count: 1, FirstTimestamp: start, LastTimestamp: start},
+	}
+	for i := 0; i < watchWarningEvents; i++ {
+		timestamp := start.Add(-time.Duration(i+1) * time.Minute)
+		events = append(events, ShipmentEvent{Type: "Warning", Reason: "Unhealthy", Message: string(rune('a' + i)), Count: 1, FirstTimestamp: timestamp, LastTimestamp: timestamp})
+	}
+
+	//only the newest warnings are shown (and nothing is new on the first refresh)
+	warnings := state.warnings(events)
+	assert.Equal(t, watchWarningEvents, len(warnings))
+	assert.Equal(t, "BackOff", warnings[0].Reason)
+	for _, warning := range warnings {
+		assert.False(t, warning.New)
+	}
+
+	//events that happen again are new
+	events[1].Count = 2
+	events[1].LastTimestamp = time.Now()
+	events = append(events, ShipmentEvent{Type: "Warning", Reason: "Failed", Message: "image pull", Count: 1, FirstTimestamp: start, LastTimestamp: start.Add(time.Minute)})
+	warnings = state.warnings(events)
+	assert.Equal(t, "BackOff", warnings[0].Reason)
+	assert.True(t, warnings[0].New)
+	assert.Equal(t, "Failed", warnings[1].Reason)
+	assert.True(t, warnings[1].New)
+	assert.False(t, warnings[2].New)
+
+	warnings = state.warnings(events)
+	assert.False(t, warnings[0].New)
+	assert.False(t, warnings[1].New)
+}
+
+func TestRenderPsWatch(t *testing.T) {
+	now := time.Date(2017, 5, 1, 12, 0, 0, 0, time.UTC)
+	snapshots := []psWatchSnapshot{
+		{
+			Shipment: "my-app",
+			Env:      "dev",
+			Barge:    "corp-sandbox",
+			Phase:    "Running",
+			Endpoint: "http://my-app.dev.services.ec2.dmtio.net:80",
+			Rows: []psWatchRow{
+				{ID: "aaaaaaa", Name: "web", Host: "ip-10-0-0-1", Image: "web:1", Status: "running", Ready: true, Restarts: 4, RestartDelta: 1, Changed: true},
+				{ID: "bbbbbbb", Name: "web", Host: "ip-10-0-0-2", Image: "web:2", Status: "running", PreviousStatus: "waiting (ContainerCreating)", Changed: true},
+				{ID: "ccccccc", Name: "web", Host: "ip-10-0-0-3", Image: "web:2", Status: "running", Ready: true},
+			},
+			Warnings: []psWatchEvent{
+				{ShipmentEvent: ShipmentEvent{Reason: "BackOff", Message: "back-off restarting", Count: 2, LastTimestamp: now.Add(-5 * time.Minute)}, New: true},
+			},
+		},
+		{
+			Shipment: "my-api",
+			Env:      "dev",
+			Err:      errors.New("barge not found"),
+		},
+	}
+
+	var out bytes.Buffer
+	renderPsWatch(&out, snapshots, now, 2*time.Second, false)
+	view := out.String()
+
+	assert.Contains(t, view, "Every 2s: harbor-compose ps --watch    12:00:00")
+	assert.Contains(t, view, "my-app dev   corp-sandbox   Running   http://my-app.dev.services.ec2.dmtio.net:80")
+	assert.Contains(t, view, "ip-10-0-0-1")
+	assert.Contains(t, view, "4 (+1)")
+	assert.Contains(t, view, "waiting (ContainerCreating) -> running")
+	assert.Contains(t, view, "  5 minutes ago  BackOff - back-off restarting (x2)")
+	assert.Contains(t, view, "my-api dev\nERROR: barge not found")
+	assert.NotContains(t, view, "\033[")
+
+	//changed rows and new events are highlighted
+	out.Reset()
+	renderPsWatch(&out, snapshots, now, 2*time.Second, true)
+	lines := strings.Split(out.String(), "\n")
+	highlighted := []string{}
+	for _, line := range lines {
+		if strings.HasPrefix(line, terminalHighlight) {
+			highlighted = append(highlighted, line)
+		}
+	}
+	assert.Equal(t, 3, len(highlighted))
+	assert.True(t, strings.Contains(highlighted[0], "aaaaaaa"))
+	assert.True(t, strings.Contains(highlighted[1], "bbbbbbb"))
+	assert.True(t, strings.Contains(highlighted[2], "BackOff"))
+}
+
+func TestPsWatchNeedsHosts(t *testing.T) {
+	state := newPsWatchState()
+	now := time.Now()
+	status := getWatchStatus(ContainerStatus{ID: "aaaaaaa111"})
+	assert.True(t, state.needsHosts(status, now))
+
+	state.hostsFetched = now
+	state.hostsChecked = map[string]bool{"aaaaaaa111": true}
+	assert.False(t, state.needsHosts(status, now.Add(time.Second)))
+
+	//a new container
+	assert.True(t, state.needsHosts(getWatchStatus(ContainerStatus{ID: "aaaaaaa111"}, ContainerStatus{ID: "bbbbbbb222"}), now.Add(time.Second)))
+
+	//the hosts are old
+	assert.True(t, state.needsHosts(status, now.Add(psWatchHostsInterval)))
+}
+
+func TestFetchPsWatchSnapshotCachesHosts(t *testing.T) {
+	var mu sync.Mutex
+	logRequests := 0
+	count := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return logRequests
+	}
+	mock := mockserver.New(&mockserver.Seed{
+		Status: map[string]client.ShipmentStatus{"my-app/dev": *getWatchStatus(ContainerStatus{ID: "aaaaaaa111", Image: "registry/web:1.0", Status: "running"})},
+		Logs: map[string]client.HelmitResponse{
+			"my-app/dev": {Replicas: []client.HelmitReplica{{Host: "ip-10-0-0-1", Containers: []client.HelmitContainer{{Name: "web", ID: "aaaaaaa111"}}}}},
+		},
+	})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/harbor/") {
+			mu.Lock()
+			logRequests++
+			mu.Unlock()
+		}
+		mock.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	defer useMockHarbor(server)()
+
+	state := newPsWatchState()
+	snapshot := psWatchSnapshot{Shipment: "my-app", Env: "dev", Barge: "corp-sandbox"}
+	for i := 0; i < 3; i++ {
+		result := fetchPsWatchSnapshot(snapshot, state)
+		assert.Nil(t, result.Err)
+		assert.Equal(t, "ip-10-0-0-1", result.Rows[0].Host)
+		assert.Equal(t, "web", result.Rows[0].Name)
+	}
+	assert.Equal(t, 1, count())
+
+	//until they're old
+	defer func(original time.Duration) { psWatchHostsInterval = original }(psWatchHostsInterval)
+	psWatchHostsInterval = 0
+	fetchPsWatchSnapshot(snapshot, state)
+	assert.Equal(t, 2, count())
+}
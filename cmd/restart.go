@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -17,22 +18,27 @@ var restartCmd = &cobra.Command{
 }
 
 func init() {
+	addEnvFlag(restartCmd)
+	addParallelFlag(restartCmd)
 	RootCmd.AddCommand(restartCmd)
 }
 
 // restart your shipment
 func restart(cmd *cobra.Command, args []string) {
+	check(validateParallel(false))
 
 	//make sure user is authenticated
 	username, token, err := Login()
 	check(err)
 
 	//read the harbor compose file
-	harborCompose := DeserializeHarborCompose(HarborComposeFile)
+	harborCompose := loadHarborCompose()
 
 	//iterate shipments
-	for shipmentName, shipment := range harborCompose.Shipments {
-		fmt.Printf("Restarting %v %v ...\n", shipmentName, shipment.Env)
+	runShipments(composeShipmentTasks(harborCompose), func(out io.Writer, task shipmentTask) error {
+		shipmentName := task.Shipment
+		shipment := task.Config
+		fmt.Fprintf(out, "Restarting %v %v ...\n", shipmentName, shipment.Env)
 
 		t := time.Now()
 		time := username + "_" + t.Format("20060102150405")
@@ -44,12 +50,16 @@ func restart(cmd *cobra.Command, args []string) {
 		}
 
 		//update env var
-		SaveEnvVar(username, token, shipmentName, shipment.Env, envVar, shipment.Containers[0])
+		if err := SaveEnvVar(username, token, shipmentName, shipment.Env, envVar, shipment.Containers[0]); err != nil {
+			return err
+		}
 
 		//trigger
-		Trigger(shipmentName, shipment.Env)
-
-		fmt.Println("done")
+		if _, _, err := Trigger(shipmentName, shipment.Env); err != nil {
+			return err
+		}
 
-	} //shipments
+		fmt.Fprintln(out, "done")
+		return nil
+	})
 }
@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/spf13/cobra"
+)
+
+// rollbackCmd represents the rollback command
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback",
+	Short: "Restore a recorded revision of your shipment environments",
+	Long: `Restore a recorded revision of your shipment environments
+
+The rollback command restores the container images, environment variables and replicas that were recorded in a revision (see the history command) and then triggers the shipment.  By default, the most recent revision is restored, which undoes the last change made by up, deploy or rollback.  Use --to to restore a specific revision.
+
+The current state is recorded as a new revision before rolling back, so a rollback can itself be rolled back.  Note that environment variables that were added after a revision was recorded are not removed.`,
+	Example: `harbor-compose rollback
+harbor-compose rollback --to 3
+harbor-compose rollback -s my-shipment -e dev --to 3`,
+	Run:    rollback,
+	PreRun: preRunHook,
+}
+
+var rollbackShipment string
+var rollbackEnvironment string
+var rollbackTo int
+
+func init() {
+	rollbackCmd.PersistentFlags().StringVarP(&rollbackShipment, "shipment", "s", "", "shipment name")
+	rollbackCmd.PersistentFlags().StringVarP(&rollbackEnvironment, "environment", "e", "", "environment name")
+	rollbackCmd.PersistentFlags().IntVarP(&rollbackTo, "to", "", 0, "the revision to restore (defaults to the most recent)")
+	RootCmd.AddCommand(rollbackCmd)
+}
+
+func rollback(cmd *cobra.Command, args []string) {
+
+	//make sure user is authenticated
+	username, token, err := Login()
+	check(err)
+
+	//determine which shipment/environments user wants to roll back
+	inputShipmentEnvironments, _ := getShipmentEnvironmentsFromInput(rollbackShipment, rollbackEnvironment)
+
+	for _, t := range inputShipmentEnvironments {
+		shipment := t.Item1
+		env := t.Item2
+
+		dir, err := historyDir(shipment, env)
+		check(err)
+		revisions, err := readRevisions(dir)
+		check(err)
+
+		target, err := findRevision(revisions, rollbackTo)
+		check(err)
+
+		current, err := GetShipmentEnvironment(username, token, shipment, env)
+		check(err)
+		if current == nil {
+			check(errors.New(messageShipmentEnvironmentNotFound))
+		}
+
+		fmt.Printf("Rolling back %v %v to revision %v ...\n", shipment, env, target.Version)
+
+		//allow the rollback itself to be rolled back
+		recordRevision(current, username, "rollback")
+
+		restoreRevision(username, token, current, *target)
+
+		//trigger shipment
+		_, messages, err := Trigger(shipment, env)
+		check(err)
+		for _, msg := range messages {
+			fmt.Println(msg)
+		}
+
+		fmt.Println("done")
+	}
+}
+
+//returns the requested revision, or the most recent one if version is 0
+func findRevision(revisions []revision, version int) (*revision, error) {
+	if len(revisions) == 0 {
+		return nil, errors.New("no revisions found, see the history command")
+	}
+	if version == 0 {
+		return &revisions[len(revisions)-1], nil
+	}
+	for i := range revisions {
+		if revisions[i].Version == version {
+			return &revisions[i], nil
+		}
+	}
+	return nil, fmt.Errorf("revision %v not found, see the history command", version)
+}
+
+//applies the differences between a shipment environment's current state and a revision
+func restoreRevision(username string, token string, current *ShipmentEnvironment, target revision) {
+	shipment := current.ParentShipment.Name
+	env := current.Name
+
+	envVarChanges := []envVarChange{}
+
+	for _, container := range target.Containers {
+		currentContainer := findContainer(container.Name, current.Containers)
+		if currentContainer == nil {
+			log.Printf("WARNING: container %v no longer exists, skipping", container.Name)
+			continue
+		}
+
+		if container.Image != currentContainer.Image {
+			if Verbose {
+				log.Printf("restoring %v image %v", container.Name, container.Image)
+			}
+			check(UpdateContainerImage(username, token, shipment, env, ContainerPayload{
+				Name:  container.Name,
+				Image: container.Image,
+			}))
+		}
+
+		envVarChanges = append(envVarChanges, restoreEnvVars(container.Name, currentContainer.EnvVars, container.EnvVars)...)
+	}
+
+	envVarChanges = append(envVarChanges, restoreEnvVars("", current.EnvVars, target.EnvVars)...)
+	check(syncEnvVars(username, token, shipment, env, envVarChanges))
+
+	provider := ec2Provider(current.Providers)
+	if provider.Replicas != target.Replicas {
+		if Verbose {
+			log.Printf("restoring replicas %v", target.Replicas)
+		}
+		check(UpdateProvider(username, token, shipment, env, ProviderPayload{
+			Name:     provider.Name,
+			Replicas: target.Replicas,
+		}))
+	}
+}
+
+//returns the env var changes needed to restore a revision (container is empty for environment-level env vars)
+func restoreEnvVars(container string, current []EnvVarPayload, target []EnvVarPayload) []envVarChange {
+	currentVars := envVarsByName(current)
+	targetVars := envVarsByName(target)
+
+	names := []string{}
+	for name := range currentVars {
+		if _, ok := targetVars[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		log.Printf("WARNING: env var %v was added after this revision and was not removed", name)
+	}
+
+	return diffEnvVarChanges(container, current, target)
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 // RootCmd represents the base command when called without any subcommands
@@ -17,6 +18,13 @@ var RootCmd = &cobra.Command{
 
 func preRunHook(cmd *cobra.Command, args []string) {
 	currentCommand = getCommandPath(cmd)
+
+	check(validateOutput())
+
+	//the first harbor compose file is the one that gets written to
+	if len(HarborComposeFiles) > 0 {
+		HarborComposeFile = HarborComposeFiles[0]
+	}
 }
 
 // CommandPath returns the full path to this command.
@@ -47,7 +55,13 @@ var Verbose bool
 var DockerComposeFile string
 
 // HarborComposeFile represents the harbor-compose.yml file
-var HarborComposeFile string
+var HarborComposeFile = defaultHarborComposeFile
+
+// ComposeEnvironment selects which of the shipment environments in the harbor compose file to use
+var ComposeEnvironment string
+
+// HarborComposeFiles are the harbor compose files that get merged together, in order
+var HarborComposeFiles []string
 
 //currently executing command
 var currentCommand string
@@ -59,6 +73,21 @@ func setCurrentUser(user string) {
 	currentUser = user
 }
 
+//adds the --env flag that selects a shipment environment from the harbor compose file
+func addEnvFlag(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVarP(&ComposeEnvironment, "env", "e", "", "the shipment environment to use from the harbor compose file")
+}
+
+//accepts --env as an alias for --environment, so that the same selector works on every command
+func addEnvAlias(cmd *cobra.Command) {
+	cmd.SetGlobalNormalizationFunc(func(f *pflag.FlagSet, name string) pflag.NormalizedName {
+		if name == "env" {
+			name = "environment"
+		}
+		return pflag.NormalizedName(name)
+	})
+}
+
 // Execute adds all child commands to the root command sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute(version string, buildDate string) {
@@ -74,5 +103,5 @@ func Execute(version string, buildDate string) {
 func init() {
 	RootCmd.PersistentFlags().BoolVarP(&Verbose, "verbose", "v", false, "Show more output")
 	RootCmd.PersistentFlags().StringVarP(&DockerComposeFile, "file", "f", "docker-compose.yml", "Specify an alternate docker compose file")
-	RootCmd.PersistentFlags().StringVarP(&HarborComposeFile, "harbor-file", "c", "harbor-compose.yml", "Specify an alternate harbor compose file")
+	RootCmd.PersistentFlags().StringArrayVarP(&HarborComposeFiles, "harbor-file", "c", []string{defaultHarborComposeFile}, "Specify an alternate harbor compose file (can be repeated to merge files in order)")
 }
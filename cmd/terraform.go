@@ -50,7 +50,8 @@ func terraform(cmd *cobra.Command, args []string) {
 	if Verbose {
 		log.Printf("fetching shipment...")
 	}
-	shipmentObject := GetShipmentEnvironment(username, token, shipment, env)
+	shipmentObject, err := GetShipmentEnvironment(username, token, shipment, env)
+	check(err)
 	if shipmentObject == nil {
 		fmt.Println(messageShipmentEnvironmentNotFound)
 		return
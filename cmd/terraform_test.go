@@ -276,3 +276,36 @@ func TestGenerateTerraformSourceCodeLogShipping(t *testing.T) {
 	assert.Contains(t, tf, fmt.Sprintf("aws_region = \"%v\"", region))
 	assert.Contains(t, tf, fmt.Sprintf("sqs_queue_name = \"%v\"", queueName))
 }
+
+func TestGenerateTerraformSourceCodeLoadBalancer(t *testing.T) {
+
+	harborComposeYaml := `
+shipments:
+  mss-poc-app:
+    env: dev
+    barge: digital-sandbox
+    containers:
+    - web
+    replicas: 2
+    loadBalancer:
+      type: alb
+      public: true
+      https:
+        certificateArn: arn:aws:acm:us-east-1:123456789012:certificate/abc
+`
+
+	//the shipment environment that up would create
+	shipmentEnv := getPortsTestShipment(t, harborComposeYaml)
+	harborCompose := transformShipmentToHarborCompose(&shipmentEnv)
+	data := getTerraformData(&shipmentEnv, &harborCompose)
+
+	//generate code
+	tf := generateTerraformSourceCode(data)
+	t.Log(tf)
+
+	assert.Contains(t, tf, `loadbalancer = "alb"`)
+	assert.Contains(t, tf, `protocol              = "https"`)
+	assert.Contains(t, tf, `public                = true`)
+	assert.Contains(t, tf, `ssl_management_type   = "acm"`)
+	assert.Contains(t, tf, `ssl_arn               = "arn:aws:acm:us-east-1:123456789012:certificate/abc"`)
+}
@@ -1,5 +1,11 @@
 package cmd
 
+import (
+	"time"
+
+	"github.com/turnerlabs/harbor-compose/harbor/client"
+)
+
 // AuthRequest represents an authentication request
 type AuthRequest struct {
 	User string `json:"username,omitempty"`
@@ -19,21 +25,62 @@ type HarborCompose struct {
 
 // ComposeShipment represents a harbor shipment in a harbor-compose.yml file
 type ComposeShipment struct {
-	Env                        string            `yaml:"env"`
+	Env                        string                    `yaml:"env"`
+	Barge                      string                    `yaml:"barge,omitempty"`
+	Containers                 []string                  `yaml:"containers"`
+	Replicas                   int                       `yaml:"replicas,omitempty"`
+	Group                      string                    `yaml:"group,omitempty"`
+	Property                   string                    `yaml:"property,omitempty"`
+	Project                    string                    `yaml:"project,omitempty"`
+	Product                    string                    `yaml:"product,omitempty"`
+	Environment                map[string]string         `yaml:"environment,omitempty"`
+	IgnoreImageVersion         bool                      `yaml:"ignoreImageVersion,omitempty"`
+	EnableMonitoring           *bool                     `yaml:"enableMonitoring,omitempty"`
+	HealthcheckTimeoutSeconds  *int                      `yaml:"healthcheckTimeoutSeconds,omitempty"`
+	HealthcheckIntervalSeconds *int                      `yaml:"healthcheckIntervalSeconds,omitempty"`
+	IamRole                    string                    `yaml:"iamRole,omitempty"`
+	Ports                      map[string][]ComposePort  `yaml:"ports,omitempty"`
+	LoadBalancer               *ComposeLoadBalancer      `yaml:"loadBalancer,omitempty"`
+	Environments               map[string]ComposeProfile `yaml:"environments,omitempty"`
+	DependsOn                  []string                  `yaml:"dependsOn,omitempty"`
+	Links                      []string                  `yaml:"links,omitempty"`
+}
+
+// ComposeProfile represents the per-environment settings of a shipment in a harbor-compose.yml file
+type ComposeProfile struct {
 	Barge                      string            `yaml:"barge,omitempty"`
-	Containers                 []string          `yaml:"containers"`
-	Replicas                   int               `yaml:"replicas,omitempty"`
-	Group                      string            `yaml:"group,omitempty"`
-	Property                   string            `yaml:"property,omitempty"`
-	Project                    string            `yaml:"project,omitempty"`
-	Product                    string            `yaml:"product,omitempty"`
+	Replicas                   *int              `yaml:"replicas,omitempty"`
 	Environment                map[string]string `yaml:"environment,omitempty"`
-	IgnoreImageVersion         bool              `yaml:"ignoreImageVersion,omitempty"`
-	EnableMonitoring           *bool             `yaml:"enableMonitoring,omitempty"`
+	IgnoreImageVersion         *bool             `yaml:"ignoreImageVersion,omitempty"`
 	HealthcheckTimeoutSeconds  *int              `yaml:"healthcheckTimeoutSeconds,omitempty"`
 	HealthcheckIntervalSeconds *int              `yaml:"healthcheckIntervalSeconds,omitempty"`
 }
 
+// ComposeLoadBalancer represents the load balancer settings in a harbor-compose.yml file
+type ComposeLoadBalancer struct {
+	Type   string        `yaml:"type,omitempty"`
+	Public *bool         `yaml:"public,omitempty"`
+	HTTPS  *ComposeHTTPS `yaml:"https,omitempty"`
+}
+
+// ComposeHTTPS represents the certificate used by a load balancer's HTTPS listener
+type ComposeHTTPS struct {
+	CertificateArn string `yaml:"certificateArn,omitempty"`
+	IamCertificate string `yaml:"iamCertificate,omitempty"`
+}
+
+// ComposePort represents the harbor settings for a container's docker-compose port mapping
+type ComposePort struct {
+	Port                int    `yaml:"port"`
+	Name                string `yaml:"name,omitempty"`
+	Protocol            string `yaml:"protocol,omitempty"`
+	Healthcheck         string `yaml:"healthcheck,omitempty"`
+	Primary             *bool  `yaml:"primary,omitempty"`
+	External            *bool  `yaml:"external,omitempty"`
+	PublicVip           *bool  `yaml:"publicVip,omitempty"`
+	EnableProxyProtocol bool   `yaml:"enableProxyProtocol,omitempty"`
+}
+
 // data used for rendering terraform source
 type terraformShipmentEnvironment struct {
 	Shipment           string
@@ -135,155 +182,98 @@ type DockerComposeService struct {
 }
 
 // ShipmentEnvironment represents a shipment/environment combination
-type ShipmentEnvironment struct {
-	Username         string             `json:"username"`
-	Token            string             `json:"token"`
-	Name             string             `json:"name"`
-	EnvVars          []EnvVarPayload    `json:"envVars"`
-	Containers       []ContainerPayload `json:"containers"`
-	Providers        []ProviderPayload  `json:"providers"`
-	ParentShipment   ParentShipment     `json:"parentShipment"`
-	BuildToken       string             `json:"buildToken,omitempty"`
-	EnableMonitoring bool               `json:"enableMonitoring"`
-	IamRole          string             `json:"iamRole"`
-}
+type ShipmentEnvironment = client.ShipmentEnvironment
 
-// The ParentShipment of the shipmentModel
-type ParentShipment struct {
-	Name    string          `json:"name"`
-	EnvVars []EnvVarPayload `json:"envVars"`
-	Group   string          `json:"group"`
-}
+// ParentShipment is the parent shipment of a shipment environment
+type ParentShipment = client.ParentShipment
 
 // EnvVarPayload represents EnvVar
-type EnvVarPayload struct {
-	Name  string `json:"name"`
-	Value string `json:"value"`
-	Type  string `json:"type,omitempty"`
-}
+type EnvVarPayload = client.EnvVarPayload
 
 // PortPayload represents a port
-type PortPayload struct {
-	Name                string `json:"name,omitempty"`
-	Value               int    `json:"value,omitempty"`
-	Protocol            string `json:"protocol,omitempty"`
-	Healthcheck         string `json:"healthcheck,omitempty"`
-	Primary             bool   `json:"primary,omitempty"`
-	External            bool   `json:"external,omitempty"`
-	PublicVip           bool   `json:"public_vip,omitempty"`
-	PublicPort          int    `json:"public_port,omitempty"`
-	EnableProxyProtocol bool   `json:"enable_proxy_protocol,omitempty"`
-	SslArn              string `json:"ssl_arn,omitempty"`
-	SslManagementType   string `json:"ssl_management_type,omitempty"`
-	HealthcheckTimeout  *int   `json:"healthcheck_timeout,omitempty"`
-	HealthcheckInterval *int   `json:"healthcheck_interval,omitempty"`
-	LBType              string `json:"lbtype,omitempty"`
-}
+type PortPayload = client.PortPayload
 
 // ContainerPayload represents a container payload
-type ContainerPayload struct {
-	Name    string          `json:"name,omitempty"`
-	Image   string          `json:"image,omitempty"`
-	EnvVars []EnvVarPayload `json:"envVars,omitempty"`
-	Ports   []PortPayload   `json:"ports,omitempty"`
-}
+type ContainerPayload = client.ContainerPayload
 
 // ProviderPayload represents a provider payload
-type ProviderPayload struct {
-	Name     string          `json:"name"`
-	Replicas int             `json:"replicas"`
-	EnvVars  []EnvVarPayload `json:"envVars,omitempty"`
-	Barge    string          `json:"barge,omitempty"`
+type ProviderPayload = client.ProviderPayload
+
+// ContainerStatusOutput represents an object that can be written to stdout and formatted
+type ContainerStatusOutput struct {
+	ID        string `json:"id"`
+	Image     string `json:"image"`
+	Started   string `json:"started"`
+	Status    string `json:"status"`
+	Restarts  string `json:"restarts"`
+	LastState string `json:"lastState"`
 }
 
-// TriggerResponseSingle is the payload returned from the trigger api
-type TriggerResponseSingle struct {
-	Message string `json:"message,omitempty"`
+// ShipmentStatusOutput represents an object that can be written to stdout and formatted
+type ShipmentStatusOutput struct {
+	Shipment        string                  `json:"shipment"`
+	Environment     string                  `json:"environment"`
+	Barge           string                  `json:"barge"`
+	Status          string                  `json:"status"`
+	Containers      string                  `json:"containers"`
+	Replicas        string                  `json:"replicas"`
+	Endpoint        string                  `json:"endpoint"`
+	IamRole         string                  `json:"iamRole,omitempty"`
+	ContainerStatus []ContainerStatusOutput `json:"containerStatus"`
 }
 
-// TriggerResponseMultiple is the payload returned from the trigger api
-type TriggerResponseMultiple struct {
-	Messages []string `json:"message,omitempty"`
+// ShipmentEventOutput represents an event that can be written to stdout and formatted
+type ShipmentEventOutput struct {
+	Shipment    string `json:"shipment"`
+	Environment string `json:"environment"`
+	ShipmentEvent
 }
 
-// ContainerStatusOutput represents an object that can be written to stdout and formatted
-type ContainerStatusOutput struct {
-	ID        string
-	Image     string
-	Started   string
-	Status    string
-	Restarts  string
-	LastState string
+// EnvVarOutput represents an env var that can be written to stdout and formatted
+type EnvVarOutput struct {
+	Shipment    string `json:"shipment"`
+	Environment string `json:"environment"`
+
+	//empty for environment-level env vars
+	Container string `json:"container,omitempty"`
+	EnvVarPayload
 }
 
-//ShipmentStatusOutput represents an object that can be written to stdout and formatted
-type ShipmentStatusOutput struct {
-	Shipment    string
-	Environment string
-	Barge       string
-	Status      string
-	Containers  string
-	Replicas    string
-	Endpoint    string
+// LogOutput represents a container log line that can be written to stdout and formatted
+type LogOutput struct {
+	Shipment    string    `json:"shipment"`
+	Environment string    `json:"environment"`
+	Container   string    `json:"container"`
+	ID          string    `json:"id"`
+	Image       string    `json:"image"`
+	Host        string    `json:"host"`
+	Time        time.Time `json:"time"`
+	Log         string    `json:"log"`
+
+	//the fields of logs that are json objects (which are merged into the json output)
+	Fields map[string]interface{} `json:"-"`
 }
 
 // CatalogitContainer is what gets sent to catalog to post a new image
-type CatalogitContainer struct {
-	Name    string `json:"name"`
-	Image   string `json:"image"`
-	Version string `json:"version"`
-}
+type CatalogitContainer = client.CatalogitContainer
 
 // DeployRequest represents a request to deploy a shipment/container to an environment
-type DeployRequest struct {
-	Name    string `json:"name"`
-	Image   string `json:"image"`
-	Version string `json:"version"`
-	Catalog bool   `json:"catalog"`
-}
+type DeployRequest = client.DeployRequest
 
 // UpdateShipmentEnvironmentRequest represents a request to update a shipment/environment
-type UpdateShipmentEnvironmentRequest struct {
-	EnableMonitoring bool `json:"enableMonitoring"`
-}
+type UpdateShipmentEnvironmentRequest = client.UpdateShipmentEnvironmentRequest
 
 // UpdatePortRequest represents a request to update a port
-type UpdatePortRequest struct {
-	Name                string `json:"name"`
-	HealthcheckTimeout  *int   `json:"healthcheck_timeout,omitempty"`
-	HealthcheckInterval *int   `json:"healthcheck_interval,omitempty"`
-}
+type UpdatePortRequest = client.UpdatePortRequest
 
 // BargeResults represents a barge payload
-type BargeResults struct {
-	Barges []Barge `json:"barges"`
-}
+type BargeResults = client.BargeResults
 
 // Barge represents a harbor barge
-type Barge struct {
-	Name           string   `json:"name"`
-	AccountID      string   `json:"accountId"`
-	AccountName    string   `json:"accountName"`
-	Vpc            string   `json:"vpc"`
-	PrivateSubnets []string `json:"privateSubnets"`
-	PublicSubnets  []string `json:"publicSubnets"`
-}
+type Barge = client.Barge
 
 // Group represents a harbor group
-type Group struct {
-	ID     string   `json:"id"`
-	Users  []string `json:"users"`
-	Admins []string `json:"admins"`
-}
+type Group = client.Group
 
 // LoadBalancer represents a harbor load balancer
-type LoadBalancer struct {
-	Name                  string `json:"name"`
-	Type                  string `json:"type"`
-	Public                bool   `json:"public"`
-	ARN                   string `json:"arn"`
-	DNSName               string `json:"dnsName"`
-	CanonicalHostedZoneID string `json:"canonicalHostedZoneId"`
-	VpcID                 string `json:"vpcId"`
-	State                 string `json:"state"`
-}
+type LoadBalancer = client.LoadBalancer
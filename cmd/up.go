@@ -6,15 +6,18 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"os"
-	"strconv"
+	"regexp"
+	"time"
 
 	"github.com/docker/libcompose/project"
 	"github.com/spf13/cobra"
 
 	"strings"
+	"sync"
 )
 
 // upCmd represents the up command
@@ -26,16 +29,56 @@ var upCmd = &cobra.Command{
 The up command applies changes from your docker/harbor compose files and brings your application up on Harbor.  The up command:
 
 - Creates Harbor shipments if needed
+- Adds containers that are new to harbor-compose.yml (along with their ports and environment variables)
 - Updates container and shipment/environment level environment variables
 - Updates and catalogs container images
 - Updates container replicas
 - Triggers your shipments
+
+Use the --plan flag to preview the changes that up would make without changing anything.  The command exits with a status of 2 when changes are pending.
+
+Use the --wait flag to block until every container is running the desired image and is ready.  While waiting, progress is printed as it changes.  The command fails with a non-zero exit status (and prints relevant warning events and container termination reasons) if containers are crash looping, images can't be pulled, containers keep restarting, or the --timeout is reached.
+
+Use the --prune flag to delete container and shipment/environment level environment variables that exist in Harbor but no longer exist in your compose files (or hidden.env).  Reserved environment variables (e.g., BARGE, PRODUCT, LOGS_ENDPOINT, etc.) are never deleted.  You'll be asked to confirm the deletes unless --yes is specified.
+
+Containers that are removed from the containers list in harbor-compose.yml are only removed from Harbor when the --remove-orphans flag is specified.  The container with the primary port can't be removed.
+
+Shipments that list other shipments in links (or whose containers have docker compose links/depends_on to containers in other shipments) get an env var (e.g., API_URL) that is set to the linked shipment's endpoint (preferring its load balancer's DNS name).
+
+Shipments that list other shipments in dependsOn (or links) are brought up after those shipments are healthy.  Shipments are grouped into tiers by their dependencies and each tier waits (up to --timeout) for its shipments to become healthy before the next tier starts.  Shipments whose dependencies fail are skipped.
+
+A failing shipment doesn't stop the others.  Once every shipment has been processed, a summary of the results is printed and the command exits with a non-zero status if any of them failed.
+
+Use the --parallel flag to process multiple shipments (in the same tier) at the same time.  Each shipment's output is printed (prefixed with the shipment and environment) when it finishes.
 	`,
+	Example: `harbor-compose up
+harbor-compose up --plan
+harbor-compose up --wait
+harbor-compose up --wait --timeout 10m
+harbor-compose up --prune --plan
+harbor-compose up --prune --yes
+harbor-compose up --remove-orphans --plan
+harbor-compose up --parallel 4`,
 	Run:    up,
 	PreRun: preRunHook,
 }
 
+var upPlan bool
+var upWait bool
+var upTimeout time.Duration
+var upPrune bool
+var upYes bool
+var upRemoveOrphans bool
+
 func init() {
+	upCmd.PersistentFlags().BoolVarP(&upPlan, "plan", "", false, "show the changes that would be made without making them")
+	upCmd.PersistentFlags().BoolVarP(&upWait, "wait", "", false, "wait for containers to be running the desired images and ready")
+	upCmd.PersistentFlags().DurationVarP(&upTimeout, "timeout", "", 5*time.Minute, "how long to --wait before failing")
+	upCmd.PersistentFlags().BoolVarP(&upPrune, "prune", "", false, "delete env vars that no longer exist in your compose files")
+	upCmd.PersistentFlags().BoolVarP(&upYes, "yes", "y", false, "don't ask for confirmation before deleting env vars")
+	upCmd.PersistentFlags().BoolVarP(&upRemoveOrphans, "remove-orphans", "", false, "remove containers that are no longer listed in harbor-compose.yml")
+	addEnvFlag(upCmd)
+	addParallelFlag(upCmd)
 	RootCmd.AddCommand(upCmd)
 }
 
@@ -43,6 +86,20 @@ var successMessage = "Please allow up to 5 minutes for Load Balancer and DNS cha
 
 const healthCheckEnvVarName = "HEALTHCHECK"
 
+//matches IAM role ARNs (arn:aws:iam::<account>:role/<path/name>)
+var iamRoleArnPattern = regexp.MustCompile(`^arn:aws:iam::\d{12}:role/[\w+=,.@/-]+$`)
+
+//exit code used by --plan when changes are pending
+const exitCodePlanPending = 2
+
+//returns the plan options that correspond to the up flags
+func upPlanOptions() planOptions {
+	return planOptions{
+		Prune:         upPrune,
+		RemoveOrphans: upRemoveOrphans,
+	}
+}
+
 func up(cmd *cobra.Command, args []string) {
 
 	//make sure user is authenticated
@@ -50,48 +107,123 @@ func up(cmd *cobra.Command, args []string) {
 	check(err)
 
 	//read the compose files
-	dockerCompose, harborCompose := unmarshalComposeFiles(DockerComposeFile, HarborComposeFile)
+	dockerCompose, harborCompose := unmarshalComposeFiles(DockerComposeFile)
 
-	//iterate shipments
+	//track whether --plan found any changes
+	pending := false
+	var pendingLock sync.Mutex
+
+	check(validateParallel(upPrune && !upYes && !upPlan))
+
+	//shipments are processed in dependency order (including the shipments they link to)
+	addLinkDependencies(&harborCompose, dockerCompose)
+	tiers, err := composeShipmentTiers(harborCompose)
+	check(err)
+
+	//transform compose yaml into desired NewShipmentEnvironment objects (so that compose errors are reported before anything changes)
+	desiredShipments := map[string]ShipmentEnvironment{}
 	for shipmentName, shipment := range harborCompose.Shipments {
+		desiredShipments[shipmentName] = transformComposeToShipmentEnvironment(shipmentName, shipment, dockerCompose)
+	}
+
+	//iterate shipments
+	runShipmentTiers(tiers, func(out io.Writer, task shipmentTask) error {
+		shipmentName, shipment := task.Shipment, task.Config
 		if Verbose {
 			log.Printf("processing shipment: %v/%v", shipmentName, shipment.Env)
 		}
 
 		//fetch the current state
-		existingShipment := GetShipmentEnvironment(username, token, shipmentName, shipment.Env)
+		existingShipment, err := GetShipmentEnvironment(username, token, shipmentName, shipment.Env)
+		if err != nil {
+			return err
+		}
+
+		desiredShipment := desiredShipments[shipmentName]
+
+		//an existing primary port stays put unless harbor-compose.yml says otherwise
+		if existingShipment != nil && !hasExplicitPrimaryPort(shipment) {
+			keepPrimaryPort(shipment, &desiredShipment, existingShipment)
+		}
 
-		//transform compose yaml into a desired NewShipmentEnvironment object
-		desiredShipment := transformComposeToShipmentEnvironment(shipmentName, shipment, dockerCompose)
+		//set env vars to the endpoints of linked shipments
+		links := getShipmentLinks(harborCompose, shipmentName, dockerCompose)
+		if err := applyShipmentLinks(&desiredShipment, links, upLinkEndpoint(username, token, harborCompose)); err != nil {
+			return err
+		}
 
 		//validate desired state
-		err := validateUp(&desiredShipment, existingShipment)
+		err = validateUp(&desiredShipment, existingShipment, upRemoveOrphans)
 		if err != nil {
-			fmt.Printf("ERROR: %s\n", err)
-			os.Exit(-1)
+			return err
+		}
+
+		//only show what would change
+		if upPlan {
+			plan := planShipment(shipmentName, shipment, &desiredShipment, existingShipment, upPlanOptions())
+			printPlan(out, plan)
+			pendingLock.Lock()
+			pending = pending || plan.pending()
+			pendingLock.Unlock()
+			return nil
 		}
 
-		fmt.Printf("Starting %v %v ...\n", shipmentName, shipment.Env)
+		fmt.Fprintf(out, "Starting %v %v ...\n", shipmentName, shipment.Env)
 
 		//creating a shipment is a different workflow than updating
 		if existingShipment == nil {
 			if Verbose {
 				log.Println(messageShipmentEnvironmentNotFound)
 			}
-			createShipment(username, token, shipmentName, shipment, dockerCompose, desiredShipment)
+			if err := createShipment(out, username, token, shipmentName, shipment, dockerCompose, desiredShipment); err != nil {
+				return err
+			}
 
 		} else {
+			//record the current state so that changes can be rolled back
+			plan := planShipment(shipmentName, shipment, &desiredShipment, existingShipment, upPlanOptions())
+			if plan.pending() {
+				recordRevision(existingShipment, username, "up")
+			}
+
 			//make changes to harbor based on compose files
-			updateShipment(username, token, existingShipment, shipmentName, shipment, dockerCompose)
+			if err := updateShipment(out, username, token, existingShipment, shipmentName, shipment, dockerCompose, &desiredShipment); err != nil {
+				return err
+			}
+		}
+
+		fmt.Fprintln(out, "done")
+
+		//block until the rollout succeeds or fails (dependencies must be healthy before their dependents start)
+		if upWait || task.HasDependents {
+			return waitForShipment(out, username, token, shipmentName, shipment.Env, upTimeout)
 		}
+		return nil
+	})
 
-		fmt.Println("done")
+	//allow ci/cd to gate on pending changes
+	if upPlan && pending {
+		os.Exit(exitCodePlanPending)
+	}
+}
 
-	} //shipments
+//resolves the endpoints of linked shipments
+//with --plan, linked shipments in the harbor compose file that don't exist yet are shown as a placeholder
+func upLinkEndpoint(username string, token string, harborCompose HarborCompose) linkEndpointFunc {
+	return func(shipment string, env string) (string, error) {
+		endpoint, err := getLinkEndpoint(username, token, shipment, env)
+		if err != nil && upPlan {
+			if _, ok := harborCompose.Shipments[shipment]; ok {
+				return fmt.Sprintf("(endpoint of %v %v)", shipment, env), nil
+			}
+		}
+		return endpoint, err
+	}
 }
 
 //validates desire shipment against existing
-func validateUp(desired *ShipmentEnvironment, existing *ShipmentEnvironment) error {
+//removeOrphans allows existing containers that are no longer desired to be removed
+func validateUp(desired *ShipmentEnvironment, existing *ShipmentEnvironment, removeOrphans bool) error {
 
 	if Verbose {
 		fmt.Println("existing:")
@@ -113,6 +245,11 @@ func validateUp(desired *ShipmentEnvironment, existing *ShipmentEnvironment) err
 		return errors.New(messageEnvironmentUnderscores)
 	}
 
+	//iam role
+	if desired.IamRole != "" && !iamRoleArnPattern.MatchString(desired.IamRole) {
+		return errors.New(messageIamRoleArn)
+	}
+
 	provider := ec2Provider(desired.Providers)
 
 	//barge
@@ -148,7 +285,7 @@ func validateUp(desired *ShipmentEnvironment, existing *ShipmentEnvironment) err
 			}
 		}
 
-		//validate health check
+		//validate health check (either the env var or a port setting)
 		foundHealthCheck := false
 		for _, v := range container.EnvVars {
 			if v.Name == healthCheckEnvVarName {
@@ -156,6 +293,11 @@ func validateUp(desired *ShipmentEnvironment, existing *ShipmentEnvironment) err
 				break
 			}
 		}
+		for _, port := range container.Ports {
+			if port.Healthcheck != "" {
+				foundHealthCheck = true
+			}
+		}
 		if !foundHealthCheck {
 			return errors.New(messageHealthCheckRequired)
 		}
@@ -168,6 +310,11 @@ func validateUp(desired *ShipmentEnvironment, existing *ShipmentEnvironment) err
 		}
 	}
 
+	err := validatePorts(desired.Containers)
+	if err != nil {
+		return err
+	}
+
 	//update-specific validation
 	if existing != nil {
 		existingProvider := ec2Provider(existing.Providers)
@@ -181,39 +328,38 @@ func validateUp(desired *ShipmentEnvironment, existing *ShipmentEnvironment) err
 			return errors.New(messageChangeBarge)
 		}
 
-		//don't allow container name changes
-		for _, desiredContainer := range desired.Containers {
-			//locate existing container with same name, error if not found
-			found := false
-			for _, existingContainer := range existing.Containers {
-				if existingContainer.Name == desiredContainer.Name {
-
-					//don't allow port changes
-					existingPort := getPrimaryPort(existingContainer.Ports)
-					desiredPort := getPrimaryPort(desiredContainer.Ports)
-					if !(existingPort.Value == desiredPort.Value && existingPort.PublicPort == desiredPort.PublicPort) {
-						return errors.New(messageChangePort)
-					}
-
-					//don't allow health check changes
-					if existingPort.Healthcheck != desiredPort.Healthcheck {
-						return errors.New(messageChangeHealthCheck)
-					}
-
-					//return container match
-					found = true
-					break
-				}
+		//containers that are no longer desired are only removed when asked to
+		for _, existingContainer := range existing.Containers {
+			if findContainer(existingContainer.Name, desired.Containers) != nil {
+				continue
+			}
+			if getPrimaryPort(existingContainer.Ports).Primary {
+				return errors.New(messageRemovePrimaryContainer)
 			}
-			if !found {
-				return errors.New(messageChangeContainer)
+			if !removeOrphans {
+				return errors.New(messageRemoveContainer)
 			}
 		}
+
+		err = validatePortChanges(desired, existing)
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
+//finds a port by name in a port slice
+func getPort(ports []PortPayload, name string) PortPayload {
+	for _, port := range ports {
+		if port.Name == name {
+			return port
+		}
+	}
+	return PortPayload{}
+}
+
 //finds the primary port in a port slice
 func getPrimaryPort(ports []PortPayload) PortPayload {
 	for _, port := range ports {
@@ -250,6 +396,9 @@ func transformComposeToShipmentEnvironment(shipmentName string, shipment Compose
 	}
 	newShipment.EnableMonitoring = enableMonitoring
 
+	//the role that containers run as
+	newShipment.IamRole = shipment.IamRole
+
 	//add environment-level env vars
 	for name, value := range shipment.Environment {
 		newShipment.EnvVars = append(newShipment.EnvVars, envVar(name, value))
@@ -258,14 +407,15 @@ func transformComposeToShipmentEnvironment(shipmentName string, shipment Compose
 	//containers
 	//iterate defined containers and apply container level updates
 	newShipment.Containers = make([]ContainerPayload, 0)
-	for containerIndex, container := range shipment.Containers {
+	for _, container := range shipment.Containers {
 
 		if Verbose {
 			log.Printf("processing container: %v", container)
 		}
 
 		//lookup the container in the list of services in the docker-compose file
-		serviceConfig := getDockerComposeService(dockerComposeProject, container)
+		serviceConfig, err := getDockerComposeService(dockerComposeProject, container)
+		check(err)
 
 		image := serviceConfig.Image
 		if image == "" {
@@ -286,45 +436,30 @@ func transformComposeToShipmentEnvironment(shipmentName string, shipment Compose
 		if len(serviceConfig.Ports) == 0 {
 			check(errors.New("at least one port mapping is required in docker compose file"))
 		}
+		healthcheck := getEnvVar(healthCheckEnvVarName, newContainer.EnvVars).Value
+		ports, err := transformComposePorts(shipment, container, serviceConfig.Ports, healthcheck)
+		check(err)
+		newContainer.Ports = ports
 
-		//map first port in docker compose to default primary HTTP "PORT"
-		parsedPort := strings.Split(serviceConfig.Ports[0], ":")
-
-		external, err := strconv.Atoi(parsedPort[0])
-		if err != nil {
-			check(errors.New("invalid port"))
-		}
-		internal, err := strconv.Atoi(parsedPort[1])
-		if err != nil {
-			log.Fatalln("invalid port")
-		}
-
-		primaryPort := PortPayload{
-			Name:              "PORT",
-			Value:             internal,
-			PublicPort:        external,
-			Primary:           (containerIndex == 0),
-			Protocol:          "http",
-			Healthcheck:       getEnvVar(healthCheckEnvVarName, newContainer.EnvVars).Value,
-			External:          true,
-			SslManagementType: "iam",
-		}
+		//add container to list
+		newShipment.Containers = append(newShipment.Containers, newContainer)
+	}
 
-		//set the healthcheck values if they are defined in the yaml
-		if shipment.HealthcheckTimeoutSeconds != nil {
-			primaryPort.HealthcheckTimeout = shipment.HealthcheckTimeoutSeconds
-		}
-		if shipment.HealthcheckIntervalSeconds != nil {
-			primaryPort.HealthcheckInterval = shipment.HealthcheckIntervalSeconds
+	//port settings have to refer to a container
+	for container := range shipment.Ports {
+		if findContainer(container, newShipment.Containers) == nil {
+			check(fmt.Errorf(messagePortsContainerNotFound, container))
 		}
+	}
 
-		//add port to list
-		newContainer.Ports = append(newContainer.Ports, primaryPort)
-
-		//add container to list
-		newShipment.Containers = append(newShipment.Containers, newContainer)
+	//the first port of the first container is primary unless specified
+	if !hasExplicitPrimaryPort(shipment) && len(newShipment.Containers) > 0 {
+		newShipment.Containers[0].Ports[0].Primary = true
 	}
 
+	//the load balancer's https listener
+	applyHTTPS(shipment.LoadBalancer, newShipment.Containers)
+
 	//add default ec2 provider
 	provider := ProviderPayload{
 		Name:     "ec2",
@@ -360,7 +495,7 @@ func parseEnvVarNames(envFile string) []string {
 	return keys
 }
 
-func createShipment(username string, token string, shipmentName string, shipment ComposeShipment, dockerComposeProject project.APIProject, newShipment ShipmentEnvironment) {
+func createShipment(out io.Writer, username string, token string, shipmentName string, shipment ComposeShipment, dockerComposeProject project.APIProject, newShipment ShipmentEnvironment) error {
 
 	if Verbose {
 		log.Println("creating shipment environment")
@@ -368,30 +503,44 @@ func createShipment(username string, token string, shipmentName string, shipment
 
 	//catalog containers
 	for _, container := range shipment.Containers {
-		serviceConfig := getDockerComposeService(dockerComposeProject, container)
-		catalogContainer(container, serviceConfig.Image)
+		serviceConfig, err := getDockerComposeService(dockerComposeProject, container)
+		if err != nil {
+			return err
+		}
+		if err := catalogContainer(container, serviceConfig.Image); err != nil {
+			return err
+		}
 	}
 
 	//push the new shipment/environment up to harbor
-	SaveNewShipmentEnvironment(username, token, newShipment)
+	if err := SaveNewShipmentEnvironment(username, token, newShipment); err != nil {
+		return err
+	}
 
 	//trigger shipment
-	success, messages := Trigger(shipmentName, shipment.Env)
+	success, messages, err := Trigger(shipmentName, shipment.Env)
+	if err != nil {
+		return err
+	}
 
 	for _, msg := range messages {
-		fmt.Println(msg)
+		fmt.Fprintln(out, msg)
 	}
 
 	if success && shipment.Replicas > 0 {
-		fmt.Println(successMessage)
+		fmt.Fprintln(out, successMessage)
 	}
+	return nil
 }
 
-func updateShipment(username string, token string, currentShipment *ShipmentEnvironment, shipmentName string, shipment ComposeShipment, dockerComposeProject project.APIProject) {
+func updateShipment(out io.Writer, username string, token string, currentShipment *ShipmentEnvironment, shipmentName string, shipment ComposeShipment, dockerComposeProject project.APIProject, desiredShipment *ShipmentEnvironment) error {
 
 	//map a ComposeShipment object (based on compose files) into
 	//a series of API calls to update a shipment
 
+	//env var changes are computed locally and applied together
+	envVarChanges := []envVarChange{}
+
 	//iterate defined containers and apply container level updates
 	for _, container := range shipment.Containers {
 		if Verbose {
@@ -399,18 +548,33 @@ func updateShipment(username string, token string, currentShipment *ShipmentEnvi
 		}
 
 		//lookup the container in the list of services in the docker-compose file
-		serviceConfig := getDockerComposeService(dockerComposeProject, container)
+		serviceConfig, err := getDockerComposeService(dockerComposeProject, container)
+		if err != nil {
+			return err
+		}
+
+		//find the existing container
+		currentContainer := findContainer(container, currentShipment.Containers)
+
+		//add new containers along with their ports and env vars
+		if currentContainer == nil {
+			if err := catalogContainer(container, serviceConfig.Image); err != nil {
+				return err
+			}
+			newContainer := newContainerPayload(*findContainer(container, desiredShipment.Containers))
+			if err := AddContainer(username, token, shipmentName, currentShipment.Name, newContainer); err != nil {
+				return err
+			}
+			envVarChanges = append(envVarChanges, diffEnvVarChanges(container, nil, newContainer.EnvVars)...)
+			continue
+		}
 
 		//should we process the image?
 		if !shipment.IgnoreImageVersion {
 
 			// catalog container image
-			catalogContainer(container, serviceConfig.Image)
-
-			//find the existing container
-			currentContainer := findContainer(container, currentShipment.Containers)
-			if currentContainer == nil {
-				check(errors.New("Cannot find container. Adding new containers is not supported"))
+			if err := catalogContainer(container, serviceConfig.Image); err != nil {
+				return err
 			}
 
 			//has the image changed?
@@ -422,27 +586,20 @@ func updateShipment(username string, token string, currentShipment *ShipmentEnvi
 				}
 
 				//update the shipment/container with the new image
-				UpdateContainerImage(username, token, shipmentName, currentShipment.Name, payload)
+				if err := UpdateContainerImage(username, token, shipmentName, currentShipment.Name, payload); err != nil {
+					return err
+				}
 
 			} else if Verbose {
 				log.Println("image has not changed, skipping")
 			}
 		}
 
-		//map docker-compose envvars to harbor env vars
-		harborEnvVars := transformDockerServiceEnvVarsToHarborEnvVars(serviceConfig)
-		for _, envvar := range harborEnvVars {
-			if envvar.Name != "" {
-				if Verbose {
-					log.Printf("processing %s (%s)", envvar.Name, envvar.Type)
-				}
-
-				//TODO: check for delta against envvars in currentShipment
-				//rather than doing additional GETs
-
-				//save the envvar
-				SaveEnvVar(username, token, shipmentName, shipment.Env, envvar, container)
-			}
+		//diff the desired env vars (from docker-compose and shipment links) against the current ones
+		harborEnvVars := findContainer(container, desiredShipment.Containers).EnvVars
+		envVarChanges = append(envVarChanges, diffEnvVarChanges(container, currentContainer.EnvVars, harborEnvVars)...)
+		if upPrune {
+			envVarChanges = append(envVarChanges, pruneEnvVarChanges(container, currentContainer.EnvVars, harborEnvVars)...)
 		}
 	}
 
@@ -458,30 +615,54 @@ func updateShipment(username string, token string, currentShipment *ShipmentEnvi
 		shipment.Environment["BARGE"] = shipment.Barge
 	}
 
-	//update shipment/environment-level envvars
-	for evName, evValue := range shipment.Environment {
-		if Verbose {
-			log.Println("processing " + evName)
-		}
-
-		//create an envvar object
-		envVarPayload := envVar(evName, evValue)
-
-		//save the envvar
-		SaveEnvVar(username, token, shipmentName, shipment.Env, envVarPayload, "")
+	//diff shipment/environment-level envvars
+	environment := basicEnvVars(shipment.Environment)
+	envVarChanges = append(envVarChanges, diffEnvVarChanges("", currentShipment.EnvVars, environment)...)
+	if upPrune {
+		envVarChanges = append(envVarChanges, pruneEnvVarChanges("", currentShipment.EnvVars, environment)...)
+		envVarChanges = confirmEnvVarPrune(out, envVarChanges, upYes)
+	}
 
-	} //envvars
+	//save all env var changes
+	if err := syncEnvVars(username, token, shipmentName, shipment.Env, envVarChanges); err != nil {
+		return err
+	}
 
 	//update settings related to ports
-	updatePorts(currentShipment, shipment, username, token)
+	if err := updatePorts(currentShipment, desiredShipment, username, token); err != nil {
+		return err
+	}
 
-	//if user specified a value for enableMonitoring that's
-	//different from current, then update
-	if shipment.EnableMonitoring != nil && *shipment.EnableMonitoring != currentShipment.EnableMonitoring {
+	//remove containers that are no longer listed (validateUp requires --remove-orphans)
+	if upRemoveOrphans {
+		for _, container := range currentShipment.Containers {
+			if findContainer(container.Name, desiredShipment.Containers) == nil {
+				if err := RemoveContainer(username, token, shipmentName, currentShipment.Name, container.Name); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	//if user specified values for enableMonitoring or iamRole that are
+	//different from current, then update (keeping the current values of the others)
+	request := UpdateShipmentEnvironmentRequest{
+		EnableMonitoring: currentShipment.EnableMonitoring,
+		IamRole:          currentShipment.IamRole,
+	}
+	if shipment.EnableMonitoring != nil {
+		request.EnableMonitoring = *shipment.EnableMonitoring
+	}
+	if shipment.IamRole != "" {
+		request.IamRole = shipment.IamRole
+	}
+	if request.EnableMonitoring != currentShipment.EnableMonitoring || request.IamRole != currentShipment.IamRole {
 		if Verbose {
-			fmt.Println("updating shipment/environment configuration (enableMonitoring)")
+			fmt.Fprintln(out, "updating shipment/environment configuration (enableMonitoring, iamRole)")
+		}
+		if err := UpdateShipmentEnvironment(username, token, shipmentName, currentShipment.Name, request); err != nil {
+			return err
 		}
-		UpdateShipmentEnvironment(username, token, shipmentName, shipment)
 	}
 
 	//update provider configuration, if changed
@@ -493,28 +674,66 @@ func updateShipment(username string, token string, currentShipment *ShipmentEnvi
 			Replicas: shipment.Replicas,
 		}
 
-		UpdateProvider(username, token, shipmentName, currentShipment.Name, providerPayload)
+		if err := UpdateProvider(username, token, shipmentName, currentShipment.Name, providerPayload); err != nil {
+			return err
+		}
 	}
 
 	//trigger shipment
-	_, messages := Trigger(shipmentName, shipment.Env)
+	_, messages, err := Trigger(shipmentName, shipment.Env)
+	if err != nil {
+		return err
+	}
 
 	for _, msg := range messages {
-		fmt.Println(msg)
+		fmt.Fprintln(out, msg)
 	}
 
 	//if replicas is changing from 0, then show wait messages
 	if ec2Provider(currentShipment.Providers).Replicas == 0 {
-		fmt.Println(successMessage)
+		fmt.Fprintln(out, successMessage)
 	}
+	return nil
+}
+
+//returns the payload used to add a container to an existing shipment environment
+//the shipment environment already has a primary port, so added ports are never primary
+func newContainerPayload(container ContainerPayload) ContainerPayload {
+	result := container
+	result.Ports = []PortPayload{}
+	for _, port := range container.Ports {
+		port.Primary = false
+		result.Ports = append(result.Ports, port)
+	}
+	return result
 }
 
 //update container ports
-func updatePorts(existingShipment *ShipmentEnvironment, desiredShipment ComposeShipment, username string, token string) {
+func updatePorts(existingShipment *ShipmentEnvironment, desiredShipment *ShipmentEnvironment, username string, token string) error {
 
 	//inspect container ports
 	for _, container := range existingShipment.Containers {
-		for _, port := range container.Ports {
+
+		//containers that are no longer desired are removed
+		desiredContainer := findContainer(container.Name, desiredShipment.Containers)
+		if desiredContainer == nil {
+			continue
+		}
+
+		for _, desiredPort := range desiredContainer.Ports {
+
+			//add new ports (the shipment environment already has a primary port)
+			port := getPort(container.Ports, desiredPort.Name)
+			if port.Name == "" {
+				if Verbose {
+					log.Printf("adding port: %s on container: %s\n", desiredPort.Name, container.Name)
+				}
+				desiredPort.Primary = false
+				if err := createPort(username, token, existingShipment.ParentShipment.Name, existingShipment.Name, container.Name, desiredPort); err != nil {
+					return err
+				}
+				continue
+			}
 
 			portPayload := UpdatePortRequest{
 				Name: port.Name,
@@ -522,37 +741,61 @@ func updatePorts(existingShipment *ShipmentEnvironment, desiredShipment ComposeS
 
 			//only update the props that have been specified and changed
 
-			if desiredShipment.HealthcheckTimeoutSeconds != nil && *desiredShipment.HealthcheckTimeoutSeconds != *port.HealthcheckTimeout {
-				portPayload.HealthcheckTimeout = desiredShipment.HealthcheckTimeoutSeconds
+			if desiredPort.HealthcheckTimeout != nil && !intPtrEqual(desiredPort.HealthcheckTimeout, port.HealthcheckTimeout) {
+				portPayload.HealthcheckTimeout = desiredPort.HealthcheckTimeout
+			}
+
+			if desiredPort.HealthcheckInterval != nil && !intPtrEqual(desiredPort.HealthcheckInterval, port.HealthcheckInterval) {
+				portPayload.HealthcheckInterval = desiredPort.HealthcheckInterval
 			}
 
-			if desiredShipment.HealthcheckIntervalSeconds != nil && *desiredShipment.HealthcheckIntervalSeconds != *port.HealthcheckInterval {
-				portPayload.HealthcheckInterval = desiredShipment.HealthcheckIntervalSeconds
+			//certificates can be changed without downtime
+			if desiredPort.SslArn != "" && (desiredPort.SslArn != port.SslArn || desiredPort.SslManagementType != port.SslManagementType) {
+				portPayload.SslArn = desiredPort.SslArn
+				portPayload.SslManagementType = desiredPort.SslManagementType
 			}
 
 			//do we need to send updates to the server?
-			if portPayload.HealthcheckTimeout != nil || portPayload.HealthcheckInterval != nil {
+			if portPayload.HealthcheckTimeout != nil || portPayload.HealthcheckInterval != nil || portPayload.SslArn != "" {
 				if Verbose {
 					log.Printf("updating port: %s on container: %s\n", port.Name, container.Name)
 				}
-				updatePort(username, token, existingShipment.ParentShipment.Name, existingShipment.Name, container.Name, portPayload)
+				if err := updatePort(username, token, existingShipment.ParentShipment.Name, existingShipment.Name, container.Name, portPayload); err != nil {
+					return err
+				}
 			}
 		}
 	}
+	return nil
 }
 
-func catalogContainer(name string, image string) {
+//compares two optional ints
+func intPtrEqual(a *int, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func catalogContainer(name string, image string) error {
 
 	if Verbose {
 		log.Printf("cataloging container %v", name)
 	}
 
-	//parse image:tag and map to name/version
-	parsedImage := strings.Split(image, ":")
-	tag := parsedImage[1]
+	//parse image:tag (or digest) and map to name/version
+	parsedImage, err := parseVersionedImage(image)
+	if err != nil {
+		return err
+	}
+	tag := parsedImage.Version()
 
 	//lookup container image in the catalog and catalog if missing
-	if !IsContainerVersionCataloged(name, tag) {
+	cataloged, err := IsContainerVersionCataloged(name, tag)
+	if err != nil {
+		return err
+	}
+	if !cataloged {
 
 		newContainer := CatalogitContainer{
 			Name:    name,
@@ -566,7 +809,7 @@ func catalogContainer(name string, image string) {
 			fmt.Println(message)
 		}
 		if err != nil {
-			check(err[0])
+			return err
 		}
 
 	} else {
@@ -574,4 +817,5 @@ func catalogContainer(name string, image string) {
 			log.Printf("container %v already cataloged", name)
 		}
 	}
+	return nil
 }
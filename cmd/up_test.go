@@ -5,12 +5,15 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
+	"net/http/httptest"
 	"os"
 	"strconv"
 	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/turnerlabs/harbor-compose/harbor/client"
+	"github.com/turnerlabs/harbor-compose/harbor/mockserver"
 )
 
 func TestUpEnvVarEqualsSign(t *testing.T) {
@@ -405,7 +408,7 @@ shipments:
 	newShipment := transformComposeToShipmentEnvironment(shipmentName, composeShipment, dockerCompose)
 
 	//test func
-	err := validateUp(&newShipment, nil)
+	err := validateUp(&newShipment, nil, false)
 	assert.NotNil(t, err)
 	assert.Contains(t, err.Error(), messageEnvironmentUnderscores)
 }
@@ -449,7 +452,7 @@ shipments:
 	newShipment := transformComposeToShipmentEnvironment(shipmentName, composeShipment, dockerCompose)
 
 	//test func
-	err := validateUp(&newShipment, nil)
+	err := validateUp(&newShipment, nil, false)
 	assert.NotNil(t, err)
 	assert.Contains(t, err.Error(), messageContainerRequired)
 }
@@ -519,7 +522,7 @@ shipments:
 	desiredShipment := transformComposeToShipmentEnvironment(name, composeShipment, dockerCompose)
 
 	//test func
-	err = validateUp(&desiredShipment, &existingShipment)
+	err = validateUp(&desiredShipment, &existingShipment, false)
 
 	//no errors
 	assert.Nil(t, err)
@@ -593,12 +596,165 @@ shipments:
 	desiredShipment := transformComposeToShipmentEnvironment(name, composeShipment, dockerCompose)
 
 	//test func
-	err = validateUp(&desiredShipment, &existingShipment)
+	err = validateUp(&desiredShipment, &existingShipment, false)
 
 	//look for error
 	t.Log(err)
 	assert.NotNil(t, err)
-	assert.Contains(t, err.Error(), messageChangeContainer)
+	assert.Contains(t, err.Error(), messageRemovePrimaryContainer)
+}
+
+//tests up with a container that's new to harbor-compose.yml
+func TestUpValidateAddContainer(t *testing.T) {
+
+	existingShipment := getPlanTestExistingShipment(t)
+
+	dockerComposeYaml := `
+version: "2"
+services:
+  sidecar:
+    image: registry/sidecar:1.0
+    ports:
+    - 8080:8080
+    environment:
+      HEALTHCHECK: /health
+  web:
+    image: registry/app:1.0
+    ports:
+    - 80:5000
+    environment:
+      HEALTHCHECK: /hc`
+
+	harborComposeYaml := `
+shipments:
+  mss-poc-app:
+    env: dev
+    barge: digital-sandbox
+    containers:
+    - sidecar
+    - web
+    replicas: 2`
+
+	dockerCompose, harborCompose := unmarshalCompose(dockerComposeYaml, harborComposeYaml)
+	composeShipment := harborCompose.Shipments["mss-poc-app"]
+	desiredShipment := transformComposeToShipmentEnvironment("mss-poc-app", composeShipment, dockerCompose)
+
+	//the existing container keeps its primary port even though it's no longer listed first
+	keepPrimaryPort(composeShipment, &desiredShipment, &existingShipment)
+	assert.True(t, getPrimaryPort(desiredShipment.Containers[1].Ports).Primary)
+
+	//test func
+	err := validateUp(&desiredShipment, &existingShipment, false)
+
+	//no errors
+	assert.Nil(t, err)
+}
+
+//tests up with a container that's no longer listed in harbor-compose.yml
+func TestUpValidateRemoveContainer(t *testing.T) {
+
+	existingShipment := getPlanTestExistingShipment(t)
+	existingShipment.Containers = append(existingShipment.Containers, ContainerPayload{
+		Name:  "sidecar",
+		Image: "registry/sidecar:1.0",
+		Ports: []PortPayload{{Name: "PORT", Value: 8080, PublicPort: 8080}},
+	})
+
+	dockerComposeYaml := `
+version: "2"
+services:
+  web:
+    image: registry/app:1.0
+    ports:
+    - 80:5000
+    environment:
+      HEALTHCHECK: /hc`
+
+	harborComposeYaml := `
+shipments:
+  mss-poc-app:
+    env: dev
+    barge: digital-sandbox
+    containers:
+    - web
+    replicas: 2`
+
+	dockerCompose, harborCompose := unmarshalCompose(dockerComposeYaml, harborComposeYaml)
+	composeShipment := harborCompose.Shipments["mss-poc-app"]
+	desiredShipment := transformComposeToShipmentEnvironment("mss-poc-app", composeShipment, dockerCompose)
+
+	//removing requires --remove-orphans
+	err := validateUp(&desiredShipment, &existingShipment, false)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), messageRemoveContainer)
+
+	err = validateUp(&desiredShipment, &existingShipment, true)
+	assert.Nil(t, err)
+}
+
+//tests that up adds new containers and removes orphans
+func TestUpdateShipmentContainers(t *testing.T) {
+
+	existing := getPlanTestExistingShipment(t)
+	existing.Containers = append(existing.Containers, ContainerPayload{
+		Name:  "worker",
+		Image: "registry/worker:1.0",
+		Ports: []PortPayload{{Name: "PORT", Value: 9000, PublicPort: 9000}},
+	})
+	server := httptest.NewServer(mockserver.New(&mockserver.Seed{Shipments: []client.ShipmentEnvironment{existing}}))
+	defer server.Close()
+
+	defer useMockHarbor(server)()
+	defer func(original bool) { upRemoveOrphans = original }(upRemoveOrphans)
+	upRemoveOrphans = true
+
+	dockerComposeYaml := `
+version: "2"
+services:
+  web:
+    image: quay.io/turner/web:1.0
+    ports:
+    - 80:5000
+    environment:
+      HEALTHCHECK: /hc
+      CONTAINER_LEVEL: containerLevel
+  sidecar:
+    image: registry/sidecar:1.0
+    ports:
+    - 8080:8080
+    environment:
+      HEALTHCHECK: /health`
+
+	harborComposeYaml := `
+shipments:
+  mss-poc-app:
+    env: dev
+    barge: digital-sandbox
+    containers:
+    - web
+    - sidecar
+    replicas: 2`
+
+	dockerCompose, harborCompose := unmarshalCompose(dockerComposeYaml, harborComposeYaml)
+	composeShipment := harborCompose.Shipments["mss-poc-app"]
+	desired := transformComposeToShipmentEnvironment("mss-poc-app", composeShipment, dockerCompose)
+
+	//test
+	err := updateShipment(ioutil.Discard, "user", "token", &existing, "mss-poc-app", composeShipment, dockerCompose, &desired)
+	assert.Nil(t, err)
+
+	//assertions
+	updated, err := GetShipmentEnvironment("user", "token", "mss-poc-app", "dev")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(updated.Containers))
+	assert.Nil(t, findContainer("worker", updated.Containers))
+
+	sidecar := findContainer("sidecar", updated.Containers)
+	assert.NotNil(t, sidecar)
+	assert.Equal(t, "registry/sidecar:1.0", sidecar.Image)
+	assert.Equal(t, 8080, sidecar.Ports[0].Value)
+	assert.False(t, sidecar.Ports[0].Primary)
+	assert.Equal(t, "/health", getEnvVar("HEALTHCHECK", sidecar.EnvVars).Value)
 }
 
 //tests up with port change
@@ -669,7 +825,7 @@ shipments:
 	desiredShipment := transformComposeToShipmentEnvironment(name, composeShipment, dockerCompose)
 
 	//test func
-	err = validateUp(&desiredShipment, &existingShipment)
+	err = validateUp(&desiredShipment, &existingShipment, false)
 
 	//look for error
 	t.Log(err)
@@ -746,7 +902,7 @@ shipments:
 	desiredShipment := transformComposeToShipmentEnvironment(name, composeShipment, dockerCompose)
 
 	//test func
-	err = validateUp(&desiredShipment, &existingShipment)
+	err = validateUp(&desiredShipment, &existingShipment, false)
 
 	//look for error
 	t.Log(err)
@@ -822,7 +978,7 @@ func TestUpValidateHealthCheckChange(t *testing.T) {
 	desiredShipment := transformComposeToShipmentEnvironment(name, composeShipment, dockerCompose)
 
 	//test func
-	err = validateUp(&desiredShipment, &existingShipment)
+	err = validateUp(&desiredShipment, &existingShipment, false)
 
 	//look for error
 	t.Log(err)
@@ -872,7 +1028,7 @@ shipments:
 
 	//test validate
 	desiredShipment := transformComposeToShipmentEnvironment(name, composeShipment, dockerCompose)
-	err := validateUp(&desiredShipment, nil)
+	err := validateUp(&desiredShipment, nil, false)
 
 	//expect to fail (interval == timeout)
 	t.Log(err)
@@ -922,7 +1078,7 @@ shipments:
 
 	//test validate
 	desiredShipment := transformComposeToShipmentEnvironment(name, composeShipment, dockerCompose)
-	err := validateUp(&desiredShipment, nil)
+	err := validateUp(&desiredShipment, nil, false)
 
 	//expect to pass (interval > timeout)
 	t.Log(err)
@@ -971,7 +1127,7 @@ shipments:
 
 	//test validate
 	desiredShipment := transformComposeToShipmentEnvironment(name, composeShipment, dockerCompose)
-	err := validateUp(&desiredShipment, nil)
+	err := validateUp(&desiredShipment, nil, false)
 
 	//expect to fail (interval < timeout)
 	t.Log(err)
@@ -1321,7 +1477,7 @@ shipments:
 
 	//test func
 	newShipment := transformComposeToShipmentEnvironment(shipmentName, composeShipment, dockerCompose)
-	err := validateUp(&newShipment, nil)
+	err := validateUp(&newShipment, nil, false)
 
 	t.Log(err)
 	assert.NotNil(t, err)
@@ -1375,7 +1531,7 @@ shipments:
 
 	//test validate
 	desiredShipment := transformComposeToShipmentEnvironment(shipmentName, composeShipment, dockerCompose)
-	err = validateUp(&desiredShipment, nil)
+	err = validateUp(&desiredShipment, nil, false)
 
 	//expect to fail since env var is empty
 	t.Log(err)
@@ -1388,3 +1544,104 @@ shipments:
 		t.Fail()
 	}
 }
+
+func TestUpValidateIamRole(t *testing.T) {
+
+	dockerComposeYaml := `
+version: "2"
+services:
+  web:
+    image: registry/app:1.0
+    ports:
+    - 80:5000
+    environment:
+      HEALTHCHECK: /hc`
+
+	harborComposeYaml := `
+shipments:
+  mss-poc-app:
+    env: dev
+    barge: digital-sandbox
+    containers:
+    - web
+    replicas: 2
+    iamRole: ${iamRole}`
+
+	tests := []struct {
+		iamRole string
+		valid   bool
+	}{
+		{"arn:aws:iam::123456789012:role/my-app", true},
+		{"arn:aws:iam::123456789012:role/service/my-app.dev", true},
+		{"my-app", false},
+		{"arn:aws:iam::123456789012:user/my-app", false},
+		{"arn:aws:iam::1234:role/my-app", false},
+	}
+
+	for _, test := range tests {
+		dockerCompose, harborCompose := unmarshalCompose(dockerComposeYaml, strings.Replace(harborComposeYaml, "${iamRole}", test.iamRole, 1))
+		desiredShipment := transformComposeToShipmentEnvironment("mss-poc-app", harborCompose.Shipments["mss-poc-app"], dockerCompose)
+		assert.Equal(t, test.iamRole, desiredShipment.IamRole)
+
+		err := validateUp(&desiredShipment, nil, false)
+		if test.valid {
+			assert.Nil(t, err, test.iamRole)
+		} else {
+			assert.Equal(t, messageIamRoleArn, err.Error(), test.iamRole)
+		}
+	}
+}
+
+func TestUpdateShipmentIamRole(t *testing.T) {
+
+	existing := getPlanTestExistingShipment(t)
+	existing.EnableMonitoring = true
+	server := httptest.NewServer(mockserver.New(&mockserver.Seed{Shipments: []client.ShipmentEnvironment{existing}}))
+	defer server.Close()
+
+	defer useMockHarbor(server)()
+
+	dockerComposeYaml := `
+version: "2"
+services:
+  web:
+    image: quay.io/turner/web:1.0
+    ports:
+    - 80:5000
+    environment:
+      HEALTHCHECK: /hc`
+
+	harborComposeYaml := `
+shipments:
+  mss-poc-app:
+    env: dev
+    barge: digital-sandbox
+    containers:
+    - web
+    replicas: 2
+    iamRole: arn:aws:iam::123456789012:role/my-app`
+
+	dockerCompose, harborCompose := unmarshalCompose(dockerComposeYaml, harborComposeYaml)
+	composeShipment := harborCompose.Shipments["mss-poc-app"]
+	desired := transformComposeToShipmentEnvironment("mss-poc-app", composeShipment, dockerCompose)
+
+	//the plan shows the change
+	plan := planShipment("mss-poc-app", composeShipment, &desired, &existing, planOptions{})
+	iamRole := findPlanChange(plan, "", "iamRole")
+	assert.Equal(t, planUpdate, iamRole.Action)
+	assert.Equal(t, `"arn:aws:iam::123456789012:role/my-app"`, iamRole.New)
+
+	//test
+	err := updateShipment(ioutil.Discard, "user", "token", &existing, "mss-poc-app", composeShipment, dockerCompose, &desired)
+	assert.Nil(t, err)
+
+	//the role is set without changing monitoring
+	updated, err := GetShipmentEnvironment("user", "token", "mss-poc-app", "dev")
+	assert.Nil(t, err)
+	assert.Equal(t, "arn:aws:iam::123456789012:role/my-app", updated.IamRole)
+	assert.True(t, updated.EnableMonitoring)
+
+	//generate round trips the role
+	generated := transformShipmentToHarborCompose(updated)
+	assert.Equal(t, "arn:aws:iam::123456789012:role/my-app", generated.Shipments["mss-poc-app"].IamRole)
+}
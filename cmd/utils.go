@@ -105,13 +105,12 @@ func getShipmentEnvironmentsFromInput(shipmentFlag string, envFlag string) ([]tu
 	//either use the shipment/environment flags or the yaml file
 	if shipmentFlag != "" && envFlag != "" {
 		result = append(result, tuple{Item1: shipmentFlag, Item2: envFlag})
-	} else if psShipment != "" && psEnvironment == "" {
-		check(errors.New(messageShipmentEnvironmentFlagsRequired))
-	} else if shipmentFlag == "" && envFlag != "" {
+	} else if shipmentFlag != "" && envFlag == "" {
 		check(errors.New(messageShipmentEnvironmentFlagsRequired))
 	} else {
 		//read the compose file to get the shipment/environment list
-		harborComposeConfig := DeserializeHarborCompose(HarborComposeFile)
+		//(an environment flag without a shipment selects the environment from the compose file)
+		harborComposeConfig := loadHarborComposeEnv(envFlag)
 		hc = &harborComposeConfig
 		for shipmentName, shipment := range hc.Shipments {
 			result = append(result, tuple{Item1: shipmentName, Item2: shipment.Env})
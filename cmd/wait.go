@@ -0,0 +1,241 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+//how often to poll harbor while waiting for a rollout
+var rolloutPollInterval = 5 * time.Second
+
+//the number of times a container can restart during a rollout before it's considered failed
+const rolloutMaxRestarts = 2
+
+//container waiting reasons (and event messages) that indicate a rollout will not succeed
+var rolloutFailureReasons = []string{
+	"CrashLoopBackOff",
+	"ErrImagePull",
+	"ImagePullBackOff",
+	"InvalidImageName",
+	"CreateContainerConfigError",
+	"CreateContainerError",
+}
+
+//rolloutTarget is what a shipment environment's running containers should look like once a rollout completes
+type rolloutTarget struct {
+	Images   map[string]bool
+	Expected int
+
+	//true when the number of containers isn't known, in which case the rollout is done
+	//when every running container is on a desired image and ready
+	AnyCount bool
+}
+
+//rolloutProgress is a point in time evaluation of a rollout
+type rolloutProgress struct {
+	Expected int
+	Ready    int
+	Updated  int
+	Restarts int
+	Done     bool
+	Failure  string
+}
+
+func (p rolloutProgress) String() string {
+	return fmt.Sprintf("%v/%v ready, %v/%v on desired image, %v restarts", p.Ready, p.Expected, p.Updated, p.Expected, p.Restarts)
+}
+
+//builds a rollout target from the desired state of a shipment environment
+func getRolloutTarget(shipment *ShipmentEnvironment) rolloutTarget {
+	target := rolloutTarget{
+		Images:   map[string]bool{},
+		Expected: ec2Provider(shipment.Providers).Replicas * len(shipment.Containers),
+	}
+	for _, container := range shipment.Containers {
+		target.Images[container.Image] = true
+	}
+	return target
+}
+
+//evaluates running containers against a target
+//baseline tracks the restart count of each container when it was first seen so that restarts can be measured during the rollout
+func evaluateRollout(target rolloutTarget, status *ShipmentStatus, baseline map[string]int) rolloutProgress {
+	progress := rolloutProgress{Expected: target.Expected}
+	if target.AnyCount {
+		progress.Expected = len(status.Status.Containers)
+	}
+
+	for _, container := range status.Status.Containers {
+
+		//restarts since the rollout started
+		if _, seen := baseline[container.ID]; !seen {
+			baseline[container.ID] = container.Restarts
+		}
+		restarts := container.Restarts - baseline[container.ID]
+		progress.Restarts += restarts
+		if restarts > rolloutMaxRestarts && progress.Failure == "" {
+			progress.Failure = fmt.Sprintf("container %v restarted %v times", shortID(container.ID), restarts)
+		}
+
+		//containers that will never start
+		if state, ok := container.State["waiting"]; ok && isRolloutFailureReason(state.Reason) && progress.Failure == "" {
+			progress.Failure = fmt.Sprintf("container %v is %v", shortID(container.ID), state.Reason)
+		}
+
+		if target.Images[container.Image] {
+			progress.Updated++
+			if container.Ready && container.Status == "running" {
+				progress.Ready++
+			}
+		}
+	}
+
+	//done when every container runs the desired image and is ready
+	progress.Done = progress.Failure == "" &&
+		(!target.AnyCount || progress.Expected > 0) &&
+		len(status.Status.Containers) == progress.Expected &&
+		progress.Ready == progress.Expected
+
+	return progress
+}
+
+//returns a failure if a warning event that occurred since the rollout started indicates that it will not succeed
+func rolloutEventFailure(events []ShipmentEvent, since time.Time) string {
+	for _, event := range events {
+		if strings.ToLower(event.Type) != "warning" || event.LastTimestamp.Before(since) {
+			continue
+		}
+		if isRolloutFailureReason(event.Reason) {
+			return event.Reason + ": " + event.Message
+		}
+		for _, reason := range rolloutFailureReasons {
+			if strings.Contains(event.Message, reason) {
+				return event.Reason + ": " + event.Message
+			}
+		}
+	}
+	return ""
+}
+
+func isRolloutFailureReason(reason string) bool {
+	for _, r := range rolloutFailureReasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
+
+func shortID(id string) string {
+	if len(id) > 7 {
+		return id[0:7]
+	}
+	return id
+}
+
+//waits for the containers in a shipment environment to be running the desired images
+func waitForShipment(out io.Writer, username string, token string, shipment string, env string, timeout time.Duration) error {
+
+	//fetch the shipment's desired state, after changes have been applied
+	shipmentEnvironment, err := GetShipmentEnvironment(username, token, shipment, env)
+	if err != nil {
+		return err
+	}
+	if shipmentEnvironment == nil {
+		return errors.New(messageShipmentEnvironmentNotFound)
+	}
+	provider := ec2Provider(shipmentEnvironment.Providers)
+
+	fmt.Fprintf(out, "Waiting for %v %v ...\n", shipment, env)
+	return waitForRollout(out, shipment, env, provider.Barge, getRolloutTarget(shipmentEnvironment), timeout)
+}
+
+//polls container status and events until a rollout succeeds, fails, or times out
+func waitForRollout(out io.Writer, shipment string, env string, barge string, target rolloutTarget, timeout time.Duration) error {
+	start := time.Now()
+	deadline := start.Add(timeout)
+	baseline := map[string]int{}
+	last := ""
+
+	for {
+		status, err := GetShipmentStatus(barge, shipment, env)
+		if err != nil {
+			return err
+		}
+		events, err := GetShipmentEvents(barge, shipment, env)
+		if err != nil {
+			return err
+		}
+
+		progress := evaluateRollout(target, status, baseline)
+		if progress.Failure == "" {
+			progress.Failure = rolloutEventFailure(events.Events, start)
+		}
+
+		//only print progress when it changes
+		if line := progress.String(); line != last {
+			fmt.Fprintf(out, "%v %v: %v\n", shipment, env, line)
+			last = line
+		}
+
+		if progress.Failure != "" {
+			printRolloutDiagnostics(out, status, events, start)
+			return fmt.Errorf("rollout of %v %v failed: %v", shipment, env, progress.Failure)
+		}
+
+		if progress.Done {
+			fmt.Fprintf(out, "%v %v is healthy\n", shipment, env)
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			printRolloutDiagnostics(out, status, events, start)
+			return fmt.Errorf("timed out after %v waiting for %v %v", timeout, shipment, env)
+		}
+
+		time.Sleep(rolloutPollInterval)
+	}
+}
+
+//prints the warning events and container termination reasons that help explain a failed rollout
+func printRolloutDiagnostics(out io.Writer, status *ShipmentStatus, events *ShipmentEventResult, since time.Time) {
+
+	//most recent first
+	warnings := []ShipmentEvent{}
+	for _, event := range events.Events {
+		if strings.ToLower(event.Type) == "warning" && !event.LastTimestamp.Before(since) {
+			warnings = append(warnings, event)
+		}
+	}
+	sort.Slice(warnings, func(i, j int) bool {
+		return warnings[i].LastTimestamp.After(warnings[j].LastTimestamp)
+	})
+
+	if len(warnings) > 0 {
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "warning events:")
+		for _, event := range warnings {
+			fmt.Fprintf(out, "  %s - %s (x%v)\n", event.Reason, event.Message, event.Count)
+		}
+	}
+
+	terminated := []string{}
+	for _, container := range status.Status.Containers {
+		if state, ok := container.LastState["terminated"]; ok && state != (ContainerLastState{}) {
+			terminated = append(terminated, fmt.Sprintf("  %v %v: %v (exit code %v)", shortID(container.ID), container.Image, state.Reason, state.ExitCode))
+		}
+	}
+
+	if len(terminated) > 0 {
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "last terminated containers:")
+		for _, line := range terminated {
+			fmt.Fprintln(out, line)
+		}
+	}
+	fmt.Fprintln(out)
+}
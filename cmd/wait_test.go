@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/turnerlabs/harbor-compose/harbor/client"
+	"github.com/turnerlabs/harbor-compose/harbor/mockserver"
+)
+
+func getWaitTestStatus(containers ...ContainerStatus) *ShipmentStatus {
+	status := ShipmentStatus{}
+	status.Status.Containers = containers
+	return &status
+}
+
+func getWaitTestTarget() rolloutTarget {
+	return rolloutTarget{
+		Images:   map[string]bool{"registry/app:2.0": true},
+		Expected: 2,
+	}
+}
+
+func TestRolloutInProgress(t *testing.T) {
+	status := getWaitTestStatus(
+		ContainerStatus{ID: "1111111111", Image: "registry/app:1.0", Ready: true, Status: "running"},
+		ContainerStatus{ID: "2222222222", Image: "registry/app:2.0", Ready: true, Status: "running"},
+	)
+
+	progress := evaluateRollout(getWaitTestTarget(), status, map[string]int{})
+
+	assert.False(t, progress.Done)
+	assert.Empty(t, progress.Failure)
+	assert.Equal(t, 1, progress.Ready)
+	assert.Equal(t, 1, progress.Updated)
+	assert.Equal(t, "1/2 ready, 1/2 on desired image, 0 restarts", progress.String())
+}
+
+func TestRolloutDone(t *testing.T) {
+	status := getWaitTestStatus(
+		ContainerStatus{ID: "1111111111", Image: "registry/app:2.0", Ready: true, Status: "running"},
+		ContainerStatus{ID: "2222222222", Image: "registry/app:2.0", Ready: true, Status: "running"},
+	)
+
+	progress := evaluateRollout(getWaitTestTarget(), status, map[string]int{})
+
+	assert.True(t, progress.Done)
+	assert.Empty(t, progress.Failure)
+}
+
+func TestRolloutNotReady(t *testing.T) {
+	status := getWaitTestStatus(
+		ContainerStatus{ID: "1111111111", Image: "registry/app:2.0", Ready: true, Status: "running"},
+		ContainerStatus{ID: "2222222222", Image: "registry/app:2.0", Ready: false, Status: "running"},
+	)
+
+	progress := evaluateRollout(getWaitTestTarget(), status, map[string]int{})
+
+	assert.False(t, progress.Done)
+	assert.Equal(t, 2, progress.Updated)
+	assert.Equal(t, 1, progress.Ready)
+}
+
+func TestRolloutCrashLoop(t *testing.T) {
+	status := getWaitTestStatus(
+		ContainerStatus{
+			ID:     "1111111111",
+			Image:  "registry/app:2.0",
+			Status: "waiting",
+			State:  map[string]ContainerState{"waiting": {Reason: "CrashLoopBackOff"}},
+		},
+	)
+
+	progress := evaluateRollout(getWaitTestTarget(), status, map[string]int{})
+
+	assert.False(t, progress.Done)
+	assert.Equal(t, "container 1111111 is CrashLoopBackOff", progress.Failure)
+}
+
+func TestRolloutRisingRestarts(t *testing.T) {
+	baseline := map[string]int{}
+	container := ContainerStatus{ID: "1111111111", Image: "registry/app:2.0", Ready: true, Status: "running", Restarts: 4}
+
+	//restarts that happened before the rollout are ignored
+	progress := evaluateRollout(getWaitTestTarget(), getWaitTestStatus(container), baseline)
+	assert.Empty(t, progress.Failure)
+	assert.Equal(t, 0, progress.Restarts)
+
+	container.Restarts = 6
+	progress = evaluateRollout(getWaitTestTarget(), getWaitTestStatus(container), baseline)
+	assert.Empty(t, progress.Failure)
+	assert.Equal(t, 2, progress.Restarts)
+
+	container.Restarts = 7
+	progress = evaluateRollout(getWaitTestTarget(), getWaitTestStatus(container), baseline)
+	assert.Equal(t, "container 1111111 restarted 3 times", progress.Failure)
+}
+
+func TestRolloutEventFailure(t *testing.T) {
+	start := time.Now()
+	events := []ShipmentEvent{
+		{Type: "Warning", Reason: "Failed", Message: "Failed to pull image: ErrImagePull", LastTimestamp: start.Add(-time.Hour)},
+		{Type: "Normal", Reason: "Pulled", Message: "Successfully pulled image", LastTimestamp: start.Add(time.Second)},
+	}
+
+	//old events are ignored
+	assert.Empty(t, rolloutEventFailure(events, start))
+
+	events[0].LastTimestamp = start.Add(time.Second)
+	assert.Equal(t, "Failed: Failed to pull image: ErrImagePull", rolloutEventFailure(events, start))
+}
+
+func TestWaitForShipmentMockServer(t *testing.T) {
+	server := httptest.NewServer(mockserver.New(&mockserver.Seed{
+		Shipments: []client.ShipmentEnvironment{
+			{
+				Name:           "dev",
+				ParentShipment: client.ParentShipment{Name: "my-app"},
+				Containers:     []client.ContainerPayload{{Name: "web", Image: "registry/app:2.0"}},
+				Providers:      []client.ProviderPayload{{Name: providerEc2, Replicas: 2, Barge: "corp-sandbox"}},
+			},
+		},
+	}))
+	defer server.Close()
+
+	defer useMockHarbor(server)()
+	defer func(original time.Duration) { rolloutPollInterval = original }(rolloutPollInterval)
+	rolloutPollInterval = time.Millisecond
+
+	//nothing is running until triggered
+	err := waitForShipment(ioutil.Discard, "user", "token", "my-app", "dev", 10*time.Millisecond)
+	assert.NotNil(t, err)
+
+	_, err = harborClient("", "").Trigger.Trigger(context.Background(), "my-app", "dev")
+	assert.Nil(t, err)
+
+	err = waitForShipment(ioutil.Discard, "user", "token", "my-app", "dev", time.Second)
+	assert.Nil(t, err)
+}
@@ -0,0 +1,43 @@
+package client
+
+import (
+	"context"
+)
+
+// BargesService provides barge and group metadata
+type BargesService interface {
+	GetBarges(ctx context.Context) (*BargeResults, error)
+	GetGroup(ctx context.Context, id string) (*Group, error)
+}
+
+type bargesService struct {
+	t *transport
+}
+
+// GetBarges returns a list of harbor barges
+func (s *bargesService) GetBarges(ctx context.Context) (*BargeResults, error) {
+	uri, err := buildURI(s.t.config.BargesURI, "/barges")
+	if err != nil {
+		return nil, err
+	}
+
+	var result BargeResults
+	if err := s.t.getJSON(ctx, uri, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetGroup returns the members of a harbor group
+func (s *bargesService) GetGroup(ctx context.Context, id string) (*Group, error) {
+	uri, err := buildURI(s.t.config.BargesURI, "/harbor/groups/{id}", "id", id)
+	if err != nil {
+		return nil, err
+	}
+
+	var result Group
+	if err := s.t.getJSON(ctx, uri, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
@@ -0,0 +1,25 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// CatalogitService catalogs container images for authenticated users
+type CatalogitService interface {
+	Catalog(ctx context.Context, container CatalogitContainer) (string, error)
+}
+
+type catalogitService struct {
+	t *transport
+}
+
+// Catalog adds a container image to the catalog and returns the api's response message
+func (s *catalogitService) Catalog(ctx context.Context, container CatalogitContainer) (string, error) {
+	uri, err := buildURI(s.t.config.CatalogitURI, "/v1/containers")
+	if err != nil {
+		return "", err
+	}
+	_, body, err := s.t.do(ctx, http.MethodPost, uri, nil, container)
+	return string(body), err
+}
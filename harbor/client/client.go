@@ -0,0 +1,176 @@
+// Package client provides an error-returning client for the Harbor APIs (shipit, helmit, trigger, customs, catalogit and barges).
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+
+	"github.com/jtacoma/uritemplates"
+)
+
+// Config contains the settings used to build a Client
+type Config struct {
+	ShipitURI    string
+	HelmitURI    string
+	TriggerURI   string
+	CustomsURI   string
+	CatalogitURI string
+	BargesURI    string
+
+	// Username and Token are sent to apis that require authentication
+	Username string
+	Token    string
+
+	// HTTPClient is used to make requests (defaults to http.DefaultClient)
+	HTTPClient *http.Client
+
+	// Logger receives verbose request/response logging when specified
+	Logger *log.Logger
+}
+
+// Client is a Harbor API client
+type Client struct {
+	Shipit    ShipitService
+	Helmit    HelmitService
+	Trigger   TriggerService
+	Customs   CustomsService
+	Catalogit CatalogitService
+	Barges    BargesService
+}
+
+// New returns a Client built from a Config
+func New(config Config) *Client {
+	if config.HTTPClient == nil {
+		config.HTTPClient = http.DefaultClient
+	}
+	t := &transport{config: config}
+	return &Client{
+		Shipit:    &shipitService{t},
+		Helmit:    &helmitService{t},
+		Trigger:   &triggerService{t},
+		Customs:   &customsService{t},
+		Catalogit: &catalogitService{t},
+		Barges:    &bargesService{t},
+	}
+}
+
+//shared http plumbing used by all services
+type transport struct {
+	config Config
+}
+
+type header struct {
+	name  string
+	value string
+}
+
+//returns the headers used to authenticate a user
+func (t *transport) auth() []header {
+	if t.config.Token == "" {
+		return nil
+	}
+	return []header{
+		{name: "x-username", value: t.config.Username},
+		{name: "x-token", value: t.config.Token},
+	}
+}
+
+func (t *transport) logf(format string, v ...interface{}) {
+	if t.config.Logger != nil {
+		t.config.Logger.Printf(format, v...)
+	}
+}
+
+//expands a uri template with the specified name/value pairs
+func buildURI(baseURI string, template string, params ...string) (string, error) {
+	uriTemplate, err := uritemplates.Parse(baseURI + template)
+	if err != nil {
+		return "", err
+	}
+	values := make(map[string]interface{})
+	for i := 0; i+1 < len(params); i += 2 {
+		values[params[i]] = params[i+1]
+	}
+	return uriTemplate.Expand(values)
+}
+
+//issues an http request, returning an error for network failures and non-success status codes
+func (t *transport) do(ctx context.Context, method string, uri string, headers []header, data interface{}, expectedStatus ...int) (*http.Response, []byte, error) {
+	res, err := t.send(ctx, method, uri, headers, data)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return res, nil, err
+	}
+
+	t.logf("status code = %v", res.StatusCode)
+	if len(body) > 0 {
+		t.logf("%s", body)
+	}
+
+	if res.StatusCode == http.StatusNotFound {
+		return res, body, ErrNotFound
+	}
+
+	if len(expectedStatus) == 0 {
+		expectedStatus = []int{http.StatusOK}
+	}
+	for _, status := range expectedStatus {
+		if res.StatusCode == status {
+			return res, body, nil
+		}
+	}
+
+	return res, body, &APIError{
+		StatusCode: res.StatusCode,
+		Body:       string(body),
+	}
+}
+
+//sends an http request and returns the raw response (the caller is responsible for closing the body)
+func (t *transport) send(ctx context.Context, method string, uri string, headers []header, data interface{}) (*http.Response, error) {
+	t.logf("%v %v", method, uri)
+
+	var reader io.Reader
+	if data != nil {
+		b, err := json.Marshal(data)
+		if err != nil {
+			return nil, err
+		}
+		t.logf("%s", b)
+		reader = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequest(method, uri, reader)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+
+	if data != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for _, h := range headers {
+		req.Header.Set(h.name, h.value)
+	}
+
+	return t.config.HTTPClient.Do(req)
+}
+
+//issues an http request and deserializes a json response
+func (t *transport) getJSON(ctx context.Context, uri string, headers []header, result interface{}) error {
+	_, body, err := t.do(ctx, http.MethodGet, uri, headers, nil)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, result)
+}
@@ -0,0 +1,120 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestClient(handler http.HandlerFunc) (*Client, *httptest.Server) {
+	server := httptest.NewServer(handler)
+	c := New(Config{
+		ShipitURI:  server.URL,
+		HelmitURI:  server.URL,
+		TriggerURI: server.URL,
+		CustomsURI: server.URL,
+		BargesURI:  server.URL,
+		Username:   "user",
+		Token:      "token",
+	})
+	return c, server
+}
+
+func TestGetShipmentEnvironment(t *testing.T) {
+	c, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/shipment/my-app/environment/dev/", r.URL.Path)
+		assert.Equal(t, "user", r.Header.Get("x-username"))
+		assert.Equal(t, "token", r.Header.Get("x-token"))
+		json.NewEncoder(w).Encode(ShipmentEnvironment{
+			Name:           "dev",
+			ParentShipment: ParentShipment{Name: "my-app"},
+		})
+	})
+	defer server.Close()
+
+	result, err := c.Shipit.GetShipmentEnvironment(context.Background(), "my-app", "dev")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "dev", result.Name)
+	assert.Equal(t, "my-app", result.ParentShipment.Name)
+}
+
+func TestGetShipmentEnvironmentNotFound(t *testing.T) {
+	c, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	defer server.Close()
+
+	result, err := c.Shipit.GetShipmentEnvironment(context.Background(), "my-app", "dev")
+
+	assert.Nil(t, result)
+	assert.Equal(t, ErrNotFound, err)
+}
+
+func TestAPIError(t *testing.T) {
+	c, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("boom"))
+	})
+	defer server.Close()
+
+	err := c.Shipit.UpdateProvider(context.Background(), "my-app", "dev", ProviderPayload{Name: "ec2", Replicas: 2})
+
+	apiErr, ok := err.(*APIError)
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusInternalServerError, apiErr.StatusCode)
+	assert.Equal(t, "boom", apiErr.Body)
+}
+
+func TestCreateEnvVar(t *testing.T) {
+	c, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/v1/shipment/my-app/environment/dev/container/web/envvars/", r.URL.Path)
+		b, _ := ioutil.ReadAll(r.Body)
+		var envVar EnvVarPayload
+		json.Unmarshal(b, &envVar)
+		assert.Equal(t, "FOO", envVar.Name)
+		w.WriteHeader(http.StatusCreated)
+	})
+	defer server.Close()
+
+	err := c.Shipit.CreateEnvVar(context.Background(), "my-app", "dev", "web", EnvVarPayload{Name: "FOO", Value: "bar", Type: "basic"})
+
+	assert.Nil(t, err)
+}
+
+func TestTrigger(t *testing.T) {
+	c, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/my-app/dev/ec2", r.URL.Path)
+		w.Write([]byte(`{"message":["my-app.dev.services.ec2.dmtio.net:80"]}`))
+	})
+	defer server.Close()
+
+	messages, err := c.Trigger.Trigger(context.Background(), "my-app", "dev")
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"my-app.dev.services.ec2.dmtio.net:80"}, messages)
+}
+
+func TestIsContainerVersionCataloged(t *testing.T) {
+	c, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/catalog/web/1.0/" {
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	})
+	defer server.Close()
+
+	cataloged, err := c.Customs.IsContainerVersionCataloged(context.Background(), "web", "1.0")
+	assert.Nil(t, err)
+	assert.True(t, cataloged)
+
+	cataloged, err = c.Customs.IsContainerVersionCataloged(context.Background(), "web", "2.0")
+	assert.Nil(t, err)
+	assert.False(t, cataloged)
+}
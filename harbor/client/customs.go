@@ -0,0 +1,58 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// CustomsService catalogs and deploys container images using shipment build tokens
+type CustomsService interface {
+	IsContainerVersionCataloged(ctx context.Context, name string, version string) (bool, error)
+	Deploy(ctx context.Context, shipment string, env string, provider string, buildToken string, request DeployRequest) error
+	Catalog(ctx context.Context, shipment string, env string, provider string, buildToken string, container CatalogitContainer) error
+}
+
+type customsService struct {
+	t *transport
+}
+
+func buildTokenHeader(buildToken string) []header {
+	return []header{{name: "x-build-token", value: buildToken}}
+}
+
+// IsContainerVersionCataloged determines whether or not a container/version exists in the catalog
+func (s *customsService) IsContainerVersionCataloged(ctx context.Context, name string, version string) (bool, error) {
+	uri, err := buildURI(s.t.config.CustomsURI, "/catalog/{name}/{version}/", "name", name, "version", version)
+	if err != nil {
+		return false, err
+	}
+
+	_, _, err = s.t.do(ctx, http.MethodGet, uri, nil, nil)
+	if err == ErrNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Deploy deploys (and optionally catalogs) a shipment container to an environment
+func (s *customsService) Deploy(ctx context.Context, shipment string, env string, provider string, buildToken string, request DeployRequest) error {
+	uri, err := buildURI(s.t.config.CustomsURI, "/deploy/{shipment}/{env}/{provider}", "shipment", shipment, "env", env, "provider", provider)
+	if err != nil {
+		return err
+	}
+	_, _, err = s.t.do(ctx, http.MethodPost, uri, buildTokenHeader(buildToken), request)
+	return err
+}
+
+// Catalog catalogs a container image
+func (s *customsService) Catalog(ctx context.Context, shipment string, env string, provider string, buildToken string, container CatalogitContainer) error {
+	uri, err := buildURI(s.t.config.CustomsURI, "/catalog/{shipment}/{env}/{provider}", "shipment", shipment, "env", env, "provider", provider)
+	if err != nil {
+		return err
+	}
+	_, _, err = s.t.do(ctx, http.MethodPost, uri, buildTokenHeader(buildToken), container)
+	return err
+}
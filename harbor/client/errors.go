@@ -0,0 +1,19 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNotFound is returned when a requested resource does not exist
+var ErrNotFound = errors.New("not found")
+
+// APIError is returned when an api responds with an unexpected status code
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("api returned a %v: %v", e.StatusCode, e.Body)
+}
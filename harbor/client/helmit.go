@@ -0,0 +1,81 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+// HelmitService provides access to running containers (status, events and logs)
+type HelmitService interface {
+	GetLogs(ctx context.Context, barge string, shipment string, env string) (*HelmitResponse, error)
+	GetLogStream(ctx context.Context, uri string) (io.ReadCloser, error)
+	GetShipmentStatus(ctx context.Context, barge string, shipment string, env string) (*ShipmentStatus, error)
+	GetShipmentEvents(ctx context.Context, barge string, shipment string, env string) (*ShipmentEventResult, error)
+}
+
+type helmitService struct {
+	t *transport
+}
+
+func (s *helmitService) uri(template string, barge string, shipment string, env string) (string, error) {
+	return buildURI(s.t.config.HelmitURI, template, "barge", barge, "shipment", shipment, "env", env)
+}
+
+// GetLogs returns the recent logs for all containers in a shipment environment
+func (s *helmitService) GetLogs(ctx context.Context, barge string, shipment string, env string) (*HelmitResponse, error) {
+	uri, err := s.uri("/harbor/{barge}/{shipment}/{env}", barge, shipment, env)
+	if err != nil {
+		return nil, err
+	}
+
+	var result HelmitResponse
+	if err := s.t.getJSON(ctx, uri, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetLogStream opens a container's log stream (which never ends on its own).  The caller must close the stream.
+func (s *helmitService) GetLogStream(ctx context.Context, uri string) (io.ReadCloser, error) {
+	res, err := s.t.send(ctx, http.MethodGet, uri, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		if res.StatusCode == http.StatusNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, &APIError{StatusCode: res.StatusCode}
+	}
+	return res.Body, nil
+}
+
+// GetShipmentStatus returns the running status of a shipment
+func (s *helmitService) GetShipmentStatus(ctx context.Context, barge string, shipment string, env string) (*ShipmentStatus, error) {
+	uri, err := s.uri("/shipment/status/{barge}/{shipment}/{env}", barge, shipment, env)
+	if err != nil {
+		return nil, err
+	}
+
+	var result ShipmentStatus
+	if err := s.t.getJSON(ctx, uri, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetShipmentEvents returns the orchestration events for a shipment environment
+func (s *helmitService) GetShipmentEvents(ctx context.Context, barge string, shipment string, env string) (*ShipmentEventResult, error) {
+	uri, err := s.uri("/shipment/events/{barge}/{shipment}/{env}", barge, shipment, env)
+	if err != nil {
+		return nil, err
+	}
+
+	var result ShipmentEventResult
+	if err := s.t.getJSON(ctx, uri, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
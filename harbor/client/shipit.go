@@ -0,0 +1,214 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// ShipitService manages shipment environments, containers, ports, providers and env vars
+type ShipitService interface {
+	GetShipmentEnvironment(ctx context.Context, shipment string, env string) (*ShipmentEnvironment, error)
+	CreateShipmentEnvironment(ctx context.Context, shipment ShipmentEnvironment) error
+	UpdateShipmentEnvironment(ctx context.Context, shipment string, env string, request UpdateShipmentEnvironmentRequest) error
+	DeleteShipmentEnvironment(ctx context.Context, shipment string, env string) error
+	UpdateProvider(ctx context.Context, shipment string, env string, provider ProviderPayload) error
+	CreateContainer(ctx context.Context, shipment string, env string, container ContainerPayload) error
+	UpdateContainer(ctx context.Context, shipment string, env string, container ContainerPayload) error
+	DeleteContainer(ctx context.Context, shipment string, env string, container string) error
+	CreatePort(ctx context.Context, shipment string, env string, container string, port PortPayload) error
+	UpdatePort(ctx context.Context, shipment string, env string, container string, port UpdatePortRequest) error
+
+	// env var operations apply to the environment level when container is empty
+	GetEnvVar(ctx context.Context, shipment string, env string, container string, name string) (*EnvVarPayload, error)
+	CreateEnvVar(ctx context.Context, shipment string, env string, container string, envVar EnvVarPayload) error
+	UpdateEnvVar(ctx context.Context, shipment string, env string, container string, envVar EnvVarPayload) error
+	DeleteEnvVar(ctx context.Context, shipment string, env string, container string, name string) error
+}
+
+type shipitService struct {
+	t *transport
+}
+
+func (s *shipitService) uri(template string, params ...string) (string, error) {
+	return buildURI(s.t.config.ShipitURI, template, params...)
+}
+
+//returns the uri of an env var collection (environment or container level)
+func (s *shipitService) envVarsURI(shipment string, env string, container string) (string, error) {
+	if container == "" {
+		return s.uri("/v1/shipment/{shipment}/environment/{env}/envvars/", "shipment", shipment, "env", env)
+	}
+	return s.uri("/v1/shipment/{shipment}/environment/{env}/container/{container}/envvars/", "shipment", shipment, "env", env, "container", container)
+}
+
+//returns the uri of a single env var (environment or container level)
+func (s *shipitService) envVarURI(shipment string, env string, container string, name string) (string, error) {
+	if container == "" {
+		return s.uri("/v1/shipment/{shipment}/environment/{env}/envvar/{envvar}", "shipment", shipment, "env", env, "envvar", name)
+	}
+	return s.uri("/v1/shipment/{shipment}/environment/{env}/container/{container}/envvar/{envvar}", "shipment", shipment, "env", env, "container", container, "envvar", name)
+}
+
+// GetShipmentEnvironment returns a shipment environment or ErrNotFound
+func (s *shipitService) GetShipmentEnvironment(ctx context.Context, shipment string, env string) (*ShipmentEnvironment, error) {
+	uri, err := s.uri("/v1/shipment/{shipment}/environment/{env}/", "shipment", shipment, "env", env)
+	if err != nil {
+		return nil, err
+	}
+
+	var result ShipmentEnvironment
+	if err := s.t.getJSON(ctx, uri, s.t.auth(), &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// CreateShipmentEnvironment bulk saves a new shipment/environment
+func (s *shipitService) CreateShipmentEnvironment(ctx context.Context, shipment ShipmentEnvironment) error {
+	uri, err := s.uri("/v1/bulk/shipments")
+	if err != nil {
+		return err
+	}
+
+	shipment.Username = s.t.config.Username
+	shipment.Token = s.t.config.Token
+
+	_, body, err := s.t.do(ctx, http.MethodPost, uri, s.t.auth(), shipment, http.StatusCreated)
+	if err != nil {
+		return err
+	}
+
+	//api returns an object with an errors property that is
+	//false when there are no errors and an object if there are
+	if !strings.Contains(string(body), "errors\": false") {
+		return errors.New("creating shipment was not successful: " + string(body))
+	}
+
+	return nil
+}
+
+// UpdateShipmentEnvironment updates shipment/environment-level configuration
+func (s *shipitService) UpdateShipmentEnvironment(ctx context.Context, shipment string, env string, request UpdateShipmentEnvironmentRequest) error {
+	uri, err := s.uri("/v1/shipment/{shipment}/environment/{env}", "shipment", shipment, "env", env)
+	if err != nil {
+		return err
+	}
+	_, _, err = s.t.do(ctx, http.MethodPut, uri, s.t.auth(), request)
+	return err
+}
+
+// DeleteShipmentEnvironment deletes a shipment/environment
+func (s *shipitService) DeleteShipmentEnvironment(ctx context.Context, shipment string, env string) error {
+	uri, err := s.uri("/v1/shipment/{shipment}/environment/{env}", "shipment", shipment, "env", env)
+	if err != nil {
+		return err
+	}
+	_, _, err = s.t.do(ctx, http.MethodDelete, uri, s.t.auth(), nil)
+	return err
+}
+
+// UpdateProvider updates provider configuration
+func (s *shipitService) UpdateProvider(ctx context.Context, shipment string, env string, provider ProviderPayload) error {
+	uri, err := s.uri("/v1/shipment/{shipment}/environment/{env}/provider/{provider}", "shipment", shipment, "env", env, "provider", "ec2")
+	if err != nil {
+		return err
+	}
+	_, _, err = s.t.do(ctx, http.MethodPut, uri, s.t.auth(), provider)
+	return err
+}
+
+// UpdateContainer updates a container (e.g., its image)
+func (s *shipitService) UpdateContainer(ctx context.Context, shipment string, env string, container ContainerPayload) error {
+	uri, err := s.uri("/v1/shipment/{shipment}/environment/{env}/container/{container}", "shipment", shipment, "env", env, "container", container.Name)
+	if err != nil {
+		return err
+	}
+	_, _, err = s.t.do(ctx, http.MethodPut, uri, s.t.auth(), container)
+	return err
+}
+
+// CreateContainer adds a container (name and image) to a shipment environment
+func (s *shipitService) CreateContainer(ctx context.Context, shipment string, env string, container ContainerPayload) error {
+	uri, err := s.uri("/v1/shipment/{shipment}/environment/{env}/containers", "shipment", shipment, "env", env)
+	if err != nil {
+		return err
+	}
+	_, _, err = s.t.do(ctx, http.MethodPost, uri, s.t.auth(), ContainerPayload{Name: container.Name, Image: container.Image}, http.StatusCreated)
+	return err
+}
+
+// DeleteContainer removes a container from a shipment environment
+func (s *shipitService) DeleteContainer(ctx context.Context, shipment string, env string, container string) error {
+	uri, err := s.uri("/v1/shipment/{shipment}/environment/{env}/container/{container}", "shipment", shipment, "env", env, "container", container)
+	if err != nil {
+		return err
+	}
+	_, _, err = s.t.do(ctx, http.MethodDelete, uri, s.t.auth(), nil)
+	return err
+}
+
+// CreatePort adds a port to a container
+func (s *shipitService) CreatePort(ctx context.Context, shipment string, env string, container string, port PortPayload) error {
+	uri, err := s.uri("/v1/shipment/{shipment}/environment/{env}/container/{container}/ports", "shipment", shipment, "env", env, "container", container)
+	if err != nil {
+		return err
+	}
+	_, _, err = s.t.do(ctx, http.MethodPost, uri, s.t.auth(), port, http.StatusCreated)
+	return err
+}
+
+// UpdatePort updates a container port
+func (s *shipitService) UpdatePort(ctx context.Context, shipment string, env string, container string, port UpdatePortRequest) error {
+	uri, err := s.uri("/v1/shipment/{shipment}/environment/{env}/container/{container}/port/{port}", "shipment", shipment, "env", env, "container", container, "port", port.Name)
+	if err != nil {
+		return err
+	}
+	_, _, err = s.t.do(ctx, http.MethodPut, uri, s.t.auth(), port)
+	return err
+}
+
+// GetEnvVar returns an env var or ErrNotFound
+func (s *shipitService) GetEnvVar(ctx context.Context, shipment string, env string, container string, name string) (*EnvVarPayload, error) {
+	uri, err := s.envVarURI(shipment, env, container, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var result EnvVarPayload
+	if err := s.t.getJSON(ctx, uri, s.t.auth(), &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// CreateEnvVar creates a new env var
+func (s *shipitService) CreateEnvVar(ctx context.Context, shipment string, env string, container string, envVar EnvVarPayload) error {
+	uri, err := s.envVarsURI(shipment, env, container)
+	if err != nil {
+		return err
+	}
+	_, _, err = s.t.do(ctx, http.MethodPost, uri, s.t.auth(), envVar, http.StatusCreated)
+	return err
+}
+
+// UpdateEnvVar updates an existing env var
+func (s *shipitService) UpdateEnvVar(ctx context.Context, shipment string, env string, container string, envVar EnvVarPayload) error {
+	uri, err := s.envVarURI(shipment, env, container, envVar.Name)
+	if err != nil {
+		return err
+	}
+	_, _, err = s.t.do(ctx, http.MethodPut, uri, s.t.auth(), envVar)
+	return err
+}
+
+// DeleteEnvVar deletes an env var
+func (s *shipitService) DeleteEnvVar(ctx context.Context, shipment string, env string, container string, name string) error {
+	uri, err := s.envVarURI(shipment, env, container, name)
+	if err != nil {
+		return err
+	}
+	_, _, err = s.t.do(ctx, http.MethodDelete, uri, s.t.auth(), nil)
+	return err
+}
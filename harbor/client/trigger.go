@@ -0,0 +1,70 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// TriggerService deploys shipment environments and reports on their load balancers
+type TriggerService interface {
+	Trigger(ctx context.Context, shipment string, env string) ([]string, error)
+	GetLoadBalancerStatus(ctx context.Context, shipment string, env string, provider string) (*LoadBalancer, error)
+}
+
+type triggerService struct {
+	t *transport
+}
+
+// Trigger deploys a shipment environment and returns the messages from the trigger api
+func (s *triggerService) Trigger(ctx context.Context, shipment string, env string) ([]string, error) {
+	uri, err := buildURI(s.t.config.TriggerURI, "/{shipment}/{env}/ec2", "shipment", shipment, "env", env)
+	if err != nil {
+		return nil, err
+	}
+
+	_, body, err := s.t.do(ctx, http.MethodPost, uri, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	//trigger api returns both single and multiple messages:
+
+	//example responses...
+	//error: {"message":"Could not parse docker image data from http://registry.services.dmtio.net/v2/mss-poc-thingproxy/manifests/: 757: unexpected token at '404 page not found\n'\n"}
+	//success: {"message":["compose-test.dev.services.ec2.dmtio.net:5000"]}
+
+	var result []string
+	if strings.Contains(string(body), "message\":\"") {
+		//convert single message into an array for consistency
+		var response TriggerResponseSingle
+		if err := json.Unmarshal(body, &response); err != nil {
+			return nil, err
+		}
+		result = append(result, response.Message)
+	} else if strings.Contains(string(body), "message\":[") {
+		//multiple messages
+		var response TriggerResponseMultiple
+		if err := json.Unmarshal(body, &response); err != nil {
+			return nil, err
+		}
+		result = response.Messages
+	}
+
+	return result, nil
+}
+
+// GetLoadBalancerStatus returns the load balancer for a shipment environment
+func (s *triggerService) GetLoadBalancerStatus(ctx context.Context, shipment string, env string, provider string) (*LoadBalancer, error) {
+	uri, err := buildURI(s.t.config.TriggerURI, "/v2/loadbalancer/status/{shipment}/{env}/{provider}", "shipment", shipment, "env", env, "provider", provider)
+	if err != nil {
+		return nil, err
+	}
+
+	var result LoadBalancer
+	if err := s.t.getJSON(ctx, uri, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
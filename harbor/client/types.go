@@ -0,0 +1,222 @@
+package client
+
+import (
+	"time"
+)
+
+// ShipmentEnvironment represents a shipment/environment combination
+type ShipmentEnvironment struct {
+	Username         string             `json:"username"`
+	Token            string             `json:"token"`
+	Name             string             `json:"name"`
+	EnvVars          []EnvVarPayload    `json:"envVars"`
+	Containers       []ContainerPayload `json:"containers"`
+	Providers        []ProviderPayload  `json:"providers"`
+	ParentShipment   ParentShipment     `json:"parentShipment"`
+	BuildToken       string             `json:"buildToken,omitempty"`
+	EnableMonitoring bool               `json:"enableMonitoring"`
+	IamRole          string             `json:"iamRole"`
+}
+
+// The ParentShipment of the shipmentModel
+type ParentShipment struct {
+	Name    string          `json:"name"`
+	EnvVars []EnvVarPayload `json:"envVars"`
+	Group   string          `json:"group"`
+}
+
+// EnvVarPayload represents EnvVar
+type EnvVarPayload struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	Type  string `json:"type,omitempty"`
+}
+
+// PortPayload represents a port
+type PortPayload struct {
+	Name                string `json:"name,omitempty"`
+	Value               int    `json:"value,omitempty"`
+	Protocol            string `json:"protocol,omitempty"`
+	Healthcheck         string `json:"healthcheck,omitempty"`
+	Primary             bool   `json:"primary,omitempty"`
+	External            bool   `json:"external,omitempty"`
+	PublicVip           bool   `json:"public_vip,omitempty"`
+	PublicPort          int    `json:"public_port,omitempty"`
+	EnableProxyProtocol bool   `json:"enable_proxy_protocol,omitempty"`
+	SslArn              string `json:"ssl_arn,omitempty"`
+	SslManagementType   string `json:"ssl_management_type,omitempty"`
+	HealthcheckTimeout  *int   `json:"healthcheck_timeout,omitempty"`
+	HealthcheckInterval *int   `json:"healthcheck_interval,omitempty"`
+	LBType              string `json:"lbtype,omitempty"`
+}
+
+// ContainerPayload represents a container payload
+type ContainerPayload struct {
+	Name    string          `json:"name,omitempty"`
+	Image   string          `json:"image,omitempty"`
+	EnvVars []EnvVarPayload `json:"envVars,omitempty"`
+	Ports   []PortPayload   `json:"ports,omitempty"`
+}
+
+// ProviderPayload represents a provider payload
+type ProviderPayload struct {
+	Name     string          `json:"name"`
+	Replicas int             `json:"replicas"`
+	EnvVars  []EnvVarPayload `json:"envVars,omitempty"`
+	Barge    string          `json:"barge,omitempty"`
+}
+
+// TriggerResponseSingle is the payload returned from the trigger api
+type TriggerResponseSingle struct {
+	Message string `json:"message,omitempty"`
+}
+
+// TriggerResponseMultiple is the payload returned from the trigger api
+type TriggerResponseMultiple struct {
+	Messages []string `json:"message,omitempty"`
+}
+
+// CatalogitContainer is what gets sent to catalog to post a new image
+type CatalogitContainer struct {
+	Name    string `json:"name"`
+	Image   string `json:"image"`
+	Version string `json:"version"`
+}
+
+// DeployRequest represents a request to deploy a shipment/container to an environment
+type DeployRequest struct {
+	Name    string `json:"name"`
+	Image   string `json:"image"`
+	Version string `json:"version"`
+	Catalog bool   `json:"catalog"`
+}
+
+// UpdateShipmentEnvironmentRequest represents a request to update a shipment/environment
+type UpdateShipmentEnvironmentRequest struct {
+	EnableMonitoring bool   `json:"enableMonitoring"`
+	IamRole          string `json:"iamRole,omitempty"`
+}
+
+// UpdatePortRequest represents a request to update a port
+type UpdatePortRequest struct {
+	Name                string `json:"name"`
+	HealthcheckTimeout  *int   `json:"healthcheck_timeout,omitempty"`
+	HealthcheckInterval *int   `json:"healthcheck_interval,omitempty"`
+	SslArn              string `json:"ssl_arn,omitempty"`
+	SslManagementType   string `json:"ssl_management_type,omitempty"`
+}
+
+// BargeResults represents a barge payload
+type BargeResults struct {
+	Barges []Barge `json:"barges"`
+}
+
+// Barge represents a harbor barge
+type Barge struct {
+	Name           string   `json:"name"`
+	AccountID      string   `json:"accountId"`
+	AccountName    string   `json:"accountName"`
+	Vpc            string   `json:"vpc"`
+	PrivateSubnets []string `json:"privateSubnets"`
+	PublicSubnets  []string `json:"publicSubnets"`
+}
+
+// Group represents a harbor group
+type Group struct {
+	ID     string   `json:"id"`
+	Users  []string `json:"users"`
+	Admins []string `json:"admins"`
+}
+
+// LoadBalancer represents a harbor load balancer
+type LoadBalancer struct {
+	Name                  string `json:"name"`
+	Type                  string `json:"type"`
+	Public                bool   `json:"public"`
+	ARN                   string `json:"arn"`
+	DNSName               string `json:"dnsName"`
+	CanonicalHostedZoneID string `json:"canonicalHostedZoneId"`
+	VpcID                 string `json:"vpcId"`
+	State                 string `json:"state"`
+}
+
+// HelmitContainer represents a single container instance in harbor
+type HelmitContainer struct {
+	Name      string   `json:"name"`
+	ID        string   `json:"id"`
+	Image     string   `json:"image"`
+	Logstream string   `json:"log_stream"`
+	Logs      []string `json:"logs"`
+}
+
+// HelmitReplica represents a single running replica in harbor
+type HelmitReplica struct {
+	Host       string            `json:"host"`
+	Provider   string            `json:"provider"`
+	Containers []HelmitContainer `json:"containers"`
+}
+
+// HelmitResponse represents a response from helmit
+type HelmitResponse struct {
+	Error    bool            `json:"error"`
+	Replicas []HelmitReplica `json:"replicas"`
+}
+
+//ShipmentStatus represents the deployed status of a shipment
+type ShipmentStatus struct {
+	Namespace string `json:"namespace"`
+	Version   string `json:"version"`
+	Status    struct {
+		Phase      string            `json:"phase"`
+		Containers []ContainerStatus `json:"containers"`
+	} `json:"status"`
+	AverageRestarts float32 `json:"averageRestarts"`
+}
+
+// ContainerStatus represents the status of a single running container
+type ContainerStatus struct {
+	ID        string                        `json:"id"`
+	Image     string                        `json:"image"`
+	Ready     bool                          `json:"ready"`
+	Restarts  int                           `json:"restarts"`
+	State     map[string]ContainerState     `json:"state"`
+	Status    string                        `json:"status"`
+	LastState map[string]ContainerLastState `json:"lastState"`
+}
+
+//ShipmentEventResult represents system events for a shipment/environment
+type ShipmentEventResult struct {
+	Namespace string          `json:"namespace"`
+	Version   string          `json:"version"`
+	Events    []ShipmentEvent `json:"events"`
+}
+
+//ShipmentEvent represents a shipment event
+type ShipmentEvent struct {
+	Type    string `json:"type"`
+	Count   int    `json:"count"`
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+	Source  struct {
+		Component string `json:"component"`
+	} `json:"source"`
+	FirstTimestamp time.Time `json:"firstTimestamp"`
+	LastTimestamp  time.Time `json:"lastTimestamp"`
+	StartTime      string    `json:"startTime,omitempty"`
+}
+
+// ContainerState represents a particular state of a container
+type ContainerState struct {
+	StartedAt time.Time `json:"startedAt"`
+	Reason    string    `json:"reason"`
+	Message   string    `json:"message"`
+}
+
+// ContainerLastState represents the last state of a container
+type ContainerLastState struct {
+	ExitCode    int       `json:"exitCode"`
+	Reason      string    `json:"reason"`
+	StartedAt   time.Time `json:"startedAt"`
+	FinishedAt  time.Time `json:"finishedAt"`
+	ContainerID string    `json:"containerID"`
+}
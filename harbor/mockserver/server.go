@@ -0,0 +1,799 @@
+// Package mockserver provides an in-memory fake of the Harbor apis used by harbor-compose (shipit, helmit, trigger, customs, catalogit, barges and auth).
+package mockserver
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/turnerlabs/harbor-compose/harbor/client"
+)
+
+// Server is an http.Handler that keeps harbor state in memory across calls
+type Server struct {
+	mu     sync.Mutex
+	state  *state
+	routes []route
+}
+
+type handlerFunc func(w http.ResponseWriter, r *http.Request, params []string)
+
+// a route matches an http method and a path pattern where "*" matches any single segment
+type route struct {
+	method  string
+	pattern []string
+	handler handlerFunc
+}
+
+// New returns a Server initialized with optional seed data
+func New(seed *Seed) *Server {
+	s := &Server{state: newState(seed)}
+
+	s.routes = []route{
+		//auth
+		s.route("POST", "/v1/auth/gettoken", s.getToken),
+		s.route("POST", "/v1/auth/checktoken", s.checkToken),
+		s.route("POST", "/v1/auth/destroytoken", s.destroyToken),
+
+		//telemetry
+		s.route("POST", "/v1/api/metric", s.ok),
+
+		//shipit
+		s.route("POST", "/v1/bulk/shipments", s.authenticated(s.createShipmentEnvironment)),
+		s.route("GET", "/v1/shipment/*/environment/*", s.authenticated(s.getShipmentEnvironment)),
+		s.route("PUT", "/v1/shipment/*/environment/*", s.authenticated(s.updateShipmentEnvironment)),
+		s.route("DELETE", "/v1/shipment/*/environment/*", s.authenticated(s.deleteShipmentEnvironment)),
+		s.route("PUT", "/v1/shipment/*/environment/*/provider/*", s.authenticated(s.updateProvider)),
+		s.route("POST", "/v1/shipment/*/environment/*/containers", s.authenticated(s.createContainer)),
+		s.route("PUT", "/v1/shipment/*/environment/*/container/*", s.authenticated(s.updateContainer)),
+		s.route("DELETE", "/v1/shipment/*/environment/*/container/*", s.authenticated(s.deleteContainer)),
+		s.route("POST", "/v1/shipment/*/environment/*/container/*/ports", s.authenticated(s.createPort)),
+		s.route("PUT", "/v1/shipment/*/environment/*/container/*/port/*", s.authenticated(s.updatePort)),
+		s.route("POST", "/v1/shipment/*/environment/*/envvars", s.authenticated(s.createEnvVar)),
+		s.route("GET", "/v1/shipment/*/environment/*/envvar/*", s.authenticated(s.getEnvVar)),
+		s.route("PUT", "/v1/shipment/*/environment/*/envvar/*", s.authenticated(s.updateEnvVar)),
+		s.route("DELETE", "/v1/shipment/*/environment/*/envvar/*", s.authenticated(s.deleteEnvVar)),
+		s.route("POST", "/v1/shipment/*/environment/*/container/*/envvars", s.authenticated(s.createEnvVar)),
+		s.route("GET", "/v1/shipment/*/environment/*/container/*/envvar/*", s.authenticated(s.getEnvVar)),
+		s.route("PUT", "/v1/shipment/*/environment/*/container/*/envvar/*", s.authenticated(s.updateEnvVar)),
+		s.route("DELETE", "/v1/shipment/*/environment/*/container/*/envvar/*", s.authenticated(s.deleteEnvVar)),
+
+		//helmit
+		s.route("GET", "/harbor/*/*/*", s.getLogs),
+		s.route("GET", "/shipment/status/*/*/*", s.getShipmentStatus),
+		s.route("GET", "/shipment/events/*/*/*", s.getShipmentEvents),
+
+		//trigger
+		s.route("POST", "/*/*/ec2", s.trigger),
+		s.route("GET", "/v2/loadbalancer/status/*/*/*", s.getLoadBalancerStatus),
+
+		//customs
+		s.route("GET", "/catalog/*/*", s.isCataloged),
+		s.route("POST", "/catalog/*/*/*", s.customsCatalog),
+		s.route("POST", "/deploy/*/*/*", s.customsDeploy),
+
+		//catalogit
+		s.route("POST", "/v1/containers", s.catalogit),
+
+		//barges
+		s.route("GET", "/barges", s.getBarges),
+		s.route("GET", "/harbor/groups/*", s.getGroup),
+	}
+
+	return s
+}
+
+func (s *Server) route(method string, pattern string, handler handlerFunc) route {
+	return route{
+		method:  method,
+		pattern: segments(pattern),
+		handler: handler,
+	}
+}
+
+// splits a path into segments, ignoring leading and trailing slashes
+func segments(path string) []string {
+	trimmed := strings.Trim(path, "/")
+	if trimmed == "" {
+		return []string{}
+	}
+	return strings.Split(trimmed, "/")
+}
+
+// returns the wildcard values if the path matches the pattern
+func match(pattern []string, path []string) ([]string, bool) {
+	if len(pattern) != len(path) {
+		return nil, false
+	}
+	params := []string{}
+	for i, segment := range pattern {
+		if segment == "*" {
+			params = append(params, path[i])
+		} else if segment != path[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// ServeHTTP dispatches requests to the fake apis
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := segments(r.URL.Path)
+
+	//log streams never end, so they're served without holding the lock
+	if len(path) == 2 && path[0] == "logstream" && r.Method == "GET" {
+		s.streamLogs(w, r, path[1:])
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, rt := range s.routes {
+		if rt.method != r.Method {
+			continue
+		}
+		if params, ok := match(rt.pattern, path); ok {
+			rt.handler(w, r, params)
+			return
+		}
+	}
+
+	writeError(w, http.StatusNotFound, "route not found: "+r.Method+" "+r.URL.Path)
+}
+
+// requires a harbor token, like shipit does
+func (s *Server) authenticated(next handlerFunc) handlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, params []string) {
+		if r.Header.Get("x-token") == "" {
+			writeError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		next(w, r, params)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+func readJSON(r *http.Request, data interface{}) error {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, data)
+}
+
+func (s *Server) ok(w http.ResponseWriter, r *http.Request, params []string) {
+	writeJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+//auth
+
+func (s *Server) getToken(w http.ResponseWriter, r *http.Request, params []string) {
+	var request struct {
+		Username string `json:"username"`
+	}
+	if err := readJSON(r, &request); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"token":   "mock-token-" + request.Username,
+		"success": true,
+	})
+}
+
+func (s *Server) checkToken(w http.ResponseWriter, r *http.Request, params []string) {
+	var request struct {
+		Token string `json:"token"`
+	}
+	if err := readJSON(r, &request); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"type":    "user",
+		"success": request.Token != "",
+	})
+}
+
+func (s *Server) destroyToken(w http.ResponseWriter, r *http.Request, params []string) {
+	writeJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+//shipit
+
+// looks up a shipment environment and writes a 404 if it doesn't exist
+func (s *Server) findShipment(w http.ResponseWriter, shipment string, env string) *client.ShipmentEnvironment {
+	result := s.state.shipments[key(shipment, env)]
+	if result == nil {
+		writeError(w, http.StatusNotFound, "shipment environment not found")
+	}
+	return result
+}
+
+// looks up a container and writes a 404 if it doesn't exist
+func findContainer(w http.ResponseWriter, shipment *client.ShipmentEnvironment, name string) *client.ContainerPayload {
+	for i := range shipment.Containers {
+		if shipment.Containers[i].Name == name {
+			return &shipment.Containers[i]
+		}
+	}
+	writeError(w, http.StatusNotFound, "container not found")
+	return nil
+}
+
+func (s *Server) createShipmentEnvironment(w http.ResponseWriter, r *http.Request, params []string) {
+	var shipment client.ShipmentEnvironment
+	if err := readJSON(r, &shipment); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	k := key(shipment.ParentShipment.Name, shipment.Name)
+	if s.state.shipments[k] != nil {
+		writeJSON(w, http.StatusConflict, map[string]string{"errors": "shipment environment already exists"})
+		return
+	}
+
+	//credentials are only used for the request
+	shipment.Username = ""
+	shipment.Token = ""
+	if shipment.BuildToken == "" {
+		shipment.BuildToken = fmt.Sprintf("mock-build-token-%s-%s", shipment.ParentShipment.Name, shipment.Name)
+	}
+	if shipment.EnvVars == nil {
+		shipment.EnvVars = []client.EnvVarPayload{}
+	}
+
+	//shipit records the barge as an environment-level env var
+	for _, p := range shipment.Providers {
+		if p.Barge != "" && !hasEnvVar(shipment.EnvVars, "BARGE") {
+			shipment.EnvVars = append(shipment.EnvVars, client.EnvVarPayload{Name: "BARGE", Value: p.Barge, Type: "basic"})
+		}
+	}
+	s.state.shipments[k] = &shipment
+
+	//shipit returns an errors property that is false when successful
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write([]byte(`{"errors": false}`))
+}
+
+func hasEnvVar(envVars []client.EnvVarPayload, name string) bool {
+	for _, e := range envVars {
+		if e.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) getShipmentEnvironment(w http.ResponseWriter, r *http.Request, params []string) {
+	if shipment := s.findShipment(w, params[0], params[1]); shipment != nil {
+		writeJSON(w, http.StatusOK, shipment)
+	}
+}
+
+func (s *Server) updateShipmentEnvironment(w http.ResponseWriter, r *http.Request, params []string) {
+	shipment := s.findShipment(w, params[0], params[1])
+	if shipment == nil {
+		return
+	}
+	var request client.UpdateShipmentEnvironmentRequest
+	if err := readJSON(r, &request); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	shipment.EnableMonitoring = request.EnableMonitoring
+	if request.IamRole != "" {
+		shipment.IamRole = request.IamRole
+	}
+	writeJSON(w, http.StatusOK, shipment)
+}
+
+func (s *Server) deleteShipmentEnvironment(w http.ResponseWriter, r *http.Request, params []string) {
+	if shipment := s.findShipment(w, params[0], params[1]); shipment != nil {
+		k := key(params[0], params[1])
+		delete(s.state.shipments, k)
+		delete(s.state.status, k)
+		delete(s.state.events, k)
+		delete(s.state.logs, k)
+		delete(s.state.loadBalancers, k)
+		writeJSON(w, http.StatusOK, map[string]bool{"success": true})
+	}
+}
+
+func (s *Server) updateProvider(w http.ResponseWriter, r *http.Request, params []string) {
+	shipment := s.findShipment(w, params[0], params[1])
+	if shipment == nil {
+		return
+	}
+	var request client.ProviderPayload
+	if err := readJSON(r, &request); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	for i := range shipment.Providers {
+		if shipment.Providers[i].Name == params[2] {
+			shipment.Providers[i].Replicas = request.Replicas
+			if request.Barge != "" {
+				shipment.Providers[i].Barge = request.Barge
+			}
+			writeJSON(w, http.StatusOK, shipment.Providers[i])
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "provider not found")
+}
+
+func (s *Server) updateContainer(w http.ResponseWriter, r *http.Request, params []string) {
+	shipment := s.findShipment(w, params[0], params[1])
+	if shipment == nil {
+		return
+	}
+	container := findContainer(w, shipment, params[2])
+	if container == nil {
+		return
+	}
+	var request client.ContainerPayload
+	if err := readJSON(r, &request); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if request.Image != "" {
+		container.Image = request.Image
+	}
+	writeJSON(w, http.StatusOK, container)
+}
+
+func (s *Server) createContainer(w http.ResponseWriter, r *http.Request, params []string) {
+	shipment := s.findShipment(w, params[0], params[1])
+	if shipment == nil {
+		return
+	}
+	var container client.ContainerPayload
+	if err := readJSON(r, &container); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	for _, c := range shipment.Containers {
+		if c.Name == container.Name {
+			writeError(w, http.StatusConflict, "container already exists")
+			return
+		}
+	}
+	container.EnvVars = []client.EnvVarPayload{}
+	container.Ports = []client.PortPayload{}
+	shipment.Containers = append(shipment.Containers, container)
+	writeJSON(w, http.StatusCreated, container)
+}
+
+func (s *Server) deleteContainer(w http.ResponseWriter, r *http.Request, params []string) {
+	shipment := s.findShipment(w, params[0], params[1])
+	if shipment == nil {
+		return
+	}
+	for i := range shipment.Containers {
+		if shipment.Containers[i].Name == params[2] {
+			shipment.Containers = append(shipment.Containers[:i], shipment.Containers[i+1:]...)
+			writeJSON(w, http.StatusOK, map[string]bool{"success": true})
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "container not found")
+}
+
+func (s *Server) createPort(w http.ResponseWriter, r *http.Request, params []string) {
+	shipment := s.findShipment(w, params[0], params[1])
+	if shipment == nil {
+		return
+	}
+	container := findContainer(w, shipment, params[2])
+	if container == nil {
+		return
+	}
+	var port client.PortPayload
+	if err := readJSON(r, &port); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	for _, p := range container.Ports {
+		if p.Name == port.Name {
+			writeError(w, http.StatusConflict, "port already exists")
+			return
+		}
+	}
+	container.Ports = append(container.Ports, port)
+	writeJSON(w, http.StatusCreated, port)
+}
+
+func (s *Server) updatePort(w http.ResponseWriter, r *http.Request, params []string) {
+	shipment := s.findShipment(w, params[0], params[1])
+	if shipment == nil {
+		return
+	}
+	container := findContainer(w, shipment, params[2])
+	if container == nil {
+		return
+	}
+	var request client.UpdatePortRequest
+	if err := readJSON(r, &request); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	for i := range container.Ports {
+		if container.Ports[i].Name == params[3] {
+			if request.HealthcheckTimeout != nil {
+				container.Ports[i].HealthcheckTimeout = request.HealthcheckTimeout
+			}
+			if request.HealthcheckInterval != nil {
+				container.Ports[i].HealthcheckInterval = request.HealthcheckInterval
+			}
+			if request.SslArn != "" {
+				container.Ports[i].SslArn = request.SslArn
+				container.Ports[i].SslManagementType = request.SslManagementType
+			}
+			writeJSON(w, http.StatusOK, container.Ports[i])
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "port not found")
+}
+
+// returns the env vars for the environment (params = shipment, env) or a container (params = shipment, env, container)
+func (s *Server) envVars(w http.ResponseWriter, params []string) *[]client.EnvVarPayload {
+	shipment := s.findShipment(w, params[0], params[1])
+	if shipment == nil {
+		return nil
+	}
+	if len(params) == 2 {
+		return &shipment.EnvVars
+	}
+	container := findContainer(w, shipment, params[2])
+	if container == nil {
+		return nil
+	}
+	return &container.EnvVars
+}
+
+func (s *Server) createEnvVar(w http.ResponseWriter, r *http.Request, params []string) {
+	envVars := s.envVars(w, params)
+	if envVars == nil {
+		return
+	}
+	var envVar client.EnvVarPayload
+	if err := readJSON(r, &envVar); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if hasEnvVar(*envVars, envVar.Name) {
+		writeError(w, http.StatusConflict, "env var already exists")
+		return
+	}
+	*envVars = append(*envVars, envVar)
+	writeJSON(w, http.StatusCreated, envVar)
+}
+
+func (s *Server) getEnvVar(w http.ResponseWriter, r *http.Request, params []string) {
+	envVars := s.envVars(w, params[:len(params)-1])
+	if envVars == nil {
+		return
+	}
+	name := params[len(params)-1]
+	for _, e := range *envVars {
+		if e.Name == name {
+			writeJSON(w, http.StatusOK, e)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "env var not found")
+}
+
+func (s *Server) updateEnvVar(w http.ResponseWriter, r *http.Request, params []string) {
+	envVars := s.envVars(w, params[:len(params)-1])
+	if envVars == nil {
+		return
+	}
+	var envVar client.EnvVarPayload
+	if err := readJSON(r, &envVar); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	name := params[len(params)-1]
+	for i := range *envVars {
+		if (*envVars)[i].Name == name {
+			(*envVars)[i].Value = envVar.Value
+			(*envVars)[i].Type = envVar.Type
+			writeJSON(w, http.StatusOK, (*envVars)[i])
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "env var not found")
+}
+
+func (s *Server) deleteEnvVar(w http.ResponseWriter, r *http.Request, params []string) {
+	envVars := s.envVars(w, params[:len(params)-1])
+	if envVars == nil {
+		return
+	}
+	name := params[len(params)-1]
+	for i := range *envVars {
+		if (*envVars)[i].Name == name {
+			*envVars = append((*envVars)[:i], (*envVars)[i+1:]...)
+			writeJSON(w, http.StatusOK, map[string]bool{"success": true})
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "env var not found")
+}
+
+//helmit
+
+func (s *Server) getLogs(w http.ResponseWriter, r *http.Request, params []string) {
+	logs := s.state.logs[key(params[1], params[2])]
+	if logs == nil {
+		writeJSON(w, http.StatusOK, client.HelmitResponse{Replicas: []client.HelmitReplica{}})
+		return
+	}
+
+	//log streams are relative to wherever the server is running
+	result := client.HelmitResponse{Error: logs.Error}
+	for _, replica := range logs.Replicas {
+		copied := replica
+		copied.Containers = []client.HelmitContainer{}
+		for _, container := range replica.Containers {
+			if strings.HasPrefix(container.Logstream, "/") {
+				container.Logstream = "http://" + r.Host + container.Logstream
+			}
+			copied.Containers = append(copied.Containers, container)
+		}
+		result.Replicas = append(result.Replicas, copied)
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// streams a single log line (docker's multiplexed format) and then waits for the client to disconnect
+func (s *Server) streamLogs(w http.ResponseWriter, r *http.Request, params []string) {
+	w.WriteHeader(http.StatusOK)
+	header := []byte{1, 0, 0, 0, 0, 0, 0, 0}
+	line := fmt.Sprintf("%s streaming logs for %s\n", time.Now().UTC().Format(time.RFC3339), params[0])
+	w.Write(append(header, []byte(line)...))
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+	<-r.Context().Done()
+}
+
+func (s *Server) getShipmentStatus(w http.ResponseWriter, r *http.Request, params []string) {
+	k := key(params[1], params[2])
+	if status := s.state.status[k]; status != nil {
+		writeJSON(w, http.StatusOK, status)
+		return
+	}
+	if s.state.shipments[k] == nil {
+		writeError(w, http.StatusNotFound, "shipment environment not found")
+		return
+	}
+
+	//not yet triggered
+	status := client.ShipmentStatus{Namespace: k}
+	status.Status.Phase = "Pending"
+	status.Status.Containers = []client.ContainerStatus{}
+	writeJSON(w, http.StatusOK, status)
+}
+
+func (s *Server) getShipmentEvents(w http.ResponseWriter, r *http.Request, params []string) {
+	k := key(params[1], params[2])
+	events := s.state.events[k]
+	if events == nil {
+		events = []client.ShipmentEvent{}
+	}
+	writeJSON(w, http.StatusOK, client.ShipmentEventResult{
+		Namespace: k,
+		Events:    events,
+	})
+}
+
+//trigger
+
+func (s *Server) trigger(w http.ResponseWriter, r *http.Request, params []string) {
+	shipment := s.state.shipments[key(params[0], params[1])]
+	if shipment == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "shipment environment not found"})
+		return
+	}
+	s.rollout(shipment)
+
+	endpoint := fmt.Sprintf("%s.%s.services.ec2.dmtio.net", params[0], params[1])
+	for _, c := range shipment.Containers {
+		for _, p := range c.Ports {
+			if p.Primary {
+				endpoint = fmt.Sprintf("%s:%v", endpoint, p.PublicPort)
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string][]string{"message": {endpoint}})
+}
+
+func (s *Server) getLoadBalancerStatus(w http.ResponseWriter, r *http.Request, params []string) {
+	lb := s.state.loadBalancers[key(params[0], params[1])]
+	if lb == nil {
+		writeError(w, http.StatusNotFound, "load balancer not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, lb)
+}
+
+// simulates a successful deployment by replacing the running containers with the desired ones
+func (s *Server) rollout(shipment *client.ShipmentEnvironment) {
+	k := key(shipment.ParentShipment.Name, shipment.Name)
+	now := time.Now().UTC()
+
+	replicas := 0
+	barge := ""
+	for _, p := range shipment.Providers {
+		if p.Name == "ec2" {
+			replicas = p.Replicas
+			barge = p.Barge
+		}
+	}
+
+	status := client.ShipmentStatus{Namespace: k}
+	status.Status.Phase = "Running"
+	status.Status.Containers = []client.ContainerStatus{}
+	logs := client.HelmitResponse{Replicas: []client.HelmitReplica{}}
+	events := []client.ShipmentEvent{}
+
+	for i := 0; i < replicas; i++ {
+		replica := client.HelmitReplica{
+			Host:       fmt.Sprintf("ip-10-0-0-%v.ec2.internal", i+1),
+			Provider:   "ec2",
+			Containers: []client.HelmitContainer{},
+		}
+		for _, c := range shipment.Containers {
+			s.state.containerIDs++
+			id := fmt.Sprintf("%x", sha256.Sum256([]byte(fmt.Sprintf("%s/%s/%v", k, c.Name, s.state.containerIDs))))
+
+			status.Status.Containers = append(status.Status.Containers, client.ContainerStatus{
+				ID:     id,
+				Image:  c.Image,
+				Ready:  true,
+				Status: "running",
+				State: map[string]client.ContainerState{
+					"running": {StartedAt: now},
+				},
+				LastState: map[string]client.ContainerLastState{},
+			})
+
+			replica.Containers = append(replica.Containers, client.HelmitContainer{
+				Name:      c.Name,
+				ID:        id,
+				Image:     c.Image,
+				Logstream: "/logstream/" + id,
+				Logs:      []string{fmt.Sprintf("%s started %s on %s", now.Format(time.RFC3339), c.Image, replica.Host)},
+			})
+
+			event := client.ShipmentEvent{
+				Type:           "Normal",
+				Count:          1,
+				Reason:         "Pulled",
+				Message:        "Successfully pulled image \"" + c.Image + "\"",
+				FirstTimestamp: now,
+				LastTimestamp:  now,
+			}
+			event.Source.Component = "kubelet"
+			events = append(events, event)
+		}
+		logs.Replicas = append(logs.Replicas, replica)
+	}
+
+	s.state.status[k] = &status
+	s.state.logs[k] = &logs
+	s.state.events[k] = append(s.state.events[k], events...)
+
+	if s.state.loadBalancers[k] == nil {
+		s.state.loadBalancers[k] = &client.LoadBalancer{
+			Name:    k,
+			Type:    "elb",
+			DNSName: fmt.Sprintf("%s-%s.%s.elb.mock", shipment.ParentShipment.Name, shipment.Name, barge),
+			State:   "active",
+		}
+	}
+}
+
+//customs
+
+func (s *Server) isCataloged(w http.ResponseWriter, r *http.Request, params []string) {
+	if !s.state.catalog[catalogKey(params[0], params[1])] {
+		writeError(w, http.StatusNotFound, "not cataloged")
+		return
+	}
+	writeJSON(w, http.StatusOK, client.CatalogitContainer{Name: params[0], Version: params[1]})
+}
+
+// validates the build token used by the customs api
+func (s *Server) buildTokenShipment(w http.ResponseWriter, r *http.Request, shipment string, env string) *client.ShipmentEnvironment {
+	result := s.findShipment(w, shipment, env)
+	if result == nil {
+		return nil
+	}
+	if r.Header.Get("x-build-token") != result.BuildToken {
+		writeError(w, http.StatusUnauthorized, "invalid build token")
+		return nil
+	}
+	return result
+}
+
+func (s *Server) customsCatalog(w http.ResponseWriter, r *http.Request, params []string) {
+	if s.buildTokenShipment(w, r, params[0], params[1]) == nil {
+		return
+	}
+	s.catalogit(w, r, params)
+}
+
+func (s *Server) customsDeploy(w http.ResponseWriter, r *http.Request, params []string) {
+	shipment := s.buildTokenShipment(w, r, params[0], params[1])
+	if shipment == nil {
+		return
+	}
+	var request client.DeployRequest
+	if err := readJSON(r, &request); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	container := findContainer(w, shipment, request.Name)
+	if container == nil {
+		return
+	}
+	if request.Catalog {
+		s.state.catalog[catalogKey(request.Name, request.Version)] = true
+	}
+	container.Image = request.Image
+	s.rollout(shipment)
+	writeJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+//catalogit
+
+func (s *Server) catalogit(w http.ResponseWriter, r *http.Request, params []string) {
+	var container client.CatalogitContainer
+	if err := readJSON(r, &container); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.state.catalog[catalogKey(container.Name, container.Version)] = true
+	writeJSON(w, http.StatusOK, container)
+}
+
+//barges
+
+func (s *Server) getBarges(w http.ResponseWriter, r *http.Request, params []string) {
+	barges := s.state.barges
+	if barges == nil {
+		barges = []client.Barge{}
+	}
+	writeJSON(w, http.StatusOK, client.BargeResults{Barges: barges})
+}
+
+func (s *Server) getGroup(w http.ResponseWriter, r *http.Request, params []string) {
+	group, ok := s.state.groups[params[0]]
+	if !ok {
+		writeError(w, http.StatusNotFound, "group not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, group)
+}
@@ -0,0 +1,184 @@
+package mockserver
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/turnerlabs/harbor-compose/harbor/client"
+)
+
+func newTestClient(seed *Seed) (*client.Client, *httptest.Server) {
+	server := httptest.NewServer(New(seed))
+	c := client.New(client.Config{
+		ShipitURI:    server.URL,
+		HelmitURI:    server.URL,
+		TriggerURI:   server.URL,
+		CustomsURI:   server.URL,
+		CatalogitURI: server.URL,
+		BargesURI:    server.URL,
+		Username:     "user",
+		Token:        "token",
+	})
+	return c, server
+}
+
+func getTestShipment() client.ShipmentEnvironment {
+	return client.ShipmentEnvironment{
+		Name:           "dev",
+		ParentShipment: client.ParentShipment{Name: "my-app", Group: "mss"},
+		Containers: []client.ContainerPayload{
+			{
+				Name:  "web",
+				Image: "registry/web:1.0",
+				Ports: []client.PortPayload{
+					{Name: "PORT", Value: 5000, PublicPort: 80, Primary: true, Healthcheck: "/health"},
+				},
+			},
+		},
+		Providers: []client.ProviderPayload{
+			{Name: "ec2", Replicas: 2, Barge: "corp-sandbox"},
+		},
+	}
+}
+
+func TestShipmentLifecycle(t *testing.T) {
+	c, server := newTestClient(nil)
+	defer server.Close()
+	ctx := context.Background()
+
+	_, err := c.Shipit.GetShipmentEnvironment(ctx, "my-app", "dev")
+	assert.Equal(t, client.ErrNotFound, err)
+
+	err = c.Shipit.CreateShipmentEnvironment(ctx, getTestShipment())
+	assert.Nil(t, err)
+
+	shipment, err := c.Shipit.GetShipmentEnvironment(ctx, "my-app", "dev")
+	assert.Nil(t, err)
+	assert.Equal(t, "web", shipment.Containers[0].Name)
+	assert.NotEmpty(t, shipment.BuildToken)
+
+	err = c.Shipit.UpdateProvider(ctx, "my-app", "dev", client.ProviderPayload{Name: "ec2", Replicas: 3})
+	assert.Nil(t, err)
+
+	err = c.Shipit.UpdateContainer(ctx, "my-app", "dev", client.ContainerPayload{Name: "web", Image: "registry/web:2.0"})
+	assert.Nil(t, err)
+
+	shipment, err = c.Shipit.GetShipmentEnvironment(ctx, "my-app", "dev")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, shipment.Providers[0].Replicas)
+	assert.Equal(t, "registry/web:2.0", shipment.Containers[0].Image)
+
+	err = c.Shipit.CreateContainer(ctx, "my-app", "dev", client.ContainerPayload{Name: "sidecar", Image: "registry/sidecar:1.0"})
+	assert.Nil(t, err)
+
+	err = c.Shipit.CreatePort(ctx, "my-app", "dev", "sidecar", client.PortPayload{Name: "PORT", Value: 8080, PublicPort: 8080})
+	assert.Nil(t, err)
+
+	err = c.Shipit.DeleteContainer(ctx, "my-app", "dev", "web")
+	assert.Nil(t, err)
+
+	shipment, err = c.Shipit.GetShipmentEnvironment(ctx, "my-app", "dev")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(shipment.Containers))
+	assert.Equal(t, "sidecar", shipment.Containers[0].Name)
+	assert.Equal(t, 8080, shipment.Containers[0].Ports[0].Value)
+
+	err = c.Shipit.DeleteShipmentEnvironment(ctx, "my-app", "dev")
+	assert.Nil(t, err)
+
+	_, err = c.Shipit.GetShipmentEnvironment(ctx, "my-app", "dev")
+	assert.Equal(t, client.ErrNotFound, err)
+}
+
+func TestEnvVars(t *testing.T) {
+	c, server := newTestClient(&Seed{Shipments: []client.ShipmentEnvironment{getTestShipment()}})
+	defer server.Close()
+	ctx := context.Background()
+
+	_, err := c.Shipit.GetEnvVar(ctx, "my-app", "dev", "web", "FOO")
+	assert.Equal(t, client.ErrNotFound, err)
+
+	err = c.Shipit.CreateEnvVar(ctx, "my-app", "dev", "web", client.EnvVarPayload{Name: "FOO", Value: "bar", Type: "basic"})
+	assert.Nil(t, err)
+
+	err = c.Shipit.UpdateEnvVar(ctx, "my-app", "dev", "web", client.EnvVarPayload{Name: "FOO", Value: "baz", Type: "basic"})
+	assert.Nil(t, err)
+
+	envVar, err := c.Shipit.GetEnvVar(ctx, "my-app", "dev", "web", "FOO")
+	assert.Nil(t, err)
+	assert.Equal(t, "baz", envVar.Value)
+
+	//environment level
+	err = c.Shipit.CreateEnvVar(ctx, "my-app", "dev", "", client.EnvVarPayload{Name: "ENV", Value: "dev", Type: "basic"})
+	assert.Nil(t, err)
+
+	shipment, err := c.Shipit.GetShipmentEnvironment(ctx, "my-app", "dev")
+	assert.Nil(t, err)
+	assert.Equal(t, "ENV", shipment.EnvVars[0].Name)
+
+	err = c.Shipit.DeleteEnvVar(ctx, "my-app", "dev", "web", "FOO")
+	assert.Nil(t, err)
+
+	_, err = c.Shipit.GetEnvVar(ctx, "my-app", "dev", "web", "FOO")
+	assert.Equal(t, client.ErrNotFound, err)
+}
+
+func TestTriggerRollsOut(t *testing.T) {
+	c, server := newTestClient(&Seed{Shipments: []client.ShipmentEnvironment{getTestShipment()}})
+	defer server.Close()
+	ctx := context.Background()
+
+	status, err := c.Helmit.GetShipmentStatus(ctx, "corp-sandbox", "my-app", "dev")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(status.Status.Containers))
+
+	messages, err := c.Trigger.Trigger(ctx, "my-app", "dev")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"my-app.dev.services.ec2.dmtio.net:80"}, messages)
+
+	status, err = c.Helmit.GetShipmentStatus(ctx, "corp-sandbox", "my-app", "dev")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(status.Status.Containers))
+	assert.True(t, status.Status.Containers[0].Ready)
+	assert.Equal(t, "running", status.Status.Containers[0].Status)
+
+	logs, err := c.Helmit.GetLogs(ctx, "corp-sandbox", "my-app", "dev")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(logs.Replicas))
+	assert.Contains(t, logs.Replicas[0].Containers[0].Logstream, server.URL)
+
+	events, err := c.Helmit.GetShipmentEvents(ctx, "corp-sandbox", "my-app", "dev")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(events.Events))
+
+	lb, err := c.Trigger.GetLoadBalancerStatus(ctx, "my-app", "dev", "ec2")
+	assert.Nil(t, err)
+	assert.Equal(t, "active", lb.State)
+}
+
+func TestCustoms(t *testing.T) {
+	c, server := newTestClient(&Seed{Shipments: []client.ShipmentEnvironment{getTestShipment()}})
+	defer server.Close()
+	ctx := context.Background()
+
+	shipment, err := c.Shipit.GetShipmentEnvironment(ctx, "my-app", "dev")
+	assert.Nil(t, err)
+
+	request := client.DeployRequest{Name: "web", Image: "registry/web:2.0", Version: "2.0", Catalog: true}
+
+	err = c.Customs.Deploy(ctx, "my-app", "dev", "ec2", "wrong", request)
+	assert.NotNil(t, err)
+
+	err = c.Customs.Deploy(ctx, "my-app", "dev", "ec2", shipment.BuildToken, request)
+	assert.Nil(t, err)
+
+	cataloged, err := c.Customs.IsContainerVersionCataloged(ctx, "web", "2.0")
+	assert.Nil(t, err)
+	assert.True(t, cataloged)
+
+	cataloged, err = c.Customs.IsContainerVersionCataloged(ctx, "web", "3.0")
+	assert.Nil(t, err)
+	assert.False(t, cataloged)
+}
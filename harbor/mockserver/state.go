@@ -0,0 +1,103 @@
+package mockserver
+
+import (
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/turnerlabs/harbor-compose/harbor/client"
+)
+
+// Seed is the json representation of the data that a Server starts with
+type Seed struct {
+	Shipments     []client.ShipmentEnvironment      `json:"shipments"`
+	Catalog       []client.CatalogitContainer       `json:"catalog"`
+	Barges        []client.Barge                    `json:"barges"`
+	Groups        []client.Group                    `json:"groups"`
+	Status        map[string]client.ShipmentStatus  `json:"status"`
+	Events        map[string][]client.ShipmentEvent `json:"events"`
+	Logs          map[string]client.HelmitResponse  `json:"logs"`
+	LoadBalancers map[string]client.LoadBalancer    `json:"loadBalancers"`
+}
+
+// LoadSeed reads seed data from a json file
+func LoadSeed(file string) (*Seed, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var seed Seed
+	if err := json.Unmarshal(data, &seed); err != nil {
+		return nil, err
+	}
+	return &seed, nil
+}
+
+// in-memory state of all of the fake harbor services
+type state struct {
+	shipments     map[string]*client.ShipmentEnvironment
+	catalog       map[string]bool
+	barges        []client.Barge
+	groups        map[string]client.Group
+	status        map[string]*client.ShipmentStatus
+	events        map[string][]client.ShipmentEvent
+	logs          map[string]*client.HelmitResponse
+	loadBalancers map[string]*client.LoadBalancer
+	tokens        map[string]string
+	containerIDs  int
+}
+
+func newState(seed *Seed) *state {
+	s := &state{
+		shipments:     map[string]*client.ShipmentEnvironment{},
+		catalog:       map[string]bool{},
+		groups:        map[string]client.Group{},
+		status:        map[string]*client.ShipmentStatus{},
+		events:        map[string][]client.ShipmentEvent{},
+		logs:          map[string]*client.HelmitResponse{},
+		loadBalancers: map[string]*client.LoadBalancer{},
+		tokens:        map[string]string{},
+	}
+
+	if seed == nil {
+		return s
+	}
+
+	for i := range seed.Shipments {
+		shipment := seed.Shipments[i]
+		s.shipments[key(shipment.ParentShipment.Name, shipment.Name)] = &shipment
+	}
+	for _, c := range seed.Catalog {
+		s.catalog[catalogKey(c.Name, c.Version)] = true
+	}
+	s.barges = seed.Barges
+	for _, g := range seed.Groups {
+		s.groups[g.ID] = g
+	}
+	for k, v := range seed.Status {
+		status := v
+		s.status[k] = &status
+	}
+	for k, v := range seed.Events {
+		s.events[k] = v
+	}
+	for k, v := range seed.Logs {
+		logs := v
+		s.logs[k] = &logs
+	}
+	for k, v := range seed.LoadBalancers {
+		lb := v
+		s.loadBalancers[k] = &lb
+	}
+
+	return s
+}
+
+// returns the key used to store data for a shipment environment
+func key(shipment string, env string) string {
+	return shipment + "/" + env
+}
+
+func catalogKey(name string, version string) string {
+	return name + ":" + version
+}
@@ -2,7 +2,7 @@
 set -uo pipefail
 
 # run tests and convert them to junit for circle
-go test -v ./cmd | tee go-test.out
+go test -v ./cmd ./harbor/... | tee go-test.out
 PASS=$?
 echo ""
 